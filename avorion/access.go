@@ -0,0 +1,240 @@
+package avorion
+
+import (
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"errors"
+	"strconv"
+	"time"
+)
+
+const (
+	errAccessOffline = "The server has to have been started to manage its access lists"
+	errAccessNoSteam = "Could not find the Steam ID of %s"
+	kickAccessDenied = "You are not allowed to join this server"
+	kickAccessNoRole = "Your Discord account does not have the role needed to join this server"
+	kickAccessNoLink = "This server requires a linked Discord account. Use /linkdiscordacct to link yours"
+	mailAccessNoLink = "This server only allows players that have linked their Discord account. " +
+		"Type /linkdiscordacct in chat and follow the instructions in the mail that you " +
+		"receive. You will be kicked in %s if your account has not been linked by then."
+	mailAccessHeader = "Discord Account Required"
+	kickAccessFailed = "Your access to this server could not be checked, please try again later"
+
+	// The Steam ID of a player that just joined can take a moment to be known
+	accessLookupAttempts = 3
+	accessLookupDelay    = 10 * time.Second
+)
+
+// checkLinkedRole kicks a linked player whose Discord account doesn't have the
+// role that is needed to join
+func (s *Server) checkLinkedRole(p ifaces.IPlayer) {
+	if s.accessresolver == nil {
+		logger.LogWarning(s, "Cannot check the Discord role of "+p.Name()+
+			", as Discord is not ready")
+		return
+	}
+
+	if !s.accessresolver(p.DiscordUID()) {
+		p.Kick(kickAccessNoRole)
+	}
+}
+
+// accessFor returns the explicit access of a player, looking up their Steam ID.
+// The lookup is retried a few times before it is given up on.
+func (s *Server) accessFor(p ifaces.IPlayer) (allowed, denied bool, err error) {
+	var steamid int64
+	for i := 0; i < accessLookupAttempts && p.Online(); i++ {
+		if i > 0 {
+			time.Sleep(accessLookupDelay)
+		}
+
+		if steamid = p.SteamUID(); steamid != 0 {
+			break
+		}
+	}
+
+	if steamid == 0 {
+		return false, false, errors.New(sprintf(errAccessNoSteam, p.Name()))
+	}
+
+	db := s.trackingDB()
+	if db == nil {
+		return false, false, errors.New(errAccessOffline)
+	}
+
+	return db.Access(steamid)
+}
+
+// denyListed returns whether anyone is on the deny list of the server. It errs
+// on the side of caution when the list can't be read.
+func (s *Server) denyListed() bool {
+	db := s.trackingDB()
+	if db == nil {
+		return true
+	}
+
+	entries, err := db.AccessList()
+	if err != nil {
+		return true
+	}
+
+	for _, e := range entries {
+		if !e.Allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+/******************************/
+/* IFace ifaces.IAccessServer */
+/******************************/
+
+// SetAccessResolver sets the function that reports whether a Discord account
+// has the role that is needed to join in linked-only mode
+func (s *Server) SetAccessResolver(f func(string) bool) {
+	s.accessresolver = f
+}
+
+// EnforceAccess kicks a player that isn't allowed on the server. Players on the
+// deny list are always kicked, and players on the allow list never are. When
+// the access of a player can't be checked while anyone is denied, they are
+// kicked as well. In linked-only mode, players without a linked Discord account
+// are given the configured grace period to link one.
+func (s *Server) EnforceAccess(p ifaces.IPlayer) {
+	allowed, denied, err := s.accessFor(p)
+	switch {
+	case err != nil:
+		logger.LogWarning(s, sprintf("Failed to check the access of %s: %s",
+			p.Name(), err.Error()))
+
+		// The player could be on the deny list, so they can't be let in
+		if s.denyListed() {
+			p.Kick(kickAccessFailed)
+			return
+		}
+	case denied:
+		p.Kick(kickAccessDenied)
+		return
+	case allowed:
+		return
+	}
+
+	if linked, _ := s.config.LinkedOnly(); !linked {
+		return
+	}
+
+	if p.DiscordUID() != "" {
+		s.checkLinkedRole(p)
+		return
+	}
+
+	grace := s.config.LinkedOnlyGrace()
+	if _, err := s.RunCommand(sprintf(`sendmail -i %s -h "%s" -- %s`, p.Index(),
+		mailAccessHeader, sprintf(mailAccessNoLink, grace))); err != nil {
+		logger.LogWarning(s, "Failed to mail link instructions: "+err.Error())
+	}
+
+	time.AfterFunc(grace, func() {
+		if !s.IsUp() || !p.Online() {
+			return
+		}
+
+		if p.DiscordUID() == "" {
+			p.Kick(kickAccessNoLink)
+			return
+		}
+
+		s.checkLinkedRole(p)
+	})
+}
+
+// AccessList returns the players that are explicitly allowed or denied
+func (s *Server) AccessList() ([]ifaces.AccessEntry, error) {
+	db := s.trackingDB()
+	if db == nil {
+		return nil, errors.New(errAccessOffline)
+	}
+	return db.AccessList()
+}
+
+// SetAccess allows or denies a player regardless of their Discord account, and
+// kicks them if they are online and no longer allowed
+func (s *Server) SetAccess(e ifaces.AccessEntry) error {
+	db := s.trackingDB()
+	if db == nil {
+		return errors.New(errAccessOffline)
+	}
+
+	if err := db.SetAccess(e); err != nil {
+		return err
+	}
+
+	verb := "denied"
+	if e.Allowed {
+		verb = "allowed"
+	}
+	s.SendLog(ifaces.ChatData{Msg: sprintf("`%s` (%d) is now %s on %s", e.Name,
+		e.SteamID, verb, s.config.Galaxy())})
+
+	if !e.Allowed {
+		for _, p := range s.players {
+			// Players that just joined may not have their Steam ID looked up yet
+			if p.Online() && p.SteamUID() == e.SteamID {
+				p.Kick(kickAccessDenied)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ClearAccess removes a player from the allow and deny lists
+func (s *Server) ClearAccess(steamid int64) (bool, error) {
+	db := s.trackingDB()
+	if db == nil {
+		return false, errors.New(errAccessOffline)
+	}
+
+	ok, err := db.ClearAccess(steamid)
+	if ok {
+		s.SendLog(ifaces.ChatData{Msg: sprintf("%d was removed from the access "+
+			"lists of %s", steamid, s.config.Galaxy())})
+	}
+
+	return ok, err
+}
+
+// ResolveSteamID finds the Steam ID and name of a player given their index,
+// name or Steam ID
+func (s *Server) ResolveSteamID(in string) (int64, string, error) {
+	p := s.Player(in)
+	if p == nil {
+		p = s.PlayerFromName(in)
+	}
+
+	if p == nil {
+		for _, pl := range s.players {
+			if sprintf("%d", pl.steam64) == in {
+				p = pl
+				break
+			}
+		}
+	}
+
+	if p != nil {
+		if sid := p.SteamUID(); sid != 0 {
+			return sid, p.Name(), nil
+		}
+		return 0, "", errors.New(sprintf(errAccessNoSteam, p.Name()))
+	}
+
+	// Players that haven't been seen yet can only be added by their Steam ID
+	sid, err := strconv.ParseInt(in, 10, 64)
+	if err != nil || len(in) < 15 {
+		return 0, "", errors.New(sprintf(errAccessNoSteam, in))
+	}
+
+	return sid, in, nil
+}
@@ -0,0 +1,131 @@
+package avorion
+
+import (
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"errors"
+	"strings"
+)
+
+const errAdminSyncOffline = "The server has to be running to update its admins"
+
+/*********************************/
+/* IFace ifaces.IAdminSyncServer */
+/*********************************/
+
+// SyncAdmins brings the in-game admin list in line with Discord. Players that
+// have linked their Discord account are made admins when isAdmin returns true
+// for their Discord ID, and the admins that were made that way are removed once
+// it no longer does. Admins that were added by other means are left alone, as
+// are the ones whose Discord roles could not be looked up.
+func (s *Server) SyncAdmins(isAdmin func(string) (bool, error)) (
+	[]ifaces.AdminChange, error) {
+	logger.LogDebug(s, "SyncAdmins() was called")
+
+	s.adminmutex.Lock()
+	defer s.adminmutex.Unlock()
+
+	db := s.trackingDB()
+	if !s.IsUp() || db == nil {
+		return nil, errors.New(errAdminSyncOffline)
+	}
+
+	managed, err := db.Admins()
+	if err != nil {
+		return nil, err
+	}
+
+	// Players don't have to be online, or even loaded, to be admins
+	linked, err := db.Integrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		changes = make([]ifaces.AdminChange, 0)
+		wanted  = make(map[string]bool)
+	)
+
+	for _, in := range linked {
+		admin, err := isAdmin(in.Discord)
+		if err != nil {
+			logger.LogWarning(s, sprintf("Could not look up the roles of %s: %s",
+				in.Name, err.Error()))
+			wanted[in.Index] = true
+			continue
+		}
+
+		if !admin {
+			continue
+		}
+
+		wanted[in.Index] = true
+		if _, ok := managed[in.Index]; ok {
+			continue
+		}
+
+		if p := s.Player(in.Index); p != nil {
+			in.Name = p.Name()
+		} else if in.Name == "" {
+			in.Name = "player:" + in.Index
+		}
+
+		if _, err := s.RunCommand(sprintf("admin -a %s", in.Index)); err != nil {
+			logger.LogError(s, "Failed to add admin: "+err.Error())
+			continue
+		}
+
+		if err := db.AddAdmin(in.Index, in.Name); err != nil {
+			logger.LogError(s, "Failed to record admin: "+err.Error())
+		}
+
+		changes = append(changes, ifaces.AdminChange{
+			Index: in.Index, Name: in.Name, Discord: in.Discord, Added: true})
+	}
+
+	for index, name := range managed {
+		if wanted[index] {
+			continue
+		}
+
+		if _, err := s.RunCommand(sprintf("admin -r %s", index)); err != nil {
+			logger.LogError(s, "Failed to remove admin: "+err.Error())
+			continue
+		}
+
+		if err := db.RemoveAdmin(index); err != nil {
+			logger.LogError(s, "Failed to clear admin: "+err.Error())
+		}
+
+		change := ifaces.AdminChange{Index: index, Name: name}
+		for _, in := range linked {
+			if in.Index == index {
+				change.Discord = in.Discord
+				break
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	if len(changes) > 0 {
+		lines := make([]string, 0, len(changes))
+		for _, c := range changes {
+			verb := "Removed"
+			if c.Added {
+				verb = "Added"
+			}
+
+			logger.LogInfo(s, sprintf("%s in-game admin: %s", verb, c.Name))
+			line := sprintf("• %s `%s`", verb, c.Name)
+			if c.Discord != "" {
+				line += sprintf(" (<@%s>)", c.Discord)
+			}
+			lines = append(lines, line)
+		}
+
+		s.SendLog(ifaces.ChatData{Msg: sprintf("**Admins of %s were updated:**\n%s",
+			s.config.Galaxy(), strings.Join(lines, "\n"))})
+	}
+
+	return changes, nil
+}
@@ -72,7 +72,9 @@ func (a *Alliance) AddJump(sc ifaces.ShipCoordData) {
 	s.Jumphistory = append(s.Jumphistory, jump)
 
 	id, _ := strconv.Atoi(a.Index())
-	a.server.tracking.AddJump(s.Index, int64(id), 1, *jump)
+	if db := a.server.trackingDB(); db != nil {
+		db.AddJump(s.Index, int64(id), 1, *jump)
+	}
 
 	logger.LogDebug(a, "Updated jumphistory")
 }
@@ -0,0 +1,466 @@
+package avorion
+
+import (
+	"archive/tar"
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	backupTimeFormat  = "20060102-150405"
+	backupExtension   = ".tar.gz"
+	checksumExtension = ".sha256"
+
+	errBackupNotFound = "No backup with that name: %s"
+	errBackupChecksum = "Checksum mismatch for backup %s, refusing to restore it"
+	errBackupNoGalaxy = "Galaxy directory does not exist: %s"
+	errBackupInvalid  = "Backup %s does not contain the galaxy %s"
+	errBackupBadPath  = "Backup contains an invalid path: %s"
+)
+
+// galaxyPath returns the directory that the galaxy is stored in
+func (s *Server) galaxyPath() string {
+	return strings.TrimSuffix(s.config.DataPath(), "/") + "/" + s.config.Galaxy()
+}
+
+// writeBackup archives the galaxy directory into w
+func (s *Server) writeBackup(w io.Writer) error {
+	var (
+		src  = s.galaxyPath()
+		base = filepath.Dir(src)
+		gz   = gzip.NewWriter(w)
+		tw   = tar.NewWriter(gz)
+	)
+
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			return err
+		}
+
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+
+		hdr.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+// readBackup extracts an archive into the directory dest
+func readBackup(file, dest string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	dest = filepath.Clean(dest)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dest, filepath.FromSlash(hdr.Name))
+		if !strings.HasPrefix(target, dest+string(os.PathSeparator)) {
+			return errors.New(sprintf(errBackupBadPath, hdr.Name))
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(hdr.Mode)|0700); err != nil {
+				return err
+			}
+
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY,
+				os.FileMode(hdr.Mode))
+			if err != nil {
+				return err
+			}
+
+			_, err = io.Copy(out, tr)
+			out.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// swapGalaxy moves the galaxy directory to previous and the restored galaxy into
+// its place. If that fails, the original galaxy is put back.
+func swapGalaxy(restored, galaxy, previous string) error {
+	moved := false
+	os.RemoveAll(previous)
+	if _, err := os.Stat(galaxy); err == nil {
+		if err := os.Rename(galaxy, previous); err != nil {
+			return err
+		}
+		moved = true
+	}
+
+	if err := os.Rename(restored, galaxy); err != nil {
+		if moved {
+			if rerr := os.Rename(previous, galaxy); rerr != nil {
+				return errors.New(err.Error() + ", and the galaxy could not be " +
+					"moved back from " + previous + ": " + rerr.Error())
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// fileChecksum returns the hex encoded sha256 sum of a file
+func fileChecksum(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// readChecksum returns the checksum recorded alongside a backup, in the same
+// format as sha256sum
+func readChecksum(file string) string {
+	f, err := os.Open(file + checksumExtension)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	if scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+			return fields[0]
+		}
+	}
+
+	return ""
+}
+
+// pruneBackups removes the backups that fall outside of the retention policy.
+// The newest backup in each of the last N hours and M days is kept.
+func (s *Server) pruneBackups() {
+	backups, err := s.Backups()
+	if err != nil {
+		logger.LogError(s, "Failed to list backups for pruning: "+err.Error())
+		return
+	}
+
+	var (
+		hourly, daily = s.config.BackupRetention()
+		hours         = make(map[string]bool)
+		days          = make(map[string]bool)
+		dir           = s.config.BackupPath()
+	)
+
+	for i, b := range backups {
+		keep := i == 0
+		hour := b.Time.Format("2006010215")
+		day := b.Time.Format("20060102")
+
+		if !hours[hour] && len(hours) < hourly {
+			hours[hour] = true
+			keep = true
+		}
+
+		if !days[day] && len(days) < daily {
+			days[day] = true
+			keep = true
+		}
+
+		if keep {
+			continue
+		}
+
+		logger.LogInfo(s, "Pruning backup: "+b.Name)
+		if err := os.Remove(dir + "/" + b.Name); err != nil {
+			logger.LogError(s, "Failed to remove backup: "+err.Error())
+			continue
+		}
+		os.Remove(dir + "/" + b.Name + checksumExtension)
+	}
+}
+
+// backupFile resolves the name of a backup to its file
+func (s *Server) backupFile(name string) (string, error) {
+	name = strings.TrimSuffix(filepath.Base(name), backupExtension) + backupExtension
+
+	backups, err := s.Backups()
+	if err != nil {
+		return "", err
+	}
+
+	for _, b := range backups {
+		if b.Name == name {
+			return s.config.BackupPath() + "/" + b.Name, nil
+		}
+	}
+
+	return "", errors.New(sprintf(errBackupNotFound, name))
+}
+
+/******************************/
+/* IFace ifaces.IBackupServer */
+/******************************/
+
+// Backups returns the backups of the galaxy, newest first
+func (s *Server) Backups() ([]ifaces.BackupInfo, error) {
+	var (
+		dir    = s.config.BackupPath()
+		prefix = s.config.Galaxy() + "-"
+	)
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []ifaces.BackupInfo{}, nil
+		}
+		return nil, err
+	}
+
+	backups := make([]ifaces.BackupInfo, 0)
+	for _, f := range files {
+		name := f.Name()
+		if !f.Mode().IsRegular() || !strings.HasPrefix(name, prefix) ||
+			!strings.HasSuffix(name, backupExtension) {
+			continue
+		}
+
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), backupExtension)
+		t, err := time.ParseInLocation(backupTimeFormat, stamp, time.UTC)
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, ifaces.BackupInfo{
+			Name:     name,
+			Time:     t,
+			Size:     f.Size(),
+			Checksum: readChecksum(dir + "/" + name)})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+
+	return backups, nil
+}
+
+// CreateBackup saves the galaxy and snapshots it into a compressed archive
+func (s *Server) CreateBackup() (ifaces.BackupInfo, error) {
+	s.backupmutex.Lock()
+	defer s.backupmutex.Unlock()
+
+	var (
+		info ifaces.BackupInfo
+		dir  = s.config.BackupPath()
+		now  = time.Now().UTC()
+	)
+
+	if _, err := os.Stat(s.galaxyPath()); err != nil {
+		return info, errors.New(sprintf(errBackupNoGalaxy, s.galaxyPath()))
+	}
+
+	if s.IsUp() {
+		if _, err := s.RunCommandContext(context.Background(),
+			ifaces.CommandPriorityHealth, "save"); err != nil {
+			logger.LogWarning(s, "Failed to save before backup: "+err.Error())
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return info, err
+	}
+
+	info.Name = s.config.Galaxy() + "-" + now.Format(backupTimeFormat) +
+		backupExtension
+	info.Time = now.Truncate(time.Second)
+	file := dir + "/" + info.Name
+
+	logger.LogInfo(s, "Creating backup: "+file)
+	f, err := os.Create(file + ".partial")
+	if err != nil {
+		return info, err
+	}
+
+	h := sha256.New()
+	err = s.writeBackup(io.MultiWriter(f, h))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		os.Remove(file + ".partial")
+		return info, err
+	}
+
+	if err := os.Rename(file+".partial", file); err != nil {
+		return info, err
+	}
+
+	info.Checksum = hex.EncodeToString(h.Sum(nil))
+	if err := ioutil.WriteFile(file+checksumExtension,
+		[]byte(info.Checksum+"  "+info.Name+"\n"), 0644); err != nil {
+		logger.LogError(s, "Failed to write backup checksum: "+err.Error())
+	}
+
+	if st, err := os.Stat(file); err == nil {
+		info.Size = st.Size()
+	}
+
+	s.pruneBackups()
+	logger.LogInfo(s, "Created backup: "+info.Name)
+	return info, nil
+}
+
+// RestoreBackup replaces the galaxy with the contents of a backup. If the
+// server is running it is stopped while the galaxy is swapped, and started
+// again afterwards. The replaced galaxy is kept until the next restore.
+func (s *Server) RestoreBackup(name string) error {
+	s.backupmutex.Lock()
+	defer s.backupmutex.Unlock()
+
+	file, err := s.backupFile(name)
+	if err != nil {
+		return err
+	}
+
+	if expected := readChecksum(file); expected != "" {
+		sum, err := fileChecksum(file)
+		if err != nil {
+			return err
+		}
+
+		if sum != expected {
+			return errors.New(sprintf(errBackupChecksum, filepath.Base(file)))
+		}
+	} else {
+		logger.LogWarning(s, "No checksum recorded for backup: "+file)
+	}
+
+	var (
+		galaxy   = s.config.Galaxy()
+		datapath = strings.TrimSuffix(s.config.DataPath(), "/")
+		tmp      = datapath + "/." + galaxy + ".restore"
+		previous = datapath + "/." + galaxy + ".previous"
+	)
+
+	// Extract the backup before stopping the server so that a bad archive
+	// doesn't cause any downtime
+	os.RemoveAll(tmp)
+	if err := readBackup(file, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if _, err := os.Stat(tmp + "/" + galaxy); err != nil {
+		return errors.New(sprintf(errBackupInvalid, filepath.Base(file), galaxy))
+	}
+
+	wasup := s.IsUp()
+	if wasup {
+		if err := s.Stop(true); err != nil {
+			return err
+		}
+	}
+
+	if err := swapGalaxy(tmp+"/"+galaxy, s.galaxyPath(), previous); err != nil {
+		logger.LogError(s, "Failed to restore backup: "+err.Error())
+		if wasup {
+			if serr := s.Start(true); serr != nil {
+				logger.LogError(s, "Failed to restart after a failed restore: "+
+					serr.Error())
+			}
+		}
+		return err
+	}
+
+	logger.LogInfo(s, "Restored backup: "+filepath.Base(file))
+	s.SendLog(ifaces.ChatData{Msg: sprintf("Restored galaxy from backup `%s`",
+		filepath.Base(file))})
+
+	if wasup {
+		return s.Start(true)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package avorion
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeGalaxy creates a galaxy directory that holds a single marker file
+func writeGalaxy(t *testing.T, dir, marker string) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "marker"), []byte(marker),
+		0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// galaxyMarker returns the marker of a galaxy directory, or "" if it has none
+func galaxyMarker(dir string) string {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "marker"))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func TestSwapGalaxy(t *testing.T) {
+	var (
+		dir      = t.TempDir()
+		restored = filepath.Join(dir, ".Galaxy.restore", "Galaxy")
+		galaxy   = filepath.Join(dir, "Galaxy")
+		previous = filepath.Join(dir, ".Galaxy.previous")
+	)
+
+	writeGalaxy(t, restored, "restored")
+	writeGalaxy(t, galaxy, "current")
+	writeGalaxy(t, previous, "stale")
+
+	if err := swapGalaxy(restored, galaxy, previous); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := galaxyMarker(galaxy); got != "restored" {
+		t.Errorf("galaxy holds %q, expected the restored galaxy", got)
+	}
+
+	if got := galaxyMarker(previous); got != "current" {
+		t.Errorf("previous holds %q, expected the replaced galaxy", got)
+	}
+}
+
+func TestSwapGalaxyWithoutGalaxy(t *testing.T) {
+	var (
+		dir      = t.TempDir()
+		restored = filepath.Join(dir, ".Galaxy.restore", "Galaxy")
+		galaxy   = filepath.Join(dir, "Galaxy")
+		previous = filepath.Join(dir, ".Galaxy.previous")
+	)
+
+	writeGalaxy(t, restored, "restored")
+
+	if err := swapGalaxy(restored, galaxy, previous); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := galaxyMarker(galaxy); got != "restored" {
+		t.Errorf("galaxy holds %q, expected the restored galaxy", got)
+	}
+}
+
+func TestSwapGalaxyRollback(t *testing.T) {
+	var (
+		dir      = t.TempDir()
+		restored = filepath.Join(dir, ".Galaxy.restore", "Galaxy")
+		galaxy   = filepath.Join(dir, "Galaxy")
+		previous = filepath.Join(dir, ".Galaxy.previous")
+	)
+
+	// The restored galaxy is missing, so moving it into place fails
+	writeGalaxy(t, galaxy, "current")
+
+	if err := swapGalaxy(restored, galaxy, previous); err == nil {
+		t.Fatal("swapping in a missing galaxy succeeded")
+	}
+
+	if got := galaxyMarker(galaxy); got != "current" {
+		t.Errorf("galaxy holds %q after a failed swap, expected the original", got)
+	}
+
+	if _, err := os.Stat(previous); !os.IsNotExist(err) {
+		t.Errorf("the original galaxy was left in %s", previous)
+	}
+}
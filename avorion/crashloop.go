@@ -0,0 +1,175 @@
+package avorion
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// crashReportLines is the number of output lines included when paging the
+// log channel about a crash loop
+const crashReportLines = 20
+
+// outputBuffer is a ring buffer of the most recent lines of Avorion output
+type outputBuffer struct {
+	mutex *sync.Mutex
+	lines []string
+	next  int
+	full  bool
+}
+
+func newOutputBuffer(size int) *outputBuffer {
+	if size < 1 {
+		size = 1
+	}
+
+	return &outputBuffer{
+		mutex: new(sync.Mutex),
+		lines: make([]string, size)}
+}
+
+// Add appends a line to the buffer, overwriting the oldest line once full
+func (b *outputBuffer) Add(line string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.lines[b.next] = line
+	b.next = (b.next + 1) % len(b.lines)
+	b.full = b.full || b.next == 0
+}
+
+// Last returns up to n of the most recent lines, oldest first
+func (b *outputBuffer) Last(n int) []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	count := b.next
+	if b.full {
+		count = len(b.lines)
+	}
+
+	if n > count {
+		n = count
+	}
+
+	out := make([]string, 0, n)
+	for i := b.next - n; i < b.next; i++ {
+		out = append(out, b.lines[(i+len(b.lines))%len(b.lines)])
+	}
+
+	return out
+}
+
+// crashLoop tracks recent crashes so that a server that keeps crashing is not
+// restarted forever
+type crashLoop struct {
+	mutex   *sync.Mutex
+	crashes []time.Time
+	backoff time.Duration
+	looping bool
+	clear   chan struct{}
+}
+
+func newCrashLoop() *crashLoop {
+	return &crashLoop{
+		mutex: new(sync.Mutex),
+		clear: make(chan struct{}, 1)}
+}
+
+// Record registers a crash and returns how long to wait before restarting the
+// server, and whether or not this crash started a crash loop
+func (cl *crashLoop) Record(now time.Time, limit int, window, base,
+	max time.Duration) (time.Duration, bool) {
+	cl.mutex.Lock()
+	defer cl.mutex.Unlock()
+
+	crashes := make([]time.Time, 0, len(cl.crashes)+1)
+	for _, t := range cl.crashes {
+		if now.Sub(t) < window {
+			crashes = append(crashes, t)
+		}
+	}
+	cl.crashes = append(crashes, now)
+
+	switch {
+	case !cl.looping && len(cl.crashes) < limit:
+		return 0, false
+
+	case !cl.looping:
+		cl.looping = true
+		cl.backoff = base
+		return cl.backoff, true
+	}
+
+	cl.backoff *= 2
+	if cl.backoff > max {
+		cl.backoff = max
+	}
+
+	return cl.backoff, false
+}
+
+// Looping returns whether or not the server is in a crash loop, along with the
+// time of the last crash
+func (cl *crashLoop) Looping() (bool, time.Time) {
+	cl.mutex.Lock()
+	defer cl.mutex.Unlock()
+
+	if len(cl.crashes) == 0 {
+		return cl.looping, time.Time{}
+	}
+
+	return cl.looping, cl.crashes[len(cl.crashes)-1]
+}
+
+// Reset clears the crash history, and cancels any pending restart
+func (cl *crashLoop) Reset() bool {
+	cl.mutex.Lock()
+	defer cl.mutex.Unlock()
+
+	looping := cl.looping
+	cl.crashes = nil
+	cl.backoff = 0
+	cl.looping = false
+
+	select {
+	case cl.clear <- struct{}{}:
+	default:
+	}
+
+	return looping
+}
+
+// Wait blocks until a delayed restart is due. It returns false if the restart
+// was cancelled, either by Reset or by the exit channel closing.
+func (cl *crashLoop) Wait(d time.Duration, exit chan struct{}) bool {
+	// Drop any stale reset that happened while nothing was waiting
+	select {
+	case <-cl.clear:
+	default:
+	}
+
+	select {
+	case <-time.After(d):
+		return true
+	case <-cl.clear:
+		return false
+	case <-exit:
+		return false
+	}
+}
+
+// crashOutput formats the last lines of Avorion output for the log channel
+func crashOutput(lines []string) string {
+	if len(lines) == 0 {
+		return "_No output was recorded_"
+	}
+
+	out := strings.Join(lines, "\n")
+	out = strings.ReplaceAll(out, "```", "'''")
+	if len(out) > 1500 {
+		out = "..." + out[len(out)-1500:]
+	}
+
+	return "```\n" + out + "\n```"
+}
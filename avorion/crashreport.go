@@ -0,0 +1,95 @@
+package avorion
+
+import (
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	crashReportPrefix  = "avorioncontrol-crash-"
+	crashReportFormat  = "20060102-150405"
+	crashLogMaxSize    = 256 * 1024
+	crashUploadMaxSize = 7 * 1024 * 1024
+)
+
+// newCrashLogs returns the crash logs in the galaxy directory that were written
+// after the given time. Our own crash reports are ignored.
+func newCrashLogs(dir string, since time.Time) []string {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	logs := make([]string, 0)
+	for _, f := range files {
+		name := strings.ToLower(f.Name())
+		if !f.Mode().IsRegular() || strings.HasPrefix(name, crashReportPrefix) ||
+			!strings.Contains(name, "crash") || f.ModTime().Before(since) {
+			continue
+		}
+		logs = append(logs, filepath.Join(dir, f.Name()))
+	}
+
+	return logs
+}
+
+// writeCrashReport writes the recent output of Avorion along with any new crash
+// logs to a timestamped report in the galaxy directory, and posts it to the log
+// channel
+func (s *Server) writeCrashReport(code int) {
+	var (
+		now    = time.Now()
+		dir    = s.galaxyPath()
+		lines  = s.recent.Last(s.config.CrashReportLines())
+		report = new(bytes.Buffer)
+		name   = crashReportPrefix + now.Format(crashReportFormat) + ".log"
+	)
+
+	report.WriteString(sprintf("Galaxy:    %s\n", s.config.Galaxy()))
+	report.WriteString(sprintf("Time:      %s\n", now.Format(time.RFC1123)))
+	report.WriteString(sprintf("Exit code: %d\n", code))
+	report.WriteString(sprintf("Uptime:    %s\n\n", now.Sub(s.state.last).Round(time.Second)))
+
+	report.WriteString(sprintf("=== Last %d lines of output ===\n", len(lines)))
+	for _, line := range lines {
+		report.WriteString(line + "\n")
+	}
+
+	for _, file := range newCrashLogs(dir, s.state.last) {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			logger.LogError(s, "Failed to read crash log: "+err.Error())
+			continue
+		}
+
+		if len(content) > crashLogMaxSize {
+			content = content[len(content)-crashLogMaxSize:]
+		}
+
+		report.WriteString(sprintf("\n=== %s ===\n", filepath.Base(file)))
+		report.Write(content)
+		report.WriteString("\n")
+	}
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, report.Bytes(), 0644); err != nil {
+		logger.LogError(s, "Failed to write crash report: "+err.Error())
+	} else {
+		logger.LogInfo(s, "Wrote crash report: "+path)
+	}
+
+	content := report.Bytes()
+	if len(content) > crashUploadMaxSize {
+		content = content[:crashUploadMaxSize]
+	}
+
+	s.SendLog(ifaces.ChatData{
+		Msg: sprintf("**Server Error**: Avorion has exited with non-zero status "+
+			"code: `%d`\nThe crash report was saved to `%s`", code, path),
+		Files: []ifaces.ChatFile{{Name: name, Content: content}}})
+}
@@ -0,0 +1,198 @@
+package avorion
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	errCronFields = `cron spec must have 5 fields (minute hour day month weekday): %s`
+	errCronRange  = `cron field out of range (%d-%d): %s`
+	errCronValue  = `invalid cron field value: %s`
+)
+
+// cronDescriptors are the shorthand specs that we accept in place of the
+// standard five fields
+var cronDescriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *"}
+
+// cronSchedule is a parsed cron spec. Each field is a bitmask of the values
+// that it matches.
+type cronSchedule struct {
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	anyDay bool // Both dom and dow were *, so every day matches
+	spec   string
+	loc    *time.Location
+}
+
+// parseCron parses a standard five field cron spec, in the given location
+func parseCron(spec string, loc *time.Location) (*cronSchedule, error) {
+	var (
+		err    error
+		fields = strings.Fields(spec)
+		c      = &cronSchedule{spec: spec, loc: loc}
+	)
+
+	if len(fields) == 1 {
+		if expanded, ok := cronDescriptors[fields[0]]; ok {
+			fields = strings.Fields(expanded)
+		}
+	}
+
+	if len(fields) != 5 {
+		return nil, errors.New(sprintf(errCronFields, spec))
+	}
+
+	if c.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+
+	if c.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+
+	if c.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+
+	if c.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+
+	// Sunday can be given as either 0 or 7
+	if c.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+
+	c.anyDay = fields[2] == "*" && fields[4] == "*"
+	return c, nil
+}
+
+// parseCronField parses a single comma separated cron field into a bitmask
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		var (
+			err   error
+			step  = 1
+			start = min
+			end   = max
+		)
+
+		if i := strings.Index(part, "/"); i >= 0 {
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return 0, errors.New(sprintf(errCronValue, part))
+			}
+			part = part[:i]
+		}
+
+		switch {
+		case part == "*":
+
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			if start, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, errors.New(sprintf(errCronValue, part))
+			}
+			if end, err = strconv.Atoi(bounds[1]); err != nil {
+				return 0, errors.New(sprintf(errCronValue, part))
+			}
+
+		default:
+			if start, err = strconv.Atoi(part); err != nil {
+				return 0, errors.New(sprintf(errCronValue, part))
+			}
+
+			// A single value with a step runs from that value until the maximum
+			if step == 1 {
+				end = start
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return 0, errors.New(sprintf(errCronRange, min, max, part))
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+// Next returns the first time after t that matches the schedule
+func (c *cronSchedule) Next(t time.Time) time.Time {
+	t = t.In(c.loc).Truncate(time.Minute).Add(time.Minute)
+
+	// Any valid spec will match at least once within a few years (Feb 29)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, c.loc)
+			continue
+		}
+
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.loc)
+			continue
+		}
+
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, c.loc)
+			continue
+		}
+
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// matchDay follows the cron convention of matching either the day of the month
+// or the weekday when both are restricted
+func (c *cronSchedule) matchDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case c.anyDay:
+		return true
+	case c.dom == cronFull(1, 31):
+		return dow
+	case c.dow&cronFull(0, 6) == cronFull(0, 6):
+		return dom
+	}
+
+	return dom || dow
+}
+
+// cronFull returns the bitmask for an unrestricted field
+func cronFull(min, max int) uint64 {
+	var bits uint64
+	for v := min; v <= max; v++ {
+		bits |= 1 << uint(v)
+	}
+	return bits
+}
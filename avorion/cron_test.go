@@ -0,0 +1,167 @@
+package avorion
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// cronBits returns the bitmask of a field that matches the given values
+func cronBits(values ...int) uint64 {
+	var bits uint64
+	for _, v := range values {
+		bits |= 1 << uint(v)
+	}
+	return bits
+}
+
+func TestParseCron(t *testing.T) {
+	for _, tc := range []struct {
+		spec string
+		want cronSchedule
+		err  string
+	}{
+		{spec: "* * * * *", want: cronSchedule{minute: cronFull(0, 59),
+			hour: cronFull(0, 23), dom: cronFull(1, 31), month: cronFull(1, 12),
+			dow: cronFull(0, 7), anyDay: true}},
+		{spec: "*/15 0-6/2 1,15 */3 7", want: cronSchedule{
+			minute: cronBits(0, 15, 30, 45), hour: cronBits(0, 2, 4, 6),
+			dom: cronBits(1, 15), month: cronBits(1, 4, 7, 10), dow: cronBits(0, 7)}},
+		{spec: "5/20 1-3,10 * * 5-7", want: cronSchedule{minute: cronBits(5, 25, 45),
+			hour: cronBits(1, 2, 3, 10), dom: cronFull(1, 31), month: cronFull(1, 12),
+			dow: cronBits(0, 5, 6, 7)}},
+		{spec: "0 0 31 12 0", want: cronSchedule{minute: cronBits(0), hour: cronBits(0),
+			dom: cronBits(31), month: cronBits(12), dow: cronBits(0)}},
+		{spec: "  30   4 * * 1 ", want: cronSchedule{minute: cronBits(30),
+			hour: cronBits(4), dom: cronFull(1, 31), month: cronFull(1, 12),
+			dow: cronBits(1)}},
+		{spec: "@daily", want: cronSchedule{minute: cronBits(0), hour: cronBits(0),
+			dom: cronFull(1, 31), month: cronFull(1, 12), dow: cronFull(0, 7),
+			anyDay: true}},
+		{spec: "@weekly", want: cronSchedule{minute: cronBits(0), hour: cronBits(0),
+			dom: cronFull(1, 31), month: cronFull(1, 12), dow: cronBits(0)}},
+		{spec: "@monthly", want: cronSchedule{minute: cronBits(0), hour: cronBits(0),
+			dom: cronBits(1), month: cronFull(1, 12), dow: cronFull(0, 7)}},
+		{spec: "", err: "must have 5 fields"},
+		{spec: "* * * *", err: "must have 5 fields"},
+		{spec: "* * * * * *", err: "must have 5 fields"},
+		{spec: "@yearly", err: "must have 5 fields"},
+		{spec: "60 * * * *", err: "out of range (0-59)"},
+		{spec: "* 24 * * *", err: "out of range (0-23)"},
+		{spec: "* * 0 * *", err: "out of range (1-31)"},
+		{spec: "* * * 13 *", err: "out of range (1-12)"},
+		{spec: "* * * * 8", err: "out of range (0-7)"},
+		{spec: "5-1 * * * *", err: "out of range"},
+		{spec: "1,60 * * * *", err: "out of range"},
+		{spec: "*/0 * * * *", err: "invalid cron field value"},
+		{spec: "*/x * * * *", err: "invalid cron field value"},
+		{spec: "a * * * *", err: "invalid cron field value"},
+		{spec: "1-x * * * *", err: "invalid cron field value"},
+		{spec: "-1 * * * *", err: "invalid cron field value"},
+	} {
+		t.Run(tc.spec, func(t *testing.T) {
+			c, err := parseCron(tc.spec, time.UTC)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got error %v, expected %q", err, tc.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tc.want.spec, tc.want.loc = tc.spec, time.UTC
+			if *c != tc.want {
+				t.Fatalf("got %+v, expected %+v", *c, tc.want)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	est := time.FixedZone("EST", -5*60*60)
+
+	for _, tc := range []struct {
+		name string
+		spec string
+		loc  *time.Location
+		from time.Time
+		want time.Time
+	}{
+		{name: "step", spec: "*/15 * * * *",
+			from: time.Date(2024, 1, 1, 10, 7, 30, 0, time.UTC),
+			want: time.Date(2024, 1, 1, 10, 15, 0, 0, time.UTC)},
+		{name: "strictly after", spec: "0 * * * *",
+			from: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{name: "next day", spec: "@daily",
+			from: time.Date(2024, 1, 1, 23, 59, 0, 0, time.UTC),
+			want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "next year", spec: "0 0 1 1 *",
+			from: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "month start", spec: "@monthly",
+			from: time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "skips short months", spec: "0 0 31 * *",
+			from: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)},
+		{name: "leap day", spec: "0 12 29 2 *",
+			from: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{name: "day of month steps", spec: "0 0 */10 * *",
+			from: time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{name: "sunday as 7", spec: "0 0 * * 7",
+			from: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{name: "sunday as 0", spec: "@weekly",
+			from: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{name: "weekdays", spec: "30 9 * * 1-5",
+			from: time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 1, 8, 9, 30, 0, 0, time.UTC)},
+		{name: "weekday list", spec: "0 6 * * 2,4",
+			from: time.Date(2024, 1, 2, 6, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 1, 4, 6, 0, 0, 0, time.UTC)},
+		{name: "day of month or weekday matches weekday", spec: "0 0 13 * 5",
+			from: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{name: "day of month or weekday matches day", spec: "0 0 13 * 5",
+			from: time.Date(2024, 1, 12, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 1, 13, 0, 0, 0, 0, time.UTC)},
+		{name: "unrestricted weekday uses day of month", spec: "0 0 15 * *",
+			from: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{name: "full weekday range uses day of month", spec: "0 0 15 * 0-7",
+			from: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{name: "unrestricted day of month uses weekday", spec: "0 0 * * 3",
+			from: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{name: "location", spec: "0 9 * * *", loc: est,
+			from: time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 1, 2, 14, 0, 0, 0, time.UTC)},
+		{name: "never", spec: "0 0 31 2 *",
+			from: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			loc := tc.loc
+			if loc == nil {
+				loc = time.UTC
+			}
+
+			c, err := parseCron(tc.spec, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := c.Next(tc.from); !got.Equal(tc.want) {
+				t.Fatalf("%s after %s: got %s, expected %s", tc.spec, tc.from, got,
+					tc.want)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -94,6 +95,38 @@ func (t *TrackingDB) Init() ([]*ifaces.Sector, error) {
 		return nil, err
 	}
 
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS "admins" (
+		"FACTION" INTEGER PRIMARY KEY,
+		"NAME"    TEXT);`)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS "access" (
+		"STEAM64ID" INTEGER PRIMARY KEY,
+		"NAME"      TEXT,
+		"ALLOW"     INTEGER);`)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS "events" (
+		"ID"      INTEGER PRIMARY KEY AUTOINCREMENT,
+		"TIME"    INTEGER,
+		"TYPE"    TEXT,
+		"FACTION" INTEGER,
+		"NAME"    TEXT,
+		"MESSAGE" TEXT,
+		"PAYLOAD" TEXT);`)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS "events_time" ON events ("TIME");`)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get all of the sectors that have been tracked
 	sectors := make([]*ifaces.Sector, 0)
 
@@ -466,6 +499,335 @@ func (t *TrackingDB) SetDiscordToPlayer(p ifaces.IPlayer) error {
 	return nil
 }
 
+// Integrations returns the players that have linked their Discord account,
+// along with the name that they were last tracked under
+func (t *TrackingDB) Integrations() ([]ifaces.Integration, error) {
+	db, err := sql.Open("sqlite3", t.dbpath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT i.FACTION, i.DISCORD, IFNULL((SELECT NAME FROM
+		factions WHERE GAMEID=i.FACTION AND KIND=0 LIMIT 1), '') FROM integrations i;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make([]ifaces.Integration, 0)
+	for rows.Next() {
+		var (
+			fid int64
+			in  ifaces.Integration
+		)
+
+		if err := rows.Scan(&fid, &in.Discord, &in.Name); err != nil {
+			return nil, err
+		}
+
+		in.Index = strconv.FormatInt(fid, 10)
+		found = append(found, in)
+	}
+
+	return found, rows.Err()
+}
+
+// Admins returns the in-game admins that are managed by us, mapped from their
+// faction index to their name
+func (t *TrackingDB) Admins() (map[string]string, error) {
+	db, err := sql.Open("sqlite3", t.dbpath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT FACTION, NAME FROM admins;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		fid    int64
+		name   string
+		admins = make(map[string]string)
+	)
+
+	for rows.Next() {
+		if err := rows.Scan(&fid, &name); err != nil {
+			return nil, err
+		}
+		admins[strconv.FormatInt(fid, 10)] = name
+	}
+
+	return admins, rows.Err()
+}
+
+// AddAdmin records that a player was made an in-game admin by us
+func (t *TrackingDB) AddAdmin(index, name string) error {
+	db, err := sql.Open("sqlite3", t.dbpath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	fid, err := strconv.ParseInt(index, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`INSERT OR REPLACE INTO admins ("FACTION", "NAME")
+		VALUES (?,?);`, fid, name)
+	return err
+}
+
+// RemoveAdmin clears the record of an in-game admin that was made by us
+func (t *TrackingDB) RemoveAdmin(index string) error {
+	db, err := sql.Open("sqlite3", t.dbpath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	fid, err := strconv.ParseInt(index, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`DELETE FROM admins WHERE FACTION=?;`, fid)
+	return err
+}
+
+// AccessList returns the players that are explicitly allowed or denied access
+// to the server
+func (t *TrackingDB) AccessList() ([]ifaces.AccessEntry, error) {
+	db, err := sql.Open("sqlite3", t.dbpath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT STEAM64ID, NAME, ALLOW FROM access
+		ORDER BY ALLOW DESC, NAME ASC;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]ifaces.AccessEntry, 0)
+	for rows.Next() {
+		var e ifaces.AccessEntry
+		if err := rows.Scan(&e.SteamID, &e.Name, &e.Allowed); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// Access returns whether a Steam ID is on the allow or deny list
+func (t *TrackingDB) Access(steamid int64) (allowed, denied bool, err error) {
+	db, err := sql.Open("sqlite3", t.dbpath)
+	if err != nil {
+		return false, false, err
+	}
+	defer db.Close()
+
+	var allow bool
+	err = db.QueryRow(`SELECT ALLOW FROM access WHERE STEAM64ID=?;`, steamid).
+		Scan(&allow)
+	if err == sql.ErrNoRows {
+		return false, false, nil
+	}
+
+	if err != nil {
+		return false, false, err
+	}
+
+	return allow, !allow, nil
+}
+
+// SetAccess puts a Steam ID on the allow or deny list, replacing any previous
+// entry for it
+func (t *TrackingDB) SetAccess(e ifaces.AccessEntry) error {
+	db, err := sql.Open("sqlite3", t.dbpath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`INSERT OR REPLACE INTO access ("STEAM64ID", "NAME", "ALLOW")
+		VALUES (?,?,?);`, e.SteamID, e.Name, e.Allowed)
+	if err != nil {
+		return err
+	}
+
+	logger.LogInfo(t, fmt.Sprintf("Set access for [%d] (%s): %t", e.SteamID,
+		e.Name, e.Allowed))
+	return nil
+}
+
+// ClearAccess removes a Steam ID from the allow and deny lists, and returns
+// whether it was on either of them
+func (t *TrackingDB) ClearAccess(steamid int64) (bool, error) {
+	db, err := sql.Open("sqlite3", t.dbpath)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	res, err := db.Exec(`DELETE FROM access WHERE STEAM64ID=?;`, steamid)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// AddEvent records an event in the tracking DB
+func (t *TrackingDB) AddEvent(e ifaces.GameEvent) error {
+	db, err := sql.Open("sqlite3", t.dbpath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`INSERT INTO events ("TIME","TYPE","FACTION","NAME","MESSAGE",
+		"PAYLOAD") VALUES (?,?,?,?,?,?);`, e.Time.Unix(), e.Type, e.Faction, e.Name,
+		e.Message, e.Payload)
+	if err != nil {
+		logger.LogError(t, fmt.Sprintf("AddEvent: %s", err.Error()))
+		return err
+	}
+
+	logger.LogDebug(t, "AddEvent: Success")
+	return nil
+}
+
+// SearchEvents returns the recorded events that match a query, newest first.
+// Types and names are matched regardless of case, and a faction and name are
+// matched if either of them is.
+func (t *TrackingDB) SearchEvents(q ifaces.GameEventQuery) ([]ifaces.GameEvent,
+	error) {
+	db, err := sql.Open("sqlite3", t.dbpath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var (
+		where = make([]string, 0)
+		args  = make([]interface{}, 0)
+	)
+
+	if len(q.Types) > 0 {
+		marks := make([]string, 0, len(q.Types))
+		for _, k := range q.Types {
+			marks = append(marks, "?")
+			args = append(args, strings.ToLower(k))
+		}
+		where = append(where, `LOWER("TYPE") IN (`+strings.Join(marks, ",")+`)`)
+	}
+
+	switch {
+	case q.Faction != 0 && q.Name != "":
+		where = append(where, `("FACTION"=? OR "NAME"=? COLLATE NOCASE)`)
+		args = append(args, q.Faction, q.Name)
+	case q.Faction != 0:
+		where = append(where, `"FACTION"=?`)
+		args = append(args, q.Faction)
+	case q.Name != "":
+		where = append(where, `"NAME"=? COLLATE NOCASE`)
+		args = append(args, q.Name)
+	}
+
+	if !q.Since.IsZero() {
+		where = append(where, `"TIME">=?`)
+		args = append(args, q.Since.Unix())
+	}
+
+	if !q.Until.IsZero() {
+		where = append(where, `"TIME"<=?`)
+		args = append(args, q.Until.Unix())
+	}
+
+	selQ := `SELECT "TIME","TYPE","FACTION","NAME","MESSAGE","PAYLOAD" FROM events`
+	if len(where) > 0 {
+		selQ += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	selQ += ` ORDER BY "TIME" DESC, "ID" DESC`
+
+	if q.Limit > 0 {
+		selQ += ` LIMIT ?`
+		args = append(args, q.Limit)
+	}
+
+	rows, err := db.Query(selQ+`;`, args...)
+	if err != nil {
+		logger.LogError(t, fmt.Sprintf("SearchEvents: %s", err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make([]ifaces.GameEvent, 0)
+	for rows.Next() {
+		var (
+			e    ifaces.GameEvent
+			unix int64
+		)
+
+		if err := rows.Scan(&unix, &e.Type, &e.Faction, &e.Name, &e.Message,
+			&e.Payload); err != nil {
+			return nil, err
+		}
+
+		e.Time = time.Unix(unix, 0)
+		found = append(found, e)
+	}
+
+	return found, rows.Err()
+}
+
+// PruneEvents removes the events that were recorded before a time, and returns
+// the number of events that were removed
+func (t *TrackingDB) PruneEvents(before time.Time) (int64, error) {
+	db, err := sql.Open("sqlite3", t.dbpath)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	res, err := db.Exec(`DELETE FROM events WHERE "TIME" < ?;`, before.Unix())
+	if err != nil {
+		logger.LogError(t, fmt.Sprintf("PruneEvents: %s", err.Error()))
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// Reset removes everything that was tracked in a galaxy, for a new one. The
+// admins, access list and Discord integrations are kept.
+func (t *TrackingDB) Reset() error {
+	db, err := sql.Open("sqlite3", t.dbpath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, table := range []string{"factions", "jumps", "sectors", "events"} {
+		if _, err := db.Exec(`DELETE FROM "` + table + `";`); err != nil {
+			return err
+		}
+	}
+
+	_, err = db.Exec(`VACUUM;`)
+	return err
+}
+
 /************************/
 /* IFace logger.ILogger */
 /************************/
@@ -0,0 +1,247 @@
+package avorion
+
+import (
+	"avorioncontrol/avorion/events"
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	webhookTimeout = 10 * time.Second
+
+	rconEventMail = `sendmail -i %s -h "%s" -- %s`
+)
+
+// rconQuoteReplacer keeps captured output from breaking out of the quoting of
+// an RCON command
+var rconQuoteReplacer = strings.NewReplacer(`"`, `'`, "\r", " ", "\n", " ")
+
+// rconQuote quotes a value so that it is passed to an RCON command as a single
+// argument
+func rconQuote(v string) string {
+	return `"` + rconQuoteReplacer.Replace(v) + `"`
+}
+
+// eventActions runs the configured actions of the custom events of a server.
+// It is only used by its own subscriber, so it doesn't need a lock.
+type eventActions struct {
+	server *Server
+	client *http.Client
+
+	// When the actions of each event last ran, and how many matches have been
+	// held back by its cooldown since
+	last    map[string]time.Time
+	skipped map[string]int
+}
+
+// eventWebhook is the body that is posted to the webhook of an event. Content
+// holds the message so that Discord webhooks can be used as they are.
+type eventWebhook struct {
+	Event   string    `json:"event"`
+	Galaxy  string    `json:"galaxy"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+	Content string    `json:"content"`
+	Line    string    `json:"line"`
+	Matches []string  `json:"matches"`
+
+	// Captured output ends up in Content, so Discord is told not to ping anyone
+	// that it mentions
+	AllowedMentions struct {
+		Parse []string `json:"parse"`
+	} `json:"allowed_mentions"`
+}
+
+// newEventActions returns the eventActions of a server
+func newEventActions(s *Server) *eventActions {
+	return &eventActions{
+		server:  s,
+		client:  &http.Client{Timeout: webhookTimeout},
+		last:    make(map[string]time.Time),
+		skipped: make(map[string]int)}
+}
+
+// run posts the message of a custom event to Discord and runs its actions,
+// unless the event is still cooling down
+func (a *eventActions) run(m events.Message) {
+	var (
+		s    = a.server
+		data = m.Data.(*events.Custom)
+		def  = data.Definition
+	)
+
+	if def == nil {
+		s.SendLog(ifaces.ChatData{Msg: data.Message})
+		return
+	}
+
+	if def.Cooldown > 0 {
+		if last, ok := a.last[def.Name]; ok && m.Time.Sub(last) < def.Cooldown {
+			a.skipped[def.Name]++
+			logger.LogDebug(s, "Event is cooling down: "+def.Name)
+			return
+		}
+		a.last[def.Name] = m.Time
+	}
+
+	msg := data.Message
+	if n := a.skipped[def.Name]; n > 0 {
+		msg += sprintf("\n_(%d more held back by the cooldown)_", n)
+		a.skipped[def.Name] = 0
+	}
+
+	s.SendLog(ifaces.ChatData{
+		Msg:         msg,
+		Channel:     def.Channel,
+		MentionRole: def.MentionRole})
+
+	if def.RCON != "" {
+		cmd := expandRCON(def.RCON, s, def, data)
+		if _, err := s.RunCommandContext(context.Background(),
+			ifaces.CommandPriorityBulk, cmd); err != nil {
+			logger.LogWarning(s, sprintf("Event %s failed to run [%s]: %s", def.Name,
+				cmd, err.Error()))
+		}
+	}
+
+	if def.Mail != "" {
+		a.mail(def, data)
+	}
+
+	if def.Webhook != "" {
+		a.webhook(def, data, m)
+	}
+}
+
+// mail sends the in-game mail of an event to the player that its template
+// names, by index, name or player:<index>
+func (a *eventActions) mail(def *ifaces.LoggedServerEvent, data *events.Custom) {
+	var (
+		s      = a.server
+		values = templateValues(s, def, data, rconQuoteReplacer.Replace)
+		to     = strings.TrimSpace(os.Expand(def.MailTo, values))
+	)
+
+	if regexPlayerIndex.MatchString(to) {
+		to = regexPlayerIndex.FindStringSubmatch(to)[1]
+	}
+
+	p := s.Player(to)
+	if p == nil {
+		p = s.PlayerFromName(to)
+	}
+
+	if p == nil {
+		logger.LogWarning(s, sprintf("Event %s could not find a player to mail: %s",
+			def.Name, to))
+		return
+	}
+
+	subject := def.MailSubject
+	if subject == "" {
+		subject = "${message}"
+	}
+
+	if _, err := s.RunCommandContext(context.Background(),
+		ifaces.CommandPriorityBulk, sprintf(rconEventMail, p.Index(),
+			os.Expand(subject, values), os.Expand(def.Mail, values))); err != nil {
+		logger.LogWarning(s, sprintf("Event %s failed to mail %s: %s", def.Name,
+			p.Name(), err.Error()))
+	}
+}
+
+// webhook posts an event to its webhook as JSON
+func (a *eventActions) webhook(def *ifaces.LoggedServerEvent, data *events.Custom,
+	m events.Message) {
+	hook := eventWebhook{
+		Event:   def.Name,
+		Galaxy:  a.server.config.Galaxy(),
+		Time:    m.Time,
+		Message: data.Message,
+		Content: data.Message,
+		Line:    m.Line,
+		Matches: data.Matches}
+	hook.AllowedMentions.Parse = make([]string, 0)
+
+	body, err := json.Marshal(hook)
+	if err != nil {
+		logger.LogError(a.server, "Failed to encode webhook: "+err.Error())
+		return
+	}
+
+	resp, err := a.client.Post(def.Webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		logger.LogWarning(a.server, sprintf("Event %s failed to call its webhook: %s",
+			def.Name, err.Error()))
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		logger.LogWarning(a.server, sprintf("Event %s webhook returned %s", def.Name,
+			resp.Status))
+	}
+}
+
+// templateValues returns the mapping that the templates of an event are
+// expanded with by os.Expand. Every value is passed through clean first.
+func templateValues(s *Server, def *ifaces.LoggedServerEvent, data *events.Custom,
+	clean func(string) string) func(string) string {
+	names := def.Regex.SubexpNames()
+
+	return func(key string) string {
+		switch key {
+		case "message":
+			return clean(data.Message)
+		case "event":
+			return clean(def.Name)
+		case "galaxy":
+			return clean(s.config.Galaxy())
+		}
+
+		if i, err := strconv.Atoi(key); err == nil {
+			if i >= 0 && i < len(data.Matches) {
+				return clean(data.Matches[i])
+			}
+			return ""
+		}
+
+		for i, name := range names {
+			if name != "" && name == key && i < len(data.Matches) {
+				return clean(data.Matches[i])
+			}
+		}
+		return ""
+	}
+}
+
+// expandRCON expands the RCON template of an event. Values that are substituted
+// inside of a quoted string are kept from closing it, and the rest are quoted
+// so that captured output can't add arguments to the command.
+func expandRCON(template string, s *Server, def *ifaces.LoggedServerEvent,
+	data *events.Custom) string {
+	var (
+		quoted   = templateValues(s, def, data, rconQuoteReplacer.Replace)
+		unquoted = templateValues(s, def, data, rconQuote)
+		parts    = strings.Split(template, `"`)
+	)
+
+	// Every other part of the template is between a pair of quotes
+	for i := range parts {
+		if i%2 == 1 {
+			parts[i] = os.Expand(parts[i], quoted)
+		} else {
+			parts[i] = os.Expand(parts[i], unquoted)
+		}
+	}
+
+	return strings.Join(parts, `"`)
+}
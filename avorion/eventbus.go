@@ -0,0 +1,254 @@
+package avorion
+
+import (
+	"avorioncontrol/avorion/events"
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+const (
+	eventQueueSize = 256
+
+	// The recorder is the only record of events, so it gets a deep queue and
+	// waits for room rather than dropping them when the DB is slow
+	recorderName      = "Tracking DB recorder"
+	recorderQueueSize = 4096
+	recorderWait      = time.Second
+
+	errEventsOffline = "The server has to have been started to search its events"
+
+	// Chat is relayed through a pipe that can already take up to five seconds,
+	// so the relay only gets a short wait before messages are dropped
+	chatRelayWait = 250 * time.Millisecond
+)
+
+// handleEvent runs the handler of an event that matched a line of output, and
+// then publishes it to the subscribers of the server. Handlers update the state
+// of the server and run in order, while anything slow belongs in a subscriber.
+func (s *Server) handleEvent(e *events.Event, in string) {
+	e.Handler(s, e, in, nil)
+
+	if m, ok := e.Message(s, in); ok {
+		s.bus.Publish(m)
+	}
+}
+
+// publish sends a message about something that we did to the server, rather
+// than something that was parsed from its output
+func (s *Server) publish(k events.Kind, data interface{}) {
+	s.bus.Publish(events.Message{
+		Kind:   k,
+		Event:  string(k),
+		Time:   time.Now(),
+		Server: s,
+		Data:   data})
+}
+
+// subscribeEvents registers the subscribers that every server has
+func (s *Server) subscribeEvents() {
+	s.bus.Subscribe("Discord chat relay", events.SubscriberOptions{
+		Size: eventQueueSize, Policy: events.Block, MaxWait: chatRelayWait},
+		s.relayChat, events.KindChat)
+
+	s.bus.Subscribe("Discord log relay", events.SubscriberOptions{
+		Size: eventQueueSize, Policy: events.DropOldest},
+		s.relayLog, events.KindModUpdate)
+
+	s.bus.Subscribe("Event actions", events.SubscriberOptions{
+		Size: eventQueueSize, Policy: events.DropOldest},
+		newEventActions(s).run, events.KindCustom)
+
+	s.bus.Subscribe(recorderName, events.SubscriberOptions{
+		Size: recorderQueueSize, Policy: events.Block, MaxWait: recorderWait},
+		s.recordEvent, events.KindPlayerJoin, events.KindPlayerLeft,
+		events.KindPlayerKick, events.KindPlayerBan, events.KindChat,
+		events.KindCustom)
+}
+
+// relayChat sends the chat of players to Discord
+func (s *Server) relayChat(m events.Message) {
+	chat := m.Data.(*events.Chat)
+
+	// Catch our own discord messages
+	if chat.FromDiscord || chat.Name == "Server" || chat.Name == "Discord" {
+		return
+	}
+
+	out := chat.Message
+	if len(out) >= 2000 {
+		logger.LogInfo(s, "Truncated player message for sending")
+		out = out[0:1900]
+		out += "...(truncated)"
+	}
+
+	s.SendChat(ifaces.ChatData{Name: chat.Name, Msg: out})
+}
+
+// relayLog sends mod updates to Discord. The events that are defined in the
+// configuration are sent by their actions.
+func (s *Server) relayLog(m events.Message) {
+	switch data := m.Data.(type) {
+	case *events.ModUpdate:
+		s.SendChat(ifaces.ChatData{
+			Name: `Startup`,
+			Msg:  sprintf("Updated %s%d", modURLBase, data.WorkshopID)})
+	}
+}
+
+// recordEvent stores an event in the tracking DB, along with the player that it
+// involves
+func (s *Server) recordEvent(m events.Message) {
+	// The tracking DB is opened when the server starts
+	db := s.trackingDB()
+	if db == nil {
+		return
+	}
+
+	e := ifaces.GameEvent{Time: m.Time, Type: string(m.Kind)}
+
+	switch data := m.Data.(type) {
+	case *events.PlayerJoin:
+		e.Faction, _ = strconv.ParseInt(data.Index, 10, 64)
+		e.Name = data.Name
+		e.Message = "Joined the server"
+
+	case *events.PlayerLeft:
+		e.Faction, _ = strconv.ParseInt(data.Index, 10, 64)
+		e.Name = data.Name
+		e.Message = "Left the server"
+
+	case *events.Moderation:
+		e.Faction, _ = strconv.ParseInt(data.Index, 10, 64)
+		e.Name = data.Name
+		e.Message = "Kicked: " + data.Reason
+		if m.Kind == events.KindPlayerBan {
+			e.Message = "Banned: " + data.Reason
+		}
+
+	case *events.Chat:
+		e.Name = data.Name
+		e.Message = data.Message
+		if p := s.PlayerFromName(data.Name); p != nil && !data.FromDiscord {
+			e.Faction, _ = strconv.ParseInt(p.Index(), 10, 64)
+		}
+
+	case *events.Custom:
+		e.Type = data.Name
+		e.Message = data.Message
+
+		// Custom events are tied to the first player that they mention
+		for _, v := range data.Matches[1:] {
+			if !regexPlayerIndex.MatchString(v) {
+				continue
+			}
+
+			index := regexPlayerIndex.FindStringSubmatch(v)[1]
+			e.Faction, _ = strconv.ParseInt(index, 10, 64)
+			if p := s.Player(index); p != nil {
+				e.Name = p.Name()
+			}
+			break
+		}
+	}
+
+	payload, err := json.Marshal(m.Data)
+	if err != nil {
+		logger.LogError(s, "Failed to encode event: "+err.Error())
+		return
+	}
+
+	e.Payload = string(payload)
+	db.AddEvent(e)
+}
+
+// pruneEvents removes the events that are older than the configured retention
+// from the tracking DB
+func (s *Server) pruneEvents() {
+	db := s.trackingDB()
+	if db == nil {
+		return
+	}
+
+	n, err := db.PruneEvents(time.Now().Add(-s.config.EventRetention()))
+	if err != nil {
+		logger.LogError(s, "Failed to prune old events: "+err.Error())
+		return
+	}
+
+	if n > 0 {
+		logger.LogInfo(s, sprintf("Pruned %d old events from the tracking DB", n))
+	}
+}
+
+// reportRecorderDrops logs the events that the recorder has dropped since the
+// last report, as those events are missing from the tracking DB for good
+func (s *Server) reportRecorderDrops() {
+	for _, sub := range s.bus.Stats() {
+		if sub.Name != recorderName || sub.Dropped == s.recorderdrops {
+			continue
+		}
+
+		logger.LogWarning(s, sprintf("%d events were not recorded in the "+
+			"tracking DB (%d in total)", sub.Dropped-s.recorderdrops, sub.Dropped))
+		s.recorderdrops = sub.Dropped
+	}
+}
+
+/*****************************/
+/* IFace ifaces.IEventServer */
+/*****************************/
+
+// EventStats returns the match counters of the registered events, and the
+// queue statistics of the subscribers of the server
+func (s *Server) EventStats() ([]ifaces.EventStats, []ifaces.SubscriberStats) {
+	return events.Stats(), s.bus.Stats()
+}
+
+// SearchEvents returns the events recorded in the tracking DB that match a
+// query, newest first
+func (s *Server) SearchEvents(q ifaces.GameEventQuery) ([]ifaces.GameEvent,
+	error) {
+	db := s.trackingDB()
+	if db == nil {
+		return nil, errors.New(errEventsOffline)
+	}
+	return db.SearchEvents(q)
+}
+
+// Events describes the registered events, in the order that lines of output are
+// matched against them
+func (s *Server) Events() []ifaces.EventInfo {
+	return events.List()
+}
+
+// TestEvent returns the event that a line of output would be dispatched to, what
+// it would capture and the payload it would publish, without handling it
+func (s *Server) TestEvent(line string) (ifaces.EventMatch, bool) {
+	e := events.Find(line)
+	if e == nil {
+		return ifaces.EventMatch{}, false
+	}
+
+	var (
+		match = ifaces.EventMatch{Event: e.Name(), Kind: string(e.Kind)}
+		m     = e.Capture.FindStringSubmatch(line)
+		names = e.Capture.SubexpNames()
+	)
+
+	for i := 1; i < len(m); i++ {
+		match.Groups = append(match.Groups, ifaces.EventGroup{
+			Name: names[i], Value: m[i]})
+	}
+
+	if e.Decode != nil {
+		if payload, err := json.Marshal(e.Decode(s, e, m)); err == nil {
+			match.Payload = string(payload)
+		}
+	}
+
+	return match, true
+}
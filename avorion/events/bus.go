@@ -0,0 +1,259 @@
+package events
+
+import (
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Policy decides what happens to a message when a subscriber has fallen behind
+// and its queue is full
+type Policy int
+
+const (
+	// DropNewest discards the message that didn't fit in the queue
+	DropNewest Policy = iota
+	// DropOldest discards the oldest queued message to make room
+	DropOldest
+	// Block waits up to the subscribers MaxWait for room, and then drops the
+	// message. This slows down the publisher, so it should be kept short.
+	Block
+)
+
+const dropWarningInterval = time.Minute
+
+// Message is an event that was parsed from the output of a server
+type Message struct {
+	Kind   Kind
+	Event  string
+	Time   time.Time
+	Line   string
+	Server ifaces.IGameServer
+
+	// Data holds the typed payload that matches Kind, such as *PlayerJoin
+	Data interface{}
+}
+
+// Handler consumes messages that were delivered to a subscriber
+type Handler func(Message)
+
+// Subscriber receives the messages of the kinds it subscribed to on its own
+// goroutine
+type Subscriber struct {
+	// Updated atomically, and first so that they are aligned on 32-bit systems
+	delivered uint64
+	dropped   uint64
+
+	name    string
+	kinds   map[Kind]bool
+	policy  Policy
+	maxwait time.Duration
+	handler Handler
+	queue   chan Message
+	done    chan struct{}
+
+	warned    time.Time
+	warnmutex *sync.Mutex
+}
+
+// SubscriberOptions configure the queue of a subscriber
+type SubscriberOptions struct {
+	Size    int
+	Policy  Policy
+	MaxWait time.Duration
+}
+
+// Bus fans the messages published by a server out to its subscribers
+type Bus struct {
+	mutex  *sync.RWMutex
+	subs   []*Subscriber
+	owner  logger.ILogger
+	closed bool
+}
+
+// NewBus returns a Bus that logs as owner
+func NewBus(owner logger.ILogger) *Bus {
+	return &Bus{
+		mutex: new(sync.RWMutex),
+		subs:  make([]*Subscriber, 0),
+		owner: owner}
+}
+
+// Subscribe registers a handler for the given kinds of messages, or for every
+// message when no kinds are given. The handler runs on its own goroutine, in
+// the order that messages were published.
+func (b *Bus) Subscribe(name string, opts SubscriberOptions, h Handler,
+	kinds ...Kind) *Subscriber {
+	if opts.Size <= 0 {
+		opts.Size = 1
+	}
+
+	sub := &Subscriber{
+		name:      name,
+		policy:    opts.Policy,
+		maxwait:   opts.MaxWait,
+		handler:   h,
+		queue:     make(chan Message, opts.Size),
+		done:      make(chan struct{}),
+		warnmutex: new(sync.Mutex)}
+
+	if len(kinds) > 0 {
+		sub.kinds = make(map[Kind]bool, len(kinds))
+		for _, k := range kinds {
+			sub.kinds[k] = true
+		}
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		close(sub.done)
+		return sub
+	}
+
+	b.subs = append(b.subs, sub)
+	go sub.run()
+
+	logger.LogInit(b.owner, "Subscribed to events: "+name)
+	return sub
+}
+
+// Unsubscribe stops a subscriber. Messages that are still queued for it are
+// discarded.
+func (b *Bus) Unsubscribe(sub *Subscriber) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for i, s := range b.subs {
+		if s == sub {
+			b.subs = append(b.subs[:i], b.subs[i+1:]...)
+			close(sub.done)
+			return
+		}
+	}
+}
+
+// Publish queues a message for every subscriber that is interested in it. It
+// only waits on subscribers that use the Block policy.
+func (b *Bus) Publish(m Message) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for _, sub := range b.subs {
+		if sub.kinds != nil && !sub.kinds[m.Kind] {
+			continue
+		}
+
+		if !sub.offer(m) {
+			b.dropped(sub)
+		}
+	}
+}
+
+// Close stops every subscriber
+func (b *Bus) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.closed = true
+	for _, sub := range b.subs {
+		close(sub.done)
+	}
+	b.subs = nil
+}
+
+// Stats returns the queue statistics of every subscriber
+func (b *Bus) Stats() []ifaces.SubscriberStats {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	stats := make([]ifaces.SubscriberStats, 0, len(b.subs))
+	for _, sub := range b.subs {
+		stats = append(stats, ifaces.SubscriberStats{
+			Name:      sub.name,
+			Queued:    len(sub.queue),
+			Delivered: atomic.LoadUint64(&sub.delivered),
+			Dropped:   atomic.LoadUint64(&sub.dropped)})
+	}
+	return stats
+}
+
+// dropped records a message that a subscriber had no room for, warning about
+// it at most once every dropWarningInterval
+func (b *Bus) dropped(sub *Subscriber) {
+	n := atomic.AddUint64(&sub.dropped, 1)
+
+	sub.warnmutex.Lock()
+	defer sub.warnmutex.Unlock()
+
+	if time.Since(sub.warned) < dropWarningInterval {
+		return
+	}
+
+	sub.warned = time.Now()
+	logger.LogWarning(b.owner, fmt.Sprintf("Event subscriber %s is falling behind, "+
+		"%d messages have been dropped", sub.name, n))
+}
+
+// offer queues a message according to the subscribers policy, and returns false
+// if a message was dropped
+func (sub *Subscriber) offer(m Message) bool {
+	select {
+	case sub.queue <- m:
+		return true
+	default:
+	}
+
+	switch sub.policy {
+	case DropOldest:
+		select {
+		case <-sub.queue:
+		default:
+		}
+
+		select {
+		case sub.queue <- m:
+		default:
+		}
+		return false
+
+	case Block:
+		t := time.NewTimer(sub.maxwait)
+		defer t.Stop()
+
+		select {
+		case sub.queue <- m:
+			return true
+		case <-sub.done:
+		case <-t.C:
+		}
+	}
+
+	return false
+}
+
+// run delivers queued messages to the handler until the subscriber is stopped
+func (sub *Subscriber) run() {
+	for {
+		select {
+		case <-sub.done:
+			return
+		case m := <-sub.queue:
+			sub.handler(m)
+			atomic.AddUint64(&sub.delivered, 1)
+		}
+	}
+}
+
+// Name returns the name of the subscriber
+func (sub *Subscriber) Name() string {
+	return sub.name
+}
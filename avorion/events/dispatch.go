@@ -0,0 +1,142 @@
+package events
+
+import (
+	"avorioncontrol/ifaces"
+	"regexp/syntax"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+)
+
+// filter holds the literals that every line matching an event has to contain,
+// so that the full regex only runs for lines that are likely to match
+type filter struct {
+	prefix   string // The line starts with prefix
+	trimmed  bool   // Leading whitespace is skipped before checking prefix
+	contains string // The line contains this somewhere
+}
+
+// eventStats counts how often an event was tried and matched. The fields are
+// updated atomically.
+type eventStats struct {
+	candidates uint64
+	matches    uint64
+	nanos      int64
+}
+
+// isSpaceClass returns whether a character class only matches whitespace
+func isSpaceClass(re *syntax.Regexp) bool {
+	if re.Op != syntax.OpCharClass || len(re.Rune) == 0 {
+		return false
+	}
+
+	for i := 0; i < len(re.Rune); i += 2 {
+		for r := re.Rune[i]; r <= re.Rune[i+1]; r++ {
+			if !unicode.IsSpace(r) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isLiteral returns the literal that a node matches, if it is a case sensitive
+// literal
+func isLiteral(re *syntax.Regexp) (string, bool) {
+	if re.Op != syntax.OpLiteral || re.Flags&syntax.FoldCase != 0 {
+		return "", false
+	}
+	return string(re.Rune), true
+}
+
+// newFilter works out the literals that a regex requires. Regexes that can't be
+// parsed, or that don't require any literals, get an empty filter that lets
+// every line through.
+func newFilter(expr string) filter {
+	var f filter
+
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return f
+	}
+	re = re.Simplify()
+
+	subs := []*syntax.Regexp{re}
+	if re.Op == syntax.OpConcat {
+		subs = re.Sub
+	}
+
+	// Only literals in the top level concatenation are required by every match
+	for _, sub := range subs {
+		if lit, ok := isLiteral(sub); ok && len(lit) > len(f.contains) {
+			f.contains = lit
+		}
+	}
+
+	if len(subs) < 2 || (subs[0].Op != syntax.OpBeginText &&
+		subs[0].Op != syntax.OpBeginLine) {
+		return f
+	}
+
+	next := subs[1]
+	if (next.Op == syntax.OpStar || next.Op == syntax.OpPlus ||
+		next.Op == syntax.OpQuest) && isSpaceClass(next.Sub[0]) && len(subs) > 2 {
+		f.trimmed = true
+		next = subs[2]
+	}
+
+	if lit, ok := isLiteral(next); ok {
+		// Whitespace would be trimmed away before the prefix is checked
+		if f.trimmed && unicode.IsSpace([]rune(lit)[0]) {
+			return f
+		}
+		f.prefix = lit
+	}
+
+	return f
+}
+
+// allows returns whether a line passes the filter. trimmed is the line without
+// its leading whitespace.
+func (f *filter) allows(line, trimmed string) bool {
+	if f.prefix != "" {
+		if f.trimmed && !strings.HasPrefix(trimmed, f.prefix) {
+			return false
+		}
+		if !f.trimmed && !strings.HasPrefix(line, f.prefix) {
+			return false
+		}
+	}
+
+	return f.contains == "" || strings.Contains(line, f.contains)
+}
+
+// match runs the regex of an event against a line, recording the attempt
+func (e *Event) match(in string) bool {
+	start := time.Now()
+	ok := e.Capture.MatchString(in)
+	atomic.AddInt64(&e.stats.nanos, int64(time.Since(start)))
+	atomic.AddUint64(&e.stats.candidates, 1)
+
+	if ok {
+		atomic.AddUint64(&e.stats.matches, 1)
+	}
+	return ok
+}
+
+// Stats returns how often each registered event was tried and matched
+func Stats() []ifaces.EventStats {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	stats := make([]ifaces.EventStats, 0, len(installed.events))
+	for _, e := range installed.events {
+		stats = append(stats, ifaces.EventStats{
+			Name:       e.name,
+			Candidates: atomic.LoadUint64(&e.stats.candidates),
+			Matches:    atomic.LoadUint64(&e.stats.matches),
+			MatchTime:  time.Duration(atomic.LoadInt64(&e.stats.nanos))})
+	}
+	return stats
+}
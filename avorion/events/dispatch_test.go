@@ -0,0 +1,187 @@
+package events
+
+import (
+	"avorioncontrol/ifaces"
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// customRegexes are shaped like the events that are defined in configurations,
+// including the ones that the filter has to be careful with
+var customRegexes = []string{
+	`^\s*bossSpawnEvent: (?P<sector>-?\d+:-?\d+) (?P<player>player:\d+)\s*$`,
+	`^\s*stationDestroyedEvent: (-?\d+:-?\d+) ((?:player|alliance):\d+) (.*)$`,
+	`^Script error: (.*)$`,
+	`Galaxy saved in ([0-9.]+)s`,
+	`(?i)^\s*playerjoinevent: ([0-9]+) (.+)$`,
+	`^\s*(?:shipJumpEvent|shipTrackInitEvent): ([0-9]+) .*$`,
+	`^\s* Sector \((-?\d+):(-?\d+)\) is being unloaded$`,
+	`^[ \t]*Memory used by scripts: (.*)$`,
+	`(?m)^Saving galaxy`,
+	`^\s*Player logged (?:in|off): (.+), index: ([0-9]+)$`,
+	`^$`,
+}
+
+func noopHandler(srv ifaces.IGameServer, e *Event, in string, oc chan string) {}
+
+// testRegistry returns a registry with the built in events and customRegexes
+func testRegistry(t testing.TB) *Registry {
+	r := NewRegistry()
+	for i, re := range customRegexes {
+		if err := r.Add(fmt.Sprintf("Custom%d", i), &Event{
+			Capture: regexp.MustCompile(re),
+			Handler: noopHandler}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+// testLines returns the lines of the recorded logs in testdata
+func testLines(t testing.TB) []string {
+	files, err := filepath.Glob("testdata/*.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := make([]string, 0)
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		f.Close()
+
+		if err := scanner.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(lines) == 0 {
+		t.Fatal("testdata has no recorded lines")
+	}
+	return lines
+}
+
+// linear returns the first event of r that matches a line by trying every
+// regex, which is what the filters must agree with
+func linear(r *Registry, in string) *Event {
+	for _, e := range r.events {
+		if e.Capture.MatchString(in) {
+			return e
+		}
+	}
+	return nil
+}
+
+func eventName(e *Event) string {
+	if e == nil {
+		return "no event"
+	}
+	return e.name
+}
+
+func TestFilterAllowsMatches(t *testing.T) {
+	for _, tc := range []struct {
+		re    string
+		lines []string
+	}{
+		{`^\s*playerJoinEvent: ([0-9]+) (.+?)\s*$`, []string{
+			"playerJoinEvent: 1 Alice", "   playerJoinEvent: 1 Alice",
+			"\tplayerJoinEvent: 2 Bob  "}},
+		{`^playerJoinEvent: ([0-9]+)`, []string{"playerJoinEvent: 12"}},
+		{`(?i)^playerjoinevent: ([0-9]+)`, []string{
+			"PLAYERJOINEVENT: 4", "playerJoinEvent: 4"}},
+		{`^(?i:player)JoinEvent`, []string{"PLAYERJoinEvent"}},
+		{`^\s* Sector`, []string{" Sector", "\t Sector", "   Sector"}},
+		{`^\s+x`, []string{" x", "\t\tx"}},
+		{`^ *x`, []string{"x", "   x"}},
+		{`^\s?x`, []string{"x", " x"}},
+		{`^(?:a|b)c`, []string{"ac", "bc"}},
+		{`^(a|b)?c`, []string{"c", "ac"}},
+		{`(?m)^x$`, []string{"x"}},
+		{`^.*Event: `, []string{"shipJumpEvent: ", "Event: "}},
+		{`(foo|bar)baz`, []string{"foobaz", "barbaz"}},
+		{`foo(bar)?baz`, []string{"foobaz", "foobarbaz"}},
+		{`a{2}b`, []string{"aab"}},
+		{`\Qa.b\E`, []string{"a.b"}},
+		{`^$`, []string{""}},
+		{`^\x{00e9}t\x{00e9}`, []string{"été"}},
+	} {
+		f := newFilter(tc.re)
+		re := regexp.MustCompile(tc.re)
+		for _, line := range tc.lines {
+			if !re.MatchString(line) {
+				t.Fatalf("%s: test line %q doesn't match", tc.re, line)
+			}
+
+			trimmed := strings.TrimLeftFunc(line, unicode.IsSpace)
+			if !f.allows(line, trimmed) {
+				t.Errorf("%s: filter %+v rejected %q", tc.re, f, line)
+			}
+		}
+	}
+}
+
+func TestFilterAllowsRecordedLines(t *testing.T) {
+	var (
+		r     = testRegistry(t)
+		lines = testLines(t)
+	)
+
+	for _, e := range r.events {
+		for _, line := range lines {
+			trimmed := strings.TrimLeftFunc(line, unicode.IsSpace)
+			if e.Capture.MatchString(line) && !e.filter.allows(line, trimmed) {
+				t.Errorf("%s: filter %+v rejected %q", e.name, e.filter, line)
+			}
+		}
+	}
+}
+
+func TestDispatchMatchesLinear(t *testing.T) {
+	var (
+		r     = testRegistry(t)
+		lines = testLines(t)
+	)
+
+	Install(r)
+	for _, line := range lines {
+		if got, want := Find(line), linear(r, line); got != want {
+			t.Errorf("%q was dispatched to %s, expected %s", line, eventName(got),
+				eventName(want))
+		}
+	}
+}
+
+func BenchmarkDispatch(b *testing.B) {
+	var (
+		r     = testRegistry(b)
+		lines = testLines(b)
+	)
+
+	Install(r)
+
+	b.Run("filtered", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			GetFromString(lines[i%len(lines)])
+		}
+	})
+
+	b.Run("linear", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			linear(r, lines[i%len(lines)])
+		}
+	})
+}
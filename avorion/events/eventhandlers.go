@@ -6,46 +6,56 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"time"
 )
 
 var discChatRe = regexp.MustCompile(`^\s*<D> <.*?#[0-9]{4}> (.*)$`)
-var modURLBase = `https://steamcommunity.com/sharedfiles/filedetails/?id=`
 
-func initB() {
-	New("EventShipTrackInit",
+// initB registers the built in events
+func (r *Registry) initB() {
+	r.New("EventShipTrackInit",
 		`^\s*shipTrackInitEvent: (-?[0-9]+) (-?[0-9]+):(-?[0-9]+) (.*)$`,
 		handleEventShipTrackInit)
 
-	New("EventPlayerChat",
+	r.NewTyped("EventPlayerChat",
 		`^\s*<(.+?)> (.*)`,
+		KindChat, decodePlayerChat,
 		handlePlayerChat)
 
-	New("EventShipJump",
+	r.NewTyped("EventShipJump",
 		`^\s*shipJumpEvent: (-?[0-9]+) (-?[0-9]+):(-?[0-9]+) (.*)$`,
+		KindShipJump, decodeShipJump,
 		handleEventShipJump)
 
-	New("EventPlayerJoin",
+	r.NewTyped("EventPlayerJoin",
 		`^\s*playerJoinEvent: ([0-9]+) (.+?)\s*$`,
+		KindPlayerJoin, decodePlayerJoin,
 		handleEventPlayerJoin)
 
-	New("EventPlayerLeft",
+	r.NewTyped("EventPlayerLeft",
 		`^\s*playerLeftEvent: ([0-9]+) (.+?)\s*$`,
+		KindPlayerLeft, decodePlayerLeft,
 		handleEventPlayerLeft)
 
-	New("EventPlayerKick",
+	r.New("EventPlayerKick",
 		`^\s*doPlayerKickEvent: ([0-9]+) (.*?)\s*$`,
 		handleEventPlayerKick)
 
-	New("EventPlayerBan",
+	r.New("EventPlayerBan",
 		`^\s*doPlayerBanEvent: ([0-9]+) (.*?)\s*$`,
 		handleEventPlayerBan)
 
-	New("EventDiscordIntegrationRequest",
+	r.New("EventDiscordIntegrationRequest",
 		`^\s*discordIntegrationRequestEvent: ([0-9]+) ([0-9]+)`,
 		handleDiscordIntegrationRequest)
 
-	New("EventModUpdate",
+	r.New("EventServerHeartbeat",
+		`^\s*serverHeartbeatEvent: ([0-9]+) ([0-9]+)\s*$`,
+		handleEventServerHeartbeat)
+
+	r.NewTyped("EventModUpdate",
 		`^\s*Downloading ([0-9]+) \[[^\s]+ of [^\s]+ \| 100%\]\s*$`,
+		KindModUpdate, decodeModUpdate,
 		handleModUpdate)
 }
 
@@ -58,15 +68,16 @@ func handleEventConnection(srv ifaces.IGameServer, e *Event, in string,
 func handleEventPlayerJoin(srv ifaces.IGameServer, e *Event, in string,
 	oc chan string) {
 	m := e.Capture.FindStringSubmatch(in)
-	if p := srv.Player(m[1]); p == nil {
+	p := srv.Player(m[1])
+	if p == nil {
 		p = srv.NewPlayer(m[1], m)
-		p.SetOnline(true)
 	} else {
 		p.Update()
-		p.SetOnline(true)
 	}
 
+	p.SetOnline(true)
 	srv.AddPlayerOnline()
+	go srv.EnforceAccess(p)
 }
 
 func handleEventPlayerLeft(srv ifaces.IGameServer, e *Event, in string,
@@ -107,26 +118,6 @@ func handleEventShipJump(srv ifaces.IGameServer, e *Event, in string,
 func handlePlayerChat(srv ifaces.IGameServer, e *Event, in string,
 	oc chan string) {
 	logger.LogChat(srv, in)
-	// Catch our own discord messages
-	if discChatRe.MatchString(in) {
-		return
-	}
-
-	m := e.Capture.FindStringSubmatch(in)
-	if m[1] != "Server" && m[1] != "Discord" {
-		out := m[2]
-		if len(out) >= 2000 {
-			logger.LogInfo(srv, "Truncated player message for sending")
-			out = out[0:1900]
-			out += "...(truncated)"
-		}
-
-		output := ifaces.ChatData{
-			Name: m[1],
-			Msg:  out}
-
-		srv.SendChat(output)
-	}
 }
 
 func handleNilCommand(srv ifaces.IGameServer, e *Event, in string,
@@ -178,18 +169,60 @@ func handleEventPlayerBan(srv ifaces.IGameServer, e *Event, in string,
 			p.Name(), m[2])})
 }
 
+func handleEventServerHeartbeat(srv ifaces.IGameServer, e *Event, in string,
+	oc chan string) {
+	m := e.Capture.FindStringSubmatch(in)
+
+	// The regex only matches integers, but they can still overflow
+	interval, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return
+	}
+
+	elapsed, err := strconv.ParseInt(m[2], 10, 64)
+	if err != nil {
+		return
+	}
+
+	srv.Heartbeat(ifaces.Heartbeat{
+		Time:     time.Now(),
+		Interval: time.Duration(interval) * time.Millisecond,
+		Elapsed:  time.Duration(elapsed) * time.Millisecond})
+}
+
 func handleModUpdate(srv ifaces.IGameServer, e *Event, in string,
 	oc chan string) {
 	logger.LogInit(srv, in)
-	m := e.Capture.FindStringSubmatch(in)
+}
 
-	out := fmt.Sprintf("Updated %s%s", modURLBase, m[1])
+/************/
+/* Decoders */
+/************/
+
+func decodePlayerJoin(srv ifaces.IGameServer, e *Event, m []string) interface{} {
+	return &PlayerJoin{Index: m[1], Name: m[2]}
+}
 
-	output := ifaces.ChatData{
-		Name: `Startup`,
-		Msg:  out}
+func decodePlayerLeft(srv ifaces.IGameServer, e *Event, m []string) interface{} {
+	return &PlayerLeft{Index: m[1], Name: m[2]}
+}
+
+func decodeShipJump(srv ifaces.IGameServer, e *Event, m []string) interface{} {
+	x, _ := strconv.Atoi(m[2])
+	y, _ := strconv.Atoi(m[3])
+	return &ShipJump{Index: m[1], X: x, Y: y, Ship: m[4]}
+}
+
+func decodePlayerChat(srv ifaces.IGameServer, e *Event, m []string) interface{} {
+	return &Chat{
+		Name:        m[1],
+		Message:     m[2],
+		FromDiscord: discChatRe.MatchString(m[0])}
+}
 
-	srv.SendChat(output)
+func decodeModUpdate(srv ifaces.IGameServer, e *Event, m []string) interface{} {
+	id, _ := strconv.ParseInt(m[1], 10, 64)
+	return &ModUpdate{WorkshopID: id}
 }
 
 func defaultEventHandler(srv ifaces.IGameServer, e *Event, in string,
@@ -1,35 +1,62 @@
 package events
 
 import (
+	"avorioncontrol/ifaces"
 	"avorioncontrol/logger"
 	"errors"
 	"regexp"
+	"sync"
 	"time"
 )
 
-var events []*Event             // Iteration
-var eventsMap map[string]*Event // Reference
-var benchTimer *time.Timer
-
-// Initialize handles event initialization and should be run before the
-// server starts
-func Initialize() {
-	// For re-init
-	events = nil
-	eventsMap = nil
-	benchTimer = nil
-
-	events = make([]*Event, 0)
-	eventsMap = make(map[string]*Event)
-	benchTimer = time.NewTimer(time.Minute * 5)
-	initB()
+// Registry is a set of events that is built up off to the side, and then put
+// in use as a whole by Install. Lines are never dispatched against a registry
+// that is still being built.
+type Registry struct {
+	events    []*Event          // Iteration
+	eventsMap map[string]*Event // Reference
+}
+
+// The installed events are shared by every server that is being managed, so
+// access to them is guarded
+var (
+	installed = &Registry{eventsMap: make(map[string]*Event)}
+	mutex     = new(sync.RWMutex)
+)
+
+// NewRegistry returns a Registry that holds the built in events
+func NewRegistry() *Registry {
+	r := &Registry{
+		events:    make([]*Event, 0),
+		eventsMap: make(map[string]*Event)}
+
+	r.initB()
+	return r
+}
+
+// Install replaces the events that lines are dispatched against with the ones
+// in r. Events that keep their name and regex keep their statistics. Events
+// that are still being handled are unaffected, as events are never changed
+// once they have been installed.
+func Install(r *Registry) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for _, e := range r.events {
+		if old, ok := installed.eventsMap[e.name]; ok &&
+			old.Capture.String() == e.Capture.String() {
+			e.stats = old.stats
+		}
+	}
+
+	installed = r
 }
 
 // New makes, registers, and returns a game event using the Regex that is used
-// to detect it. Panic if the Regexp that was provided matches the Regexp of a
-// previously registered Event. Returns the index of the registered event
-func New(n, re string, h EventHandler) (*Event, error) {
-	for _, e := range events {
+// to detect it. Panic if the Regexp that was provided is invalid. Returns an
+// error if the name or Regexp match those of a previously registered Event.
+func (r *Registry) New(n, re string, h EventHandler) (*Event, error) {
+	for _, e := range r.events {
 		if e.Capture.String() == re || e.name == n {
 			return nil, errors.New("Cannot register the same event multiple times")
 		}
@@ -39,18 +66,34 @@ func New(n, re string, h EventHandler) (*Event, error) {
 		name:     n,
 		loglevel: 0,
 		Capture:  regexp.MustCompile(re),
-		Handler:  h}
+		Handler:  h,
+		filter:   newFilter(re),
+		stats:    new(eventStats)}
 
-	events = append(events, ge)
-	eventsMap[n] = ge
+	r.events = append(r.events, ge)
+	r.eventsMap[n] = ge
 
 	logger.LogInit(ge, "Registered event regex: ["+ge.Capture.String()+"]")
 
 	return ge, nil
 }
 
+// NewTyped makes and registers an event like New, which is also published to
+// subscribers as a Message of kind k with the payload built by d
+func (r *Registry) NewTyped(n, re string, k Kind, d Decoder,
+	h EventHandler) (*Event, error) {
+	ge, err := r.New(n, re, h)
+	if err != nil {
+		return nil, err
+	}
+
+	ge.Kind = k
+	ge.Decode = d
+	return ge, nil
+}
+
 // Add takes a premade event and registers it to our event configuration
-func Add(n string, ge *Event) error {
+func (r *Registry) Add(n string, ge *Event) error {
 	if n == "" {
 		return errors.New("Event does not have valid name")
 	}
@@ -63,26 +106,54 @@ func Add(n string, ge *Event) error {
 		return errors.New("Event does not have a defined handler")
 	}
 
-	for _, e := range events {
+	for _, e := range r.events {
 		if e.Capture.String() == ge.Capture.String() || e.name == n {
 			return errors.New("Cannot register the same event multiple times")
 		}
 	}
 
 	ge.name = n
-	events = append(events, ge)
-	eventsMap[n] = ge
+	ge.filter = newFilter(ge.Capture.String())
+	ge.stats = new(eventStats)
+	r.events = append(r.events, ge)
+	r.eventsMap[n] = ge
 
 	logger.LogInit(ge, "Registered event regex: ["+ge.Capture.String()+"]")
 
 	return nil
 }
 
+// Len returns the number of events in the registry
+func (r *Registry) Len() int {
+	return len(r.events)
+}
+
 // Name returns the name of the Event
 func (e *Event) Name() string {
 	return e.name
 }
 
+// Message builds the Message that is published for a line that matched the
+// event. Returns false for events that aren't published.
+func (e *Event) Message(srv ifaces.IGameServer, in string) (Message, bool) {
+	if e.Kind == "" || e.Decode == nil {
+		return Message{}, false
+	}
+
+	m := e.Capture.FindStringSubmatch(in)
+	if m == nil {
+		return Message{}, false
+	}
+
+	return Message{
+		Kind:   e.Kind,
+		Event:  e.name,
+		Time:   time.Now(),
+		Line:   in,
+		Server: srv,
+		Data:   e.Decode(srv, e, m)}, true
+}
+
 /************************/
 /* IFace logger.ILogger */
 /************************/
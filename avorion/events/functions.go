@@ -1,21 +1,66 @@
 package events
 
-import "avorioncontrol/ifaces"
+import (
+	"avorioncontrol/ifaces"
+	"strings"
+	"unicode"
+)
 
-// GetFromString returns a reference to a game event given a matching string
+// GetFromString returns a reference to a game event given a matching string.
+// Events are tried in the order they were registered, but only the ones whose
+// literals appear in the string have their regex run.
 func GetFromString(in string) *Event {
-	for _, e := range events {
-		if e.Capture.MatchString(in) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	trimmed := strings.TrimLeftFunc(in, unicode.IsSpace)
+	for _, e := range installed.events {
+		if e.filter.allows(in, trimmed) && e.match(in) {
 			return e
 		}
 	}
 	return nil
 }
 
+// Find returns the event that a line would be dispatched to, like
+// GetFromString, without counting it in the statistics of the events
+func Find(in string) *Event {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	trimmed := strings.TrimLeftFunc(in, unicode.IsSpace)
+	for _, e := range installed.events {
+		if e.filter.allows(in, trimmed) && e.Capture.MatchString(in) {
+			return e
+		}
+	}
+	return nil
+}
+
+// List describes the installed events, in the order that lines are matched
+// against them
+func List() []ifaces.EventInfo {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	info := make([]ifaces.EventInfo, 0, len(installed.events))
+	for _, e := range installed.events {
+		info = append(info, ifaces.EventInfo{
+			Name:    e.name,
+			Kind:    string(e.Kind),
+			Regex:   e.Capture.String(),
+			FString: e.FString})
+	}
+	return info
+}
+
 // EventType - Given string and its source Server{}, determine the event type
 // that was provided. Returns a -1 if none was found.
 func EventType(s string, srv ifaces.IGameServer) int {
-	for i, ge := range events {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	for i, ge := range installed.events {
 		if ge.Capture.MatchString(s) {
 			return i
 		}
@@ -8,6 +8,24 @@ import (
 // EventHandler is a function that parses a logfile and performs an action
 type EventHandler func(ifaces.IGameServer, *Event, string, chan string)
 
+// Decoder builds the typed payload of an event from the submatches of its regex
+type Decoder func(ifaces.IGameServer, *Event, []string) interface{}
+
+// Kind identifies the type of payload that a published Message carries
+type Kind string
+
+// Kinds of messages that are published to a Bus
+const (
+	KindPlayerJoin Kind = "PlayerJoin"
+	KindPlayerLeft Kind = "PlayerLeft"
+	KindPlayerKick Kind = "PlayerKick"
+	KindPlayerBan  Kind = "PlayerBan"
+	KindShipJump   Kind = "ShipJump"
+	KindChat       Kind = "Chat"
+	KindModUpdate  Kind = "ModUpdate"
+	KindCustom     Kind = "Custom"
+)
+
 // Event describes logged output that the ifaces.Server has output that can
 // be acted upon in some fashion
 type Event struct {
@@ -16,4 +34,64 @@ type Event struct {
 	loglevel int
 	Capture  *regexp.Regexp
 	Handler  EventHandler
+
+	// Events with a Kind are published to the Bus of the server once they have
+	// been handled
+	Kind   Kind
+	Decode Decoder
+
+	filter filter
+	stats  *eventStats
+}
+
+// PlayerJoin is published when a player logs in
+type PlayerJoin struct {
+	Index string
+	Name  string
+}
+
+// PlayerLeft is published when a player logs off
+type PlayerLeft struct {
+	Index string
+	Name  string
+}
+
+// Moderation is published with KindPlayerKick or KindPlayerBan when a player is
+// kicked or banned by us
+type Moderation struct {
+	Index  string
+	Name   string
+	Reason string
+}
+
+// ShipJump is published when a tracked ship jumps to another sector
+type ShipJump struct {
+	Index string
+	Ship  string
+	X     int
+	Y     int
+}
+
+// Chat is published for chat messages. FromDiscord is set for messages that
+// were relayed into the game from Discord.
+type Chat struct {
+	Name        string
+	Message     string
+	FromDiscord bool
+}
+
+// ModUpdate is published when Avorion finishes downloading a workshop mod
+type ModUpdate struct {
+	WorkshopID int64
+}
+
+// Custom is published for the events that are defined in the configuration
+type Custom struct {
+	Name    string
+	Message string
+	Matches []string
+
+	// Definition is the configuration of the event when it was installed, so
+	// that its actions don't read a configuration that is being reloaded
+	Definition *ifaces.LoggedServerEvent `json:"-"`
 }
@@ -2,6 +2,7 @@ package avorion
 
 import (
 	"avorioncontrol/avorion/events"
+	"avorioncontrol/ifaces"
 	"avorioncontrol/logger"
 	"bufio"
 	"time"
@@ -20,6 +21,14 @@ func updateAvorionStatus(s *Server, closech chan struct{}) {
 	s.wg.Add(1)
 
 	logger.LogInit(s, "Starting status supervisor")
+
+	// The tickers are created once so that the shorter one doesn't keep on
+	// resetting the longer one
+	counts := time.NewTicker(s.config.HangTimeDuration())
+	defer counts.Stop()
+	dbupdate := time.NewTicker(s.config.DBUpdateTimeDuration())
+	defer dbupdate.Stop()
+
 	for {
 		// Close the routine gracefully
 		select {
@@ -28,18 +37,18 @@ func updateAvorionStatus(s *Server, closech chan struct{}) {
 			return
 
 		case <-closech:
-			if !state.isstopping && !state.isrestarting && !state.isstarting {
-				logger.LogWarning(s, "Avorion server exited abnormally, restarting")
+			if s.state.exitedUnexpectedly() {
 				s.Crashed()
-				if err := s.Restart(); err == nil {
-					state.iscrashed = false
-					state.isrestarting = false
+				if !delayCrashRestart(s) {
+					return
 				}
+
+				s.Restart()
 			}
 			return
 
-		// Check the server status after the configured duration of time has passed
-		case <-time.After(s.config.HangTimeDuration()):
+		// Update the count of online players after the configured duration
+		case <-counts.C:
 			online := 0
 			for _, p := range s.players {
 				if p.Online() {
@@ -49,27 +58,166 @@ func updateAvorionStatus(s *Server, closech chan struct{}) {
 
 			s.onlineplayercount = online
 
-			if state.isrestarting || state.isstopping || state.isstarting {
-				continue
-			}
+		// Update our playerinfo db after the configured duration of time has passed
+		case <-dbupdate.C:
+			s.UpdatePlayerDatabase(true)
+		}
+	}
+}
 
-			// TODO: Make this command configura
-			_, err := s.RunCommand("echo Server status check")
-			if err != nil {
-				s.Crashed()
-				logger.LogError(s, err.Error())
-				s.Cmd.Process.Kill()
-			}
+// superviseEvents prunes the events that have outlived their retention from
+// the tracking DB, and reports the events that the recorder had to drop. It
+// runs alongside the updates of the player database.
+func superviseEvents(s *Server, closech chan struct{}) {
+	defer func() { logger.LogInfo(s, "Stopping old event supervisor") }()
+	logger.LogInit(s, "Starting event supervisor")
 
-			if s.IsCrashed() && err == nil {
-				s.Recovered()
-			}
+	ticker := time.NewTicker(s.config.DBUpdateTimeDuration())
+	defer ticker.Stop()
 
-		// Update our playerinfo db after the configured duration of time has passed
-		case <-time.After(s.config.DBUpdateTimeDuration()):
-			s.UpdatePlayerDatabase(true)
+	for {
+		select {
+		case <-closech:
+			return
+		case <-s.exit:
+			return
+		case <-ticker.C:
+			s.pruneEvents()
+			s.reportRecorderDrops()
+		}
+	}
+}
+
+// superviseHeartbeats is the watchdog that kills the Avorion process once its
+// game loop stops sending heartbeats, so that the status supervisor restarts it.
+// It runs on its own ticker so that its short interval doesn't hold back the
+// other work of the status supervisor.
+func superviseHeartbeats(s *Server, closech chan struct{}) {
+	defer func() { logger.LogInfo(s, "Stopping old heartbeat watchdog") }()
+	logger.LogInit(s, "Starting heartbeat watchdog")
+
+	ticker := time.NewTicker(hangCheckInterval(s))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closech:
+			return
+		case <-s.exit:
+			return
+		case <-ticker.C:
+		}
+
+		if s.state.base() != ifaces.ServerOnline {
+			continue
+		}
+
+		err := s.CheckHang()
+		if err != nil {
+			s.Crashed()
+			logger.LogError(s, err.Error())
+			s.Cmd.Process.Kill()
+		}
+
+		if s.IsCrashed() && err == nil {
+			s.Recovered()
+		}
+
+		// Clear the crash loop once the server has stayed up for long enough
+		_, window := s.config.CrashLimit()
+		if looping, last := s.crashes.Looping(); looping && err == nil &&
+			time.Since(last) > window {
+			s.crashes.Reset()
+			logger.LogInfo(s, "Avorion server has recovered from a crash loop")
+			s.SendLog(ifaces.ChatData{
+				Msg: "Avorion has recovered from its crash loop"})
+		}
+	}
+}
+
+// hangCheckInterval returns the time between hang checks, which is shortened to
+// the heartbeat grace period so that missing heartbeats are caught in time
+func hangCheckInterval(s *Server) time.Duration {
+	grace, _ := s.config.HeartbeatGrace()
+	if d := s.config.HangTimeDuration(); d < grace {
+		return d
+	}
+	return grace
+}
+
+// superviseResources samples the resource usage of the Avorion process at the
+// configured interval, and alerts the log channel when its memory usage crosses
+// one of the configured thresholds
+func superviseResources(s *Server, closech chan struct{}) {
+	defer func() { logger.LogInfo(s, "Stopping old resource supervisor") }()
+	logger.LogInit(s, "Starting resource supervisor")
+
+	var prev procSample
+	for {
+		select {
+		case <-closech:
+			return
+		case <-s.exit:
+			return
+		case <-time.After(s.config.ResourceSampleDuration()):
+		}
+
+		if s.Cmd == nil || s.Cmd.Process == nil {
+			continue
+		}
+
+		cur, err := readProcSample(s.Cmd.Process.Pid)
+		if err != nil {
+			logger.LogDebug(s, "Failed to sample resource usage: "+err.Error())
+			continue
 		}
+
+		// Alerts from a previous process don't carry over
+		if cur.PID != prev.PID {
+			s.memalerts = 0
+		}
+
+		cur.CPU = cpuPercent(prev, cur)
+		s.resources.Add(cur.ProcessStats)
+		s.alertMemory(cur.RSS)
+		prev = cur
+	}
+}
+
+// delayCrashRestart records a crash, and when the server is crash looping
+// waits out the backoff before it is restarted. Returns false if the restart
+// should no longer happen.
+func delayCrashRestart(s *Server) bool {
+	limit, window := s.config.CrashLimit()
+	base, max := s.config.CrashBackoff()
+	delay, started := s.crashes.Record(time.Now(), limit, window, base, max)
+
+	if delay == 0 {
+		logger.LogWarning(s, "Avorion server exited abnormally, restarting")
+		return true
 	}
+
+	logger.LogError(s, sprintf("Avorion server is crash looping, restarting in %s",
+		delay.String()))
+
+	if started {
+		s.SendLog(ifaces.ChatData{Msg: sprintf("**Crash loop detected:** Avorion "+
+			"crashed %d times within %s. Restarts will be delayed, starting at %s. "+
+			"Use `server recover` to clear this.\nLast output:\n%s", limit,
+			window.String(), delay.String(),
+			crashOutput(s.recent.Last(crashReportLines)))})
+	} else {
+		s.SendLog(ifaces.ChatData{Msg: sprintf("Avorion crashed again, "+
+			"restarting in %s", delay.String())})
+	}
+
+	if !s.crashes.Wait(delay, s.exit) {
+		logger.LogInfo(s, "Cancelled delayed restart")
+		return false
+	}
+
+	// The server may have been started manually while we were waiting
+	return !s.IsUp()
 }
 
 // superviseAvorionOut watches the output provided by the Avorion process and
@@ -86,6 +234,7 @@ func superviseAvorionOut(s *Server, ready chan struct{},
 	// TODO: Move the scanner.Scan() loop into a goroutine.
 	for scanner.Scan() {
 		out := scanner.Text()
+		s.recent.Add(out)
 
 		// Exit gracefully
 		select {
@@ -99,7 +248,7 @@ func superviseAvorionOut(s *Server, ready chan struct{},
 				logger.LogOutput(s, out)
 				continue
 			}
-			e.Handler(s, e, out, nil)
+			s.handleEvent(e, out)
 
 		// Output as INIT until the server is ready
 		default:
@@ -117,7 +266,7 @@ func superviseAvorionOut(s *Server, ready chan struct{},
 				if e == nil {
 					continue
 				}
-				e.Handler(s, e, out, nil)
+				s.handleEvent(e, out)
 			}
 		}
 	}
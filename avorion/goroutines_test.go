@@ -0,0 +1,79 @@
+package avorion
+
+import (
+	gamedb "avorioncontrol/avorion/database"
+	"avorioncontrol/avorion/events"
+	"avorioncontrol/ifaces"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSuperviseEventsPrunes(t *testing.T) {
+	db, err := gamedb.New(filepath.Join(t.TempDir(), "data.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for _, e := range []ifaces.GameEvent{
+		{Time: now.Add(-72 * time.Hour), Type: "old", Message: "Pruned"},
+		{Time: now.Add(-25 * time.Hour), Type: "old", Message: "Pruned"},
+		{Time: now.Add(-time.Hour), Type: "recent", Message: "Kept"},
+	} {
+		if err := db.AddEvent(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		closech = make(chan struct{})
+		done    = make(chan struct{})
+		s       = &Server{
+			uuid:       "test",
+			exit:       make(chan struct{}),
+			tracking:   db,
+			trackmutex: new(sync.RWMutex),
+			config: &testConfig{dbupdate: 10 * time.Millisecond,
+				retention: 24 * time.Hour}}
+	)
+
+	s.bus = events.NewBus(s)
+	defer s.bus.Close()
+
+	go func() {
+		superviseEvents(s, closech)
+		close(done)
+	}()
+
+	defer func() {
+		close(closech)
+		<-done
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		found, err := db.SearchEvents(ifaces.GameEventQuery{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(found) == 1 {
+			if found[0].Type != "recent" {
+				t.Fatalf("kept %q, expected the recent event", found[0].Type)
+			}
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("%d events are left, expected the old ones to be pruned",
+				len(found))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
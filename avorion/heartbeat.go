@@ -0,0 +1,187 @@
+package avorion
+
+import (
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	heartbeatHistory = 360 // One hour at the default interval
+	tickLagWindow    = 30  // Heartbeats that are averaged for lag alerts
+
+	errHeartbeatMissing = "No heartbeat received from the game loop in %s"
+)
+
+// heartbeatMonitor tracks the heartbeats sent by the avocontrol-utilities mod
+type heartbeatMonitor struct {
+	mutex   *sync.Mutex
+	beats   []ifaces.Heartbeat
+	seen    bool // A heartbeat was received since the last Reset
+	lagging bool // The lag alert has been sent
+	legacy  bool // The fallback warning has been logged
+}
+
+func newHeartbeatMonitor() *heartbeatMonitor {
+	return &heartbeatMonitor{
+		mutex: new(sync.Mutex),
+		beats: make([]ifaces.Heartbeat, 0)}
+}
+
+// Reset forgets the heartbeats of a previous server process
+func (h *heartbeatMonitor) Reset() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.beats = h.beats[:0]
+	h.seen = false
+	h.lagging = false
+}
+
+// Add records a heartbeat, dropping the oldest one once the history is full
+func (h *heartbeatMonitor) Add(b ifaces.Heartbeat) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if len(h.beats) >= heartbeatHistory {
+		copy(h.beats, h.beats[1:])
+		h.beats = h.beats[:len(h.beats)-1]
+	}
+
+	h.beats = append(h.beats, b)
+	h.seen = true
+}
+
+// Last returns the time of the most recent heartbeat, and whether one has been
+// received since the last Reset
+func (h *heartbeatMonitor) Last() (time.Time, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if !h.seen {
+		return time.Time{}, false
+	}
+
+	return h.beats[len(h.beats)-1].Time, true
+}
+
+// Beats returns a copy of the heartbeat history, oldest first
+func (h *heartbeatMonitor) Beats() []ifaces.Heartbeat {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	beats := make([]ifaces.Heartbeat, len(h.beats))
+	copy(beats, h.beats)
+	return beats
+}
+
+// averageLag returns the average tick lag of the last n heartbeats
+func averageLag(beats []ifaces.Heartbeat, n int) time.Duration {
+	if len(beats) > n {
+		beats = beats[len(beats)-n:]
+	}
+
+	if len(beats) == 0 {
+		return 0
+	}
+
+	var total time.Duration
+	for _, b := range beats {
+		total += b.Lag()
+	}
+
+	return total / time.Duration(len(beats))
+}
+
+// alertTickLag notifies the log channel when the average tick lag rises above,
+// or falls back below, the configured threshold
+func (s *Server) alertTickLag() {
+	limit := s.config.TickLagAlert()
+	if limit <= 0 {
+		return
+	}
+
+	lag := averageLag(s.heartbeats.Beats(), tickLagWindow)
+
+	s.heartbeats.mutex.Lock()
+	defer s.heartbeats.mutex.Unlock()
+
+	switch {
+	case lag > limit && !s.heartbeats.lagging:
+		s.heartbeats.lagging = true
+		logger.LogWarning(s, sprintf("Average tick lag is %s", lag.String()))
+		go s.SendLog(ifaces.ChatData{Msg: sprintf("**Tick lag alert:** the game "+
+			"loop is running %s behind on average, above the %s threshold",
+			lag.Round(time.Millisecond), limit)})
+
+	case lag <= limit && s.heartbeats.lagging:
+		s.heartbeats.lagging = false
+		logger.LogInfo(s, "Tick lag has recovered")
+		go s.SendLog(ifaces.ChatData{Msg: sprintf("Tick lag has recovered to %s",
+			lag.Round(time.Millisecond))})
+	}
+}
+
+// setHeartbeatInterval tells the avocontrol-utilities mod how often to send
+// heartbeats
+func (s *Server) setHeartbeatInterval() {
+	secs := int64(s.config.HeartbeatInterval() / time.Second)
+	if _, err := s.RunCommand(sprintf("setheartbeat %d", secs)); err != nil {
+		logger.LogWarning(s, "Failed to set the heartbeat interval: "+err.Error())
+	}
+}
+
+/*********************************/
+/* IFace ifaces.IHeartbeatServer */
+/*********************************/
+
+// Heartbeat records a heartbeat from the game loop
+func (s *Server) Heartbeat(b ifaces.Heartbeat) {
+	s.heartbeats.Add(b)
+}
+
+// Heartbeats returns the recent heartbeats of the game loop, oldest first
+func (s *Server) Heartbeats() []ifaces.Heartbeat {
+	return s.heartbeats.Beats()
+}
+
+// CheckHang returns an error if the game loop has stopped sending heartbeats.
+// Servers that have not sent a heartbeat since starting (such as those without
+// the avocontrol-utilities mod) are checked over RCON once the startup grace
+// period has passed.
+func (s *Server) CheckHang() error {
+	if !s.IsUp() {
+		return errors.New(errServerOffline)
+	}
+
+	grace, startup := s.config.HeartbeatGrace()
+	last, seen := s.heartbeats.Last()
+
+	switch {
+	case seen && time.Since(last) > grace:
+		return errors.New(sprintf(errHeartbeatMissing,
+			time.Since(last).Round(time.Second)))
+
+	case seen:
+		s.alertTickLag()
+		return nil
+
+	case time.Since(s.state.last) < startup:
+		return nil
+	}
+
+	s.heartbeats.mutex.Lock()
+	if !s.heartbeats.legacy {
+		s.heartbeats.legacy = true
+		logger.LogWarning(s, "No heartbeats received, falling back to RCON checks. "+
+			"Is the avocontrol-utilities mod installed?")
+	}
+	s.heartbeats.mutex.Unlock()
+
+	_, err := s.RunCommandContext(context.Background(),
+		ifaces.CommandPriorityHealth, "echo Server status check")
+	return err
+}
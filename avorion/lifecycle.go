@@ -0,0 +1,328 @@
+package avorion
+
+import (
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	stateHistorySize = 200
+	stateHistoryFile = "avorioncontrol-history.json"
+	stateSubBuffer   = 16
+
+	errInvalidTransition = "Invalid state transition from %s to %s"
+)
+
+// stateTransitions lists the states that can follow each state, ignoring
+// whether or not the server has crashed. Restarting covers both the stop and
+// start of a restart.
+var stateTransitions = map[int][]int{
+	ifaces.ServerOffline: {
+		ifaces.ServerStarting,
+		ifaces.ServerRestarting},
+	ifaces.ServerStarting: {
+		ifaces.ServerOnline,
+		ifaces.ServerOffline},
+	ifaces.ServerOnline: {
+		ifaces.ServerStopping,
+		ifaces.ServerRestarting,
+		ifaces.ServerOffline},
+	ifaces.ServerStopping: {
+		ifaces.ServerOffline},
+	ifaces.ServerRestarting: {
+		ifaces.ServerOnline,
+		ifaces.ServerOffline}}
+
+// RunState describes the lifecycle state of the server
+type RunState struct {
+	// Held for the duration of a Start or Stop
+	mutex *sync.Mutex
+
+	smutex      *sync.Mutex
+	fmutex      *sync.Mutex
+	current     int
+	history     []ifaces.StateTransition
+	subscribers map[chan ifaces.StateTransition]struct{}
+
+	// Track the last time the server was started
+	last time.Time
+}
+
+func newRunState() *RunState {
+	return &RunState{
+		mutex:       new(sync.Mutex),
+		smutex:      new(sync.Mutex),
+		fmutex:      new(sync.Mutex),
+		current:     ifaces.ServerOffline,
+		history:     make([]ifaces.StateTransition, 0),
+		subscribers: make(map[chan ifaces.StateTransition]struct{}),
+		last:        time.Now()}
+}
+
+// baseState returns a state without its crashed offset
+func baseState(st int) int {
+	if st >= ifaces.ServerCrashedOffline {
+		return st - ifaces.ServerCrashedOffline
+	}
+	return st
+}
+
+// isCrashedState returns whether or not a state has the crashed offset
+func isCrashedState(st int) bool {
+	return st >= ifaces.ServerCrashedOffline
+}
+
+// validTransition returns whether or not a server can move between two states.
+// Only the crashed offset is allowed to change within the same base state.
+func validTransition(from, to int) bool {
+	from, to = baseState(from), baseState(to)
+	if from == to {
+		return true
+	}
+
+	for _, next := range stateTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stateName returns a human readable name for a state
+func stateName(st int) string {
+	name, _ := ifaces.State(st)
+	return name
+}
+
+// get returns the current state
+func (r *RunState) get() int {
+	r.smutex.Lock()
+	defer r.smutex.Unlock()
+	return r.current
+}
+
+// base returns the current state without its crashed offset
+func (r *RunState) base() int {
+	return baseState(r.get())
+}
+
+// crashed returns whether or not the current state has the crashed offset
+func (r *RunState) crashed() bool {
+	return isCrashedState(r.get())
+}
+
+// exitedUnexpectedly returns whether or not the server went offline from being
+// online, rather than being stopped or failing to start
+func (r *RunState) exitedUnexpectedly() bool {
+	r.smutex.Lock()
+	defer r.smutex.Unlock()
+
+	if baseState(r.current) != ifaces.ServerOffline {
+		return false
+	}
+
+	// Skip over changes to the crashed offset to find what we came from
+	for i := len(r.history) - 1; i >= 0; i-- {
+		t := r.history[i]
+		if baseState(t.From) == baseState(t.To) {
+			continue
+		}
+		return baseState(t.From) == ifaces.ServerOnline
+	}
+
+	return false
+}
+
+// transition moves to a new state and records it. Returns false if the state
+// did not change.
+func (r *RunState) transition(to int, reason string) (ifaces.StateTransition,
+	bool, error) {
+	r.smutex.Lock()
+	defer r.smutex.Unlock()
+
+	t := ifaces.StateTransition{
+		From:   r.current,
+		To:     to,
+		Time:   time.Now(),
+		Reason: reason}
+
+	if r.current == to {
+		return t, false, nil
+	}
+
+	if !validTransition(r.current, to) {
+		return t, false, errors.New(sprintf(errInvalidTransition,
+			stateName(r.current), stateName(to)))
+	}
+
+	r.current = to
+	if len(r.history) >= stateHistorySize {
+		copy(r.history, r.history[1:])
+		r.history = r.history[:len(r.history)-1]
+	}
+	r.history = append(r.history, t)
+
+	// Subscribers that fall behind miss transitions rather than blocking
+	for ch := range r.subscribers {
+		select {
+		case ch <- t:
+		default:
+		}
+	}
+
+	return t, true, nil
+}
+
+// setState moves the server to a new state, logging and persisting the change.
+// Invalid transitions are logged and ignored.
+func (s *Server) setState(to int, reason string) error {
+	t, changed, err := s.state.transition(to, reason)
+	if err != nil {
+		logger.LogError(s, err.Error())
+		return err
+	}
+
+	if !changed {
+		return nil
+	}
+
+	logger.LogDebug(s, sprintf("State changed from %s to %s (%s)",
+		stateName(t.From), stateName(t.To), reason))
+
+	if err := s.saveStateHistory(); err != nil {
+		logger.LogWarning(s, "Failed to save state history: "+err.Error())
+	}
+
+	return nil
+}
+
+// crashState returns the given state, offset if the server has crashed
+func (s *Server) crashState(st int) int {
+	if s.state.crashed() {
+		return st + ifaces.ServerCrashedOffline
+	}
+	return st
+}
+
+// setCrashed adds or removes the crashed offset from the current state
+func (s *Server) setCrashed(crashed bool, reason string) {
+	st := baseState(s.state.get())
+	if crashed {
+		st += ifaces.ServerCrashedOffline
+	}
+	s.setState(st, reason)
+}
+
+// stateHistoryPath returns the path of the persisted state history
+func (s *Server) stateHistoryPath() string {
+	return s.galaxyPath() + "/" + stateHistoryFile
+}
+
+// saveStateHistory writes the state history to the galaxy directory
+func (s *Server) saveStateHistory() error {
+	s.state.fmutex.Lock()
+	defer s.state.fmutex.Unlock()
+
+	data, err := json.Marshal(s.StateHistory())
+	if err != nil {
+		return err
+	}
+
+	// The galaxy directory doesn't exist until the server has been started once
+	if _, err := os.Stat(s.galaxyPath()); os.IsNotExist(err) {
+		return nil
+	}
+
+	file := s.stateHistoryPath()
+	if err := ioutil.WriteFile(file+".partial", data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(file+".partial", file)
+}
+
+// loadStateHistory reads the persisted state history. The server always begins
+// offline, whatever the last recorded state was.
+func (s *Server) loadStateHistory() {
+	file := s.stateHistoryPath()
+	info, err := os.Stat(file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.LogWarning(s, "Failed to read state history: "+err.Error())
+		}
+		return
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.LogWarning(s, "Failed to read state history: "+err.Error())
+		}
+		return
+	}
+
+	history := make([]ifaces.StateTransition, 0)
+	if err := json.Unmarshal(data, &history); err != nil {
+		logger.LogWarning(s, "Failed to parse state history: "+err.Error())
+		return
+	}
+
+	// Close out whatever state we were in when the bot last exited
+	if n := len(history); n > 0 && history[n-1].To != ifaces.ServerOffline {
+		history = append(history, ifaces.StateTransition{
+			From:   history[n-1].To,
+			To:     ifaces.ServerOffline,
+			Time:   info.ModTime(),
+			Reason: "AvorionControl exited"})
+	}
+
+	if len(history) > stateHistorySize {
+		history = history[len(history)-stateHistorySize:]
+	}
+
+	s.state.smutex.Lock()
+	s.state.history = history
+	s.state.smutex.Unlock()
+}
+
+/*********************************/
+/* IFace ifaces.ILifecycleServer */
+/*********************************/
+
+// StateHistory returns the recent state transitions of the server, oldest
+// first
+func (s *Server) StateHistory() []ifaces.StateTransition {
+	s.state.smutex.Lock()
+	defer s.state.smutex.Unlock()
+
+	history := make([]ifaces.StateTransition, len(s.state.history))
+	copy(history, s.state.history)
+	return history
+}
+
+// SubscribeState returns a channel that receives every state transition, and
+// a function that cancels the subscription. Transitions are dropped for
+// subscribers that are not keeping up.
+func (s *Server) SubscribeState() (<-chan ifaces.StateTransition, func()) {
+	ch := make(chan ifaces.StateTransition, stateSubBuffer)
+
+	s.state.smutex.Lock()
+	s.state.subscribers[ch] = struct{}{}
+	s.state.smutex.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.state.smutex.Lock()
+			delete(s.state.subscribers, ch)
+			s.state.smutex.Unlock()
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package avorion
+
+import (
+	"avorioncontrol/ifaces"
+	"strings"
+	"testing"
+)
+
+func TestBaseState(t *testing.T) {
+	for _, tc := range []struct {
+		st      int
+		base    int
+		crashed bool
+	}{
+		{ifaces.ServerOffline, ifaces.ServerOffline, false},
+		{ifaces.ServerRestarting, ifaces.ServerRestarting, false},
+		{ifaces.ServerCrashedOffline, ifaces.ServerOffline, true},
+		{ifaces.ServerCrashedRecovered, ifaces.ServerOnline, true},
+		{ifaces.ServerCrashedStarting, ifaces.ServerStarting, true},
+		{ifaces.ServerCrashedStopping, ifaces.ServerStopping, true},
+		{ifaces.ServerCrashedRestarting, ifaces.ServerRestarting, true},
+	} {
+		if got := baseState(tc.st); got != tc.base {
+			t.Errorf("baseState(%d) = %d, expected %d", tc.st, got, tc.base)
+		}
+
+		if got := isCrashedState(tc.st); got != tc.crashed {
+			t.Errorf("isCrashedState(%d) = %t, expected %t", tc.st, got, tc.crashed)
+		}
+	}
+}
+
+func TestValidTransition(t *testing.T) {
+	for _, tc := range []struct {
+		from, to int
+		want     bool
+	}{
+		{ifaces.ServerOffline, ifaces.ServerStarting, true},
+		{ifaces.ServerOffline, ifaces.ServerRestarting, true},
+		{ifaces.ServerStarting, ifaces.ServerOnline, true},
+		{ifaces.ServerStarting, ifaces.ServerOffline, true},
+		{ifaces.ServerOnline, ifaces.ServerStopping, true},
+		{ifaces.ServerOnline, ifaces.ServerRestarting, true},
+		{ifaces.ServerOnline, ifaces.ServerOffline, true},
+		{ifaces.ServerStopping, ifaces.ServerOffline, true},
+		{ifaces.ServerRestarting, ifaces.ServerOnline, true},
+		{ifaces.ServerRestarting, ifaces.ServerOffline, true},
+
+		{ifaces.ServerOffline, ifaces.ServerOnline, false},
+		{ifaces.ServerOffline, ifaces.ServerStopping, false},
+		{ifaces.ServerStarting, ifaces.ServerStopping, false},
+		{ifaces.ServerStarting, ifaces.ServerRestarting, false},
+		{ifaces.ServerOnline, ifaces.ServerStarting, false},
+		{ifaces.ServerStopping, ifaces.ServerOnline, false},
+		{ifaces.ServerStopping, ifaces.ServerStarting, false},
+		{ifaces.ServerRestarting, ifaces.ServerStopping, false},
+
+		// The crashed offset can change on its own, and doesn't change which
+		// states can follow
+		{ifaces.ServerOnline, ifaces.ServerCrashedRecovered, true},
+		{ifaces.ServerCrashedRecovered, ifaces.ServerOnline, true},
+		{ifaces.ServerOffline, ifaces.ServerCrashedOffline, true},
+		{ifaces.ServerCrashedOffline, ifaces.ServerCrashedStarting, true},
+		{ifaces.ServerCrashedOffline, ifaces.ServerStarting, true},
+		{ifaces.ServerCrashedStarting, ifaces.ServerCrashedRecovered, true},
+		{ifaces.ServerCrashedRecovered, ifaces.ServerCrashedStopping, true},
+		{ifaces.ServerCrashedRestarting, ifaces.ServerOffline, true},
+		{ifaces.ServerCrashedOffline, ifaces.ServerCrashedRecovered, false},
+		{ifaces.ServerCrashedStopping, ifaces.ServerStarting, false},
+		{ifaces.ServerStarting, ifaces.ServerCrashedStopping, false},
+	} {
+		if got := validTransition(tc.from, tc.to); got != tc.want {
+			t.Errorf("validTransition(%s, %s) = %t, expected %t", stateName(tc.from),
+				stateName(tc.to), got, tc.want)
+		}
+	}
+}
+
+func TestTransition(t *testing.T) {
+	r := newRunState()
+
+	if _, changed, err := r.transition(ifaces.ServerOffline, "noop"); changed ||
+		err != nil {
+		t.Fatalf("moving to the current state: changed %t, error %v", changed, err)
+	}
+
+	_, changed, err := r.transition(ifaces.ServerOnline, "skip starting")
+	if err == nil || !strings.Contains(err.Error(), "Invalid state transition") {
+		t.Fatalf("got error %v, expected an invalid transition", err)
+	}
+
+	if changed || r.get() != ifaces.ServerOffline || len(r.history) != 0 {
+		t.Fatalf("a rejected transition changed the state to %s",
+			stateName(r.get()))
+	}
+
+	for _, st := range []int{ifaces.ServerStarting, ifaces.ServerOnline,
+		ifaces.ServerCrashedRecovered, ifaces.ServerCrashedOffline} {
+		if _, changed, err := r.transition(st, "test"); !changed || err != nil {
+			t.Fatalf("moving to %s: changed %t, error %v", stateName(st), changed,
+				err)
+		}
+	}
+
+	if r.base() != ifaces.ServerOffline || !r.crashed() {
+		t.Fatalf("ended up in %s, expected %s", stateName(r.get()),
+			stateName(ifaces.ServerCrashedOffline))
+	}
+
+	if len(r.history) != 4 {
+		t.Fatalf("recorded %d transitions, expected 4", len(r.history))
+	}
+
+	// Changing the crashed offset is skipped over to find where we came from
+	if !r.exitedUnexpectedly() {
+		t.Fatal("going offline from online was not unexpected")
+	}
+}
+
+func TestExitedUnexpectedly(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		states []int
+		want   bool
+	}{
+		{"never started", nil, false},
+		{"stopped", []int{ifaces.ServerStarting, ifaces.ServerOnline,
+			ifaces.ServerStopping, ifaces.ServerOffline}, false},
+		{"failed to start", []int{ifaces.ServerStarting, ifaces.ServerOffline},
+			false},
+		{"exited", []int{ifaces.ServerStarting, ifaces.ServerOnline,
+			ifaces.ServerOffline}, true},
+		{"crashed after exiting", []int{ifaces.ServerStarting, ifaces.ServerOnline,
+			ifaces.ServerOffline, ifaces.ServerCrashedOffline}, true},
+		{"still online", []int{ifaces.ServerStarting, ifaces.ServerOnline}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newRunState()
+			for _, st := range tc.states {
+				if _, _, err := r.transition(st, "test"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := r.exitedUnexpectedly(); got != tc.want {
+				t.Fatalf("got %t, expected %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSubscribeState(t *testing.T) {
+	s := &Server{state: newRunState()}
+
+	ch, cancel := s.SubscribeState()
+	other, cancelOther := s.SubscribeState()
+	defer cancelOther()
+
+	if _, _, err := s.state.transition(ifaces.ServerStarting, "start"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []<-chan ifaces.StateTransition{ch, other} {
+		select {
+		case tr := <-c:
+			if tr.From != ifaces.ServerOffline || tr.To != ifaces.ServerStarting ||
+				tr.Reason != "start" {
+				t.Fatalf("got transition %+v", tr)
+			}
+		default:
+			t.Fatal("a subscriber was not notified")
+		}
+	}
+
+	// Rejected transitions aren't sent
+	s.state.transition(ifaces.ServerStopping, "invalid")
+	if len(ch) != 0 {
+		t.Fatal("a rejected transition was sent to subscribers")
+	}
+
+	cancel()
+	cancel()
+	s.state.transition(ifaces.ServerOnline, "online")
+	if len(ch) != 0 {
+		t.Fatal("a cancelled subscriber was notified")
+	}
+
+	if len(other) != 1 {
+		t.Fatal("a remaining subscriber was not notified")
+	}
+
+	// A subscriber that isn't keeping up misses transitions instead of
+	// blocking them
+	for i := 0; i < stateSubBuffer*2; i++ {
+		st := ifaces.ServerOnline
+		if i%2 == 0 {
+			st = ifaces.ServerCrashedRecovered
+		}
+
+		if _, _, err := s.state.transition(st, "flap"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(other) != stateSubBuffer {
+		t.Fatalf("subscriber has %d queued transitions, expected %d", len(other),
+			stateSubBuffer)
+	}
+}
@@ -0,0 +1,198 @@
+package avorion
+
+import (
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	workshopAppID   = "445220"
+	steamCMDTimeout = 10 * time.Minute
+
+	modURLBase       = `https://steamcommunity.com/sharedfiles/filedetails/?id=`
+	errModUpdatesOff = "Checking for mod updates is disabled"
+)
+
+// steamcmdmutex keeps galaxies from running steamcmd in the same directory at
+// the same time
+var steamcmdmutex = new(sync.Mutex)
+
+// modFingerprint summarizes the files of a downloaded mod, so that changes to
+// it can be spotted. Mods that haven't been downloaded have an empty print.
+func modFingerprint(dir string) string {
+	var (
+		newest time.Time
+		size   int64
+		files  int
+	)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			files++
+			size += info.Size()
+			if info.ModTime().After(newest) {
+				newest = info.ModTime()
+			}
+		}
+		return nil
+	})
+
+	if err != nil || files == 0 {
+		return ""
+	}
+
+	return sprintf("%d/%d/%d", newest.UnixNano(), size, files)
+}
+
+// downloadMods has steamcmd download the current version of the given mods
+func (s *Server) downloadMods(dir string, mods []int64) error {
+	steamcmdmutex.Lock()
+	defer steamcmdmutex.Unlock()
+
+	args := []string{"+force_install_dir", dir, "+login", "anonymous"}
+	for _, id := range mods {
+		args = append(args, "+workshop_download_item", workshopAppID,
+			strconv.FormatInt(id, 10))
+	}
+	args = append(args, "+quit")
+
+	ctx, cancel := context.WithTimeout(context.Background(), steamCMDTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, s.config.SteamCMDPath(), args...).
+		CombinedOutput()
+	if err != nil {
+		logger.LogDebug(s, "steamcmd output: "+string(out))
+		return errors.New("steamcmd failed: " + err.Error())
+	}
+
+	return nil
+}
+
+// modUpdates returns the mods that changed since they were last checked. The
+// first check after the server starts only records the current versions.
+func (s *Server) modUpdates() ([]int64, error) {
+	method, _ := s.config.ModUpdateCheck()
+	if method == ifaces.ModUpdatesOff {
+		return nil, errors.New(errModUpdatesOff)
+	}
+
+	mods := s.config.ListServerMods()
+	if method == ifaces.ModUpdatesSteamCMD && len(mods) > 0 {
+		if err := s.downloadMods(s.config.WorkshopPath(), mods); err != nil {
+			return nil, err
+		}
+	}
+
+	dir := s.config.WorkshopContentPath()
+	s.modmutex.Lock()
+	defer s.modmutex.Unlock()
+
+	baseline := s.modversions == nil
+	versions := make(map[int64]string, len(mods))
+	updated := make([]int64, 0)
+
+	for _, id := range mods {
+		print := modFingerprint(dir + strconv.FormatInt(id, 10))
+		versions[id] = print
+
+		// Mods that weren't downloaded before are new, not updated
+		if old := s.modversions[id]; !baseline && old != "" && old != print {
+			updated = append(updated, id)
+		}
+	}
+
+	s.modversions = versions
+	return updated, nil
+}
+
+// announceModUpdates tells players and Discord about updated mods, and restarts
+// the server once it is empty to load them
+func (s *Server) announceModUpdates(mods []int64) {
+	for _, id := range mods {
+		msg := sprintf("Mod %d was updated: %s%d", id, modURLBase, id)
+		logger.LogInfo(s, msg)
+		s.SendChat(ifaces.ChatData{Name: "Server", Msg: msg})
+	}
+
+	s.SendLog(ifaces.ChatData{Msg: sprintf("**%d mod(s) were updated** on %s, "+
+		"restarting once the server is empty", len(mods), s.config.Galaxy())})
+
+	err := s.SoftRestart(s.config.SoftRestartDeadline())
+	switch {
+	case err == nil:
+	case err.Error() == errSoftRestartPending:
+		logger.LogInfo(s, "Mod updates will be loaded by the pending restart")
+	default:
+		logger.LogError(s, "Failed to schedule a restart for mod updates: "+
+			err.Error())
+	}
+}
+
+// superviseModUpdates periodically checks the configured mods for updates while
+// the server is running
+func superviseModUpdates(s *Server, closech chan struct{}) {
+	method, interval := s.config.ModUpdateCheck()
+	if method == ifaces.ModUpdatesOff {
+		return
+	}
+
+	defer func() { logger.LogInfo(s, "Stopping old mod update supervisor") }()
+	logger.LogInit(s, "Starting mod update supervisor")
+
+	// Avorion downloads updates when it starts, so start from a new baseline
+	s.modmutex.Lock()
+	s.modversions = nil
+	s.modmutex.Unlock()
+
+	wait := time.Minute
+	for {
+		select {
+		case <-closech:
+			return
+		case <-s.exit:
+			return
+		case <-time.After(wait):
+		}
+
+		if !s.IsUp() || s.state.base() != ifaces.ServerOnline {
+			continue
+		}
+
+		wait = interval
+		if _, err := s.CheckModUpdates(); err != nil {
+			logger.LogWarning(s, "Failed to check for mod updates: "+err.Error())
+		}
+	}
+}
+
+/*********************************/
+/* IFace ifaces.IModUpdateServer */
+/*********************************/
+
+// CheckModUpdates checks the configured mods for updates, announcing any that
+// were found and scheduling a restart to load them
+func (s *Server) CheckModUpdates() ([]int64, error) {
+	updated, err := s.modUpdates()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(updated) > 0 {
+		s.announceModUpdates(updated)
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,36 @@
+package avorion
+
+import (
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"strings"
+	"time"
+)
+
+// sendMOTD tells the avocontrol-utilities mod which message of the day to show
+func (s *Server) sendMOTD() {
+	motd := strings.Join(strings.Fields(s.MOTD()), " ")
+	if _, err := s.RunCommand(strings.TrimSpace("setmotd " + motd)); err != nil {
+		logger.LogWarning(s, "Failed to set the MOTD: "+err.Error())
+	}
+}
+
+// superviseMOTD rotates the message of the day while the server is running
+func superviseMOTD(s *Server, closech chan struct{}) {
+	defer func() { logger.LogInfo(s, "Stopping old MOTD supervisor") }()
+	logger.LogInit(s, "Starting MOTD supervisor")
+
+	for {
+		select {
+		case <-closech:
+			return
+		case <-s.exit:
+			return
+		case <-time.After(s.config.MOTDRotation()):
+		}
+
+		if s.IsUp() && s.state.base() == ifaces.ServerOnline {
+			s.RotateMOTD()
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package avorion
 
 import (
+	"avorioncontrol/avorion/events"
 	"avorioncontrol/ifaces"
 	"avorioncontrol/logger"
+	"context"
 	"fmt"
 	"net"
 	"regexp"
@@ -92,7 +94,9 @@ func (p *Player) AddJump(sc ifaces.ShipCoordData) {
 	sector.Jumphistory = append(sector.Jumphistory, jump)
 
 	id, _ := strconv.Atoi(p.Index())
-	p.server.tracking.AddJump(sector.Index, int64(id), 0, *jump)
+	if db := p.server.trackingDB(); db != nil {
+		db.AddJump(sector.Index, int64(id), 0, *jump)
+	}
 	logger.LogDebug(p, "Updated jumphistory")
 }
 
@@ -119,6 +123,8 @@ func (p *Player) Name() string {
 func (p *Player) Kick(r string) {
 	logger.LogWarning(p, "Kicked: "+r)
 	p.server.RunCommand(sprintf(`kick %s "%s"`, p.Index(), r))
+	p.server.publish(events.KindPlayerKick, &events.Moderation{
+		Index: p.Index(), Name: p.Name(), Reason: r})
 	p.server.SendLog(ifaces.ChatData{
 		Msg: fmt.Sprintf("**Kicked Player:** `%s`\n**Reason:** _%s_",
 			p.Name(), r)})
@@ -127,6 +133,8 @@ func (p *Player) Kick(r string) {
 // Ban bans the player
 func (p *Player) Ban(r string) {
 	p.server.RunCommand(sprintf(`ban %s "%s"`, p.Index(), r))
+	p.server.publish(events.KindPlayerBan, &events.Moderation{
+		Index: p.Index(), Name: p.Name(), Reason: r})
 	p.server.SendLog(ifaces.ChatData{
 		Msg: fmt.Sprintf("**Banned Player:** `%s`\n**Reason:** _%s_",
 			p.Name(), r)})
@@ -146,7 +154,8 @@ func (p *Player) SetOnline(o bool) {
 func (p *Player) SetDiscordUID(uid string) {
 	p.discordid = uid
 	if uid != "" {
-		p.server.RunCommand(sprintf(rconPlayerDiscord, p.index, uid))
+		p.server.RunCommandContext(context.Background(),
+			ifaces.CommandPriorityBulk, sprintf(rconPlayerDiscord, p.index, uid))
 	}
 }
 
@@ -172,7 +181,8 @@ func (p *Player) SteamUID() int64 {
 	}
 
 	cmd := sprintf(steamUIDCommand, p.Index())
-	out, err := p.server.RunCommand(cmd)
+	out, err := p.server.RunCommandContext(context.Background(),
+		ifaces.CommandPriorityBulk, cmd)
 	if err != nil {
 		return 0
 	}
@@ -0,0 +1,168 @@
+package avorion
+
+import (
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+const errPreflightFailed = "Pre-flight checks failed"
+
+// checkExecutable makes sure that the Avorion server binary exists and can be
+// run
+func (s *Server) checkExecutable() ifaces.PreflightCheck {
+	var (
+		check = ifaces.PreflightCheck{Name: "Server binary"}
+		path  = s.serverpath + "/bin/" + s.executable
+	)
+
+	info, err := os.Stat(path)
+	switch {
+	case err != nil:
+		check.Message = "Cannot find " + path
+	case !info.Mode().IsRegular():
+		check.Message = path + " is not a file"
+	case info.Mode().Perm()&0111 == 0:
+		check.Message = path + " is not executable"
+	default:
+		check.Passed = true
+		check.Message = path
+	}
+
+	return check
+}
+
+// checkPorts makes sure that none of the ports that Avorion needs are in use.
+// The ports of a running server are its own, so they aren't checked.
+func (s *Server) checkPorts() ifaces.PreflightCheck {
+	check := ifaces.PreflightCheck{Name: "Ports"}
+
+	ports := []struct {
+		name string
+		port int
+	}{
+		{"game", s.config.GamePort()},
+		{"query", s.config.GamePort() + 3},
+		{"ping", s.config.PingPort()},
+		{"Steam master", s.config.PingPort() + 1},
+		{"RCON", s.config.RCONPort()}}
+
+	if s.IsUp() {
+		check.Passed = true
+		check.Message = "In use by the running server"
+		return check
+	}
+
+	busy := make([]string, 0)
+	free := make([]string, 0)
+	for _, p := range ports {
+		desc := sprintf("%s %d", p.name, p.port)
+		if s.config.PortAvailable(p.port) {
+			free = append(free, desc)
+		} else {
+			busy = append(busy, desc)
+		}
+	}
+
+	if len(busy) > 0 {
+		check.Message = "Already in use: " + strings.Join(busy, ", ")
+		return check
+	}
+
+	check.Passed = true
+	check.Message = "Available: " + strings.Join(free, ", ")
+	return check
+}
+
+// checkWritable makes sure that the data directory, and the galaxy directory if
+// it exists, can be written to
+func (s *Server) checkWritable() ifaces.PreflightCheck {
+	var (
+		check = ifaces.PreflightCheck{Name: "Data directory"}
+		dirs  = []string{strings.TrimSuffix(s.config.DataPath(), "/")}
+	)
+
+	if _, err := os.Stat(s.galaxyPath()); err == nil {
+		dirs = append(dirs, s.galaxyPath())
+	}
+
+	for _, dir := range dirs {
+		f, err := ioutil.TempFile(dir, ".avorioncontrol-preflight-")
+		if err != nil {
+			check.Message = "Cannot write to " + dir + ": " + err.Error()
+			return check
+		}
+		f.Close()
+		os.Remove(f.Name())
+	}
+
+	check.Passed = true
+	check.Message = strings.Join(dirs, ", ")
+	return check
+}
+
+// checkModConfig makes sure that the configured mods can be loaded
+func (s *Server) checkModConfig() ifaces.PreflightCheck {
+	check := ifaces.PreflightCheck{Name: "Mod configuration"}
+
+	errs, warnings := s.config.ValidateModConfig()
+	if len(errs) > 0 {
+		msgs := make([]string, 0, len(errs))
+		for _, err := range errs {
+			msgs = append(msgs, err.Error())
+		}
+		check.Message = strings.Join(msgs, "; ")
+		return check
+	}
+
+	for _, w := range warnings {
+		logger.LogWarning(s, "Mod configuration: "+w)
+	}
+
+	check.Passed = true
+	check.Message = sprintf("%d server mods, %d client mods",
+		len(s.config.ListServerMods()), len(s.config.ListClientMods()))
+	if len(warnings) > 0 {
+		check.Message += sprintf(" (warnings: %s)", strings.Join(warnings, "; "))
+	}
+	return check
+}
+
+// preflightError posts a failed pre-flight report to the log channel, and
+// returns the error that stops the server from starting
+func (s *Server) preflightError(r ifaces.PreflightReport) error {
+	var (
+		msg   = sprintf("**%s for %s:**", errPreflightFailed, s.config.Galaxy())
+		names = make([]string, 0)
+	)
+
+	for _, c := range r.Failed() {
+		logger.LogError(s, sprintf("Pre-flight: %s: %s", c.Name, c.Message))
+		msg += sprintf("\n• **%s:** %s", c.Name, c.Message)
+		names = append(names, c.Name)
+	}
+
+	s.SendLog(ifaces.ChatData{Msg: msg})
+	return errors.New(errPreflightFailed + ": " + strings.Join(names, ", "))
+}
+
+/*********************************/
+/* IFace ifaces.IPreflightServer */
+/*********************************/
+
+// Preflight checks that everything Avorion needs to start is in place
+func (s *Server) Preflight() ifaces.PreflightReport {
+	logger.LogDebug(s, "Preflight() was called")
+
+	return ifaces.PreflightReport{
+		Time: time.Now(),
+		Checks: []ifaces.PreflightCheck{
+			s.checkExecutable(),
+			s.checkPorts(),
+			s.checkWritable(),
+			s.checkModConfig()}}
+}
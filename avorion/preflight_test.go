@@ -0,0 +1,67 @@
+package avorion
+
+import (
+	"avorioncontrol/ifaces"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testConfig is an ifaces.IConfigurator for tests. Only the methods that it
+// overrides can be called.
+type testConfig struct {
+	ifaces.IConfigurator
+
+	gameport int
+	pingport int
+	rconport int
+	busy     map[int]bool
+
+	dbupdate  time.Duration
+	retention time.Duration
+}
+
+func (c *testConfig) GamePort() int               { return c.gameport }
+func (c *testConfig) PingPort() int               { return c.pingport }
+func (c *testConfig) RCONPort() int               { return c.rconport }
+func (c *testConfig) PortAvailable(port int) bool { return !c.busy[port] }
+
+func (c *testConfig) DBUpdateTimeDuration() time.Duration { return c.dbupdate }
+func (c *testConfig) EventRetention() time.Duration       { return c.retention }
+
+func TestCheckPorts(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		busy []int
+		want string
+	}{
+		{"free", nil, "Available: game 27000, query 27003, ping 27020, " +
+			"Steam master 27021, RCON 27015"},
+		{"game", []int{27000}, "Already in use: game 27000"},
+		{"query", []int{27003}, "Already in use: query 27003"},
+		{"ping", []int{27020}, "Already in use: ping 27020"},
+		{"Steam master", []int{27021}, "Already in use: Steam master 27021"},
+		{"RCON", []int{27015}, "Already in use: RCON 27015"},
+		{"several", []int{27003, 27021}, "Already in use: query 27003, " +
+			"Steam master 27021"},
+		{"unrelated", []int{27001, 27002, 27022}, "Available: game 27000, " +
+			"query 27003, ping 27020, Steam master 27021, RCON 27015"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &testConfig{gameport: 27000, pingport: 27020, rconport: 27015,
+				busy: make(map[int]bool)}
+			for _, port := range tc.busy {
+				c.busy[port] = true
+			}
+
+			check := (&Server{config: c}).checkPorts()
+			if check.Message != tc.want {
+				t.Fatalf("got %q, expected %q", check.Message, tc.want)
+			}
+
+			if check.Passed != strings.HasPrefix(tc.want, "Available") {
+				t.Fatalf("check passed: %t", check.Passed)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package avorion
+
+import (
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// clockTicks is the value of USER_HZ, which is 100 on every platform that
+// Avorion supports. Reading it properly would require cgo.
+const clockTicks = 100
+
+// procSample is a raw sample of a process, which is needed to calculate CPU
+// usage between two samples
+type procSample struct {
+	ifaces.ProcessStats
+	ticks uint64
+}
+
+// readProcSample reads the current resource usage of a process from /proc
+func readProcSample(pid int) (procSample, error) {
+	var (
+		sample = procSample{}
+		dir    = "/proc/" + strconv.Itoa(pid)
+	)
+
+	stat, err := ioutil.ReadFile(dir + "/stat")
+	if err != nil {
+		return sample, err
+	}
+
+	// The command name can contain spaces, so skip past it before splitting
+	i := strings.LastIndexByte(string(stat), ')')
+	if i < 0 {
+		return sample, errors.New("invalid format for " + dir + "/stat")
+	}
+
+	// Fields are offset by 3, since the pid and command have been removed
+	fields := strings.Fields(string(stat[i+1:]))
+	if len(fields) < 22 {
+		return sample, errors.New("invalid format for " + dir + "/stat")
+	}
+
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+	threads, _ := strconv.Atoi(fields[17])
+	rss, _ := strconv.ParseUint(fields[21], 10, 64)
+
+	fds, err := ioutil.ReadDir(dir + "/fd")
+	if err != nil {
+		return sample, err
+	}
+
+	sample.Time = time.Now()
+	sample.PID = pid
+	sample.RSS = rss * uint64(os.Getpagesize())
+	sample.Threads = threads
+	sample.FDs = len(fds)
+	sample.ticks = utime + stime
+	return sample, nil
+}
+
+// cpuPercent returns the CPU usage between two samples of the same process, as
+// a percentage of a single core
+func cpuPercent(prev, cur procSample) float64 {
+	elapsed := cur.Time.Sub(prev.Time).Seconds()
+	if prev.PID != cur.PID || elapsed <= 0 || cur.ticks < prev.ticks {
+		return 0
+	}
+
+	return float64(cur.ticks-prev.ticks) / clockTicks / elapsed * 100
+}
+
+// resourceHistory is a rolling history of ProcessStats
+type resourceHistory struct {
+	mutex   *sync.Mutex
+	samples []ifaces.ProcessStats
+	max     int
+}
+
+func newResourceHistory(max int) *resourceHistory {
+	if max < 1 {
+		max = 1
+	}
+
+	return &resourceHistory{
+		mutex:   new(sync.Mutex),
+		samples: make([]ifaces.ProcessStats, 0),
+		max:     max}
+}
+
+// Add records a sample, dropping the oldest one once the history is full
+func (h *resourceHistory) Add(p ifaces.ProcessStats) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if len(h.samples) >= h.max {
+		copy(h.samples, h.samples[1:])
+		h.samples = h.samples[:len(h.samples)-1]
+	}
+
+	h.samples = append(h.samples, p)
+}
+
+// Latest returns the most recent sample
+func (h *resourceHistory) Latest() (ifaces.ProcessStats, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if len(h.samples) == 0 {
+		return ifaces.ProcessStats{}, false
+	}
+
+	return h.samples[len(h.samples)-1], true
+}
+
+// Samples returns a copy of the history, oldest first
+func (h *resourceHistory) Samples() []ifaces.ProcessStats {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	samples := make([]ifaces.ProcessStats, len(h.samples))
+	copy(samples, h.samples)
+	return samples
+}
+
+// memoryThresholds returns the number of thresholds that rss is at or above
+func memoryThresholds(rss uint64, thresholds []uint64) int {
+	n := 0
+	for _, t := range thresholds {
+		if rss >= t {
+			n++
+		}
+	}
+	return n
+}
+
+// alertMemory notifies the log channel when the memory usage of Avorion crosses
+// one of the configured thresholds
+func (s *Server) alertMemory(rss uint64) {
+	thresholds := s.config.MemoryAlertThresholds()
+	n := memoryThresholds(rss, thresholds)
+
+	// The thresholds can shrink when the configuration is reloaded
+	if s.memalerts > len(thresholds) {
+		s.memalerts = len(thresholds)
+	}
+
+	switch {
+	case n > s.memalerts:
+		logger.LogWarning(s, sprintf("Memory usage is above %s",
+			humanize.IBytes(thresholds[n-1])))
+		s.SendLog(ifaces.ChatData{Msg: sprintf("**Memory alert:** Avorion is "+
+			"using %s of memory, which is above the %s threshold",
+			humanize.IBytes(rss), humanize.IBytes(thresholds[n-1]))})
+
+	case n < s.memalerts:
+		logger.LogInfo(s, sprintf("Memory usage dropped below %s",
+			humanize.IBytes(thresholds[n])))
+		s.SendLog(ifaces.ChatData{Msg: sprintf("Avorion memory usage has dropped "+
+			"to %s, below the %s threshold", humanize.IBytes(rss),
+			humanize.IBytes(thresholds[n]))})
+	}
+
+	s.memalerts = n
+}
+
+/*********************************/
+/* IFace ifaces.IMonitoredServer */
+/*********************************/
+
+// ProcessHistory returns the rolling history of the resource usage of the
+// Avorion process, oldest first
+func (s *Server) ProcessHistory() []ifaces.ProcessStats {
+	return s.resources.Samples()
+}
@@ -0,0 +1,287 @@
+package rcon
+
+import (
+	"avorioncontrol/logger"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Servers are only required to accept bodies up to this size
+	maxCommandSize = 4096 - packetHeaderSize - packetPaddingSize
+)
+
+var (
+	sprintf = fmt.Sprintf
+
+	// ErrAuthFailed is returned when the server rejects the configured password
+	ErrAuthFailed = errors.New("rcon: authentication failed")
+
+	// ErrCommandTooLong is returned when a command exceeds the maximum packet
+	// size permitted by the protocol
+	ErrCommandTooLong = errors.New("rcon: command is too long")
+)
+
+// Commander describes an object that can run RCON commands
+type Commander interface {
+	Exec(context.Context, string) (string, error)
+	Close() error
+}
+
+// Client is a Source RCON client. It maintains a single authenticated
+// connection to the server, and will transparently reconnect if that
+// connection is lost between calls.
+type Client struct {
+	addr     string
+	password string
+	timeout  time.Duration
+
+	conn  net.Conn
+	id    int32
+	mutex *sync.Mutex
+
+	loglevel int
+}
+
+// New returns a new RCON client for the server at addr. The connection is not
+// established until the first command is run. timeout is used as the deadline
+// for calls whose context does not provide an earlier one.
+func New(addr, password string, timeout time.Duration) *Client {
+	return &Client{
+		addr:     addr,
+		password: password,
+		timeout:  timeout,
+		mutex:    new(sync.Mutex)}
+}
+
+// Exec runs a command on the server and returns its (possibly multi-packet)
+// response. If a previously established connection turns out to be dead
+// before any response was read, the client reconnects and tries once more.
+func (c *Client) Exec(ctx context.Context, cmd string) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(cmd) > maxCommandSize {
+		return "", ErrCommandTooLong
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	reused := c.conn != nil
+	if !reused {
+		if err := c.connect(ctx); err != nil {
+			return "", contextError(ctx, err)
+		}
+	}
+
+	out, received, err := c.exec(ctx, cmd)
+	if err == nil {
+		return out, nil
+	}
+
+	c.close()
+	if cerr := contextError(ctx, err); cerr != err || !reused || received {
+		return "", cerr
+	}
+
+	logger.LogDebug(c, "Connection was lost, reconnecting: "+err.Error())
+	if err := c.connect(ctx); err != nil {
+		return "", contextError(ctx, err)
+	}
+
+	if out, _, err = c.exec(ctx, cmd); err != nil {
+		c.close()
+		return "", contextError(ctx, err)
+	}
+
+	return out, nil
+}
+
+// Close closes the current connection, if there is one. The client can still
+// be used afterwards, and will reconnect on the next call to Exec.
+func (c *Client) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.close()
+}
+
+func (c *Client) close() error {
+	if c.conn == nil {
+		return nil
+	}
+
+	err := c.conn.Close()
+	c.conn = nil
+	logger.LogDebug(c, "Closed connection")
+	return err
+}
+
+// connect dials the server and authenticates
+func (c *Client) connect(ctx context.Context) error {
+	deadline := c.deadline(ctx)
+	dialer := net.Dialer{Deadline: deadline}
+
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return err
+	}
+
+	conn.SetDeadline(deadline)
+	id := c.nextID()
+
+	if err := writePacket(conn, packet{id: id, kind: typeAuth,
+		body: c.password}); err != nil {
+		conn.Close()
+		return err
+	}
+
+	// Servers send an empty SERVERDATA_RESPONSE_VALUE ahead of the actual
+	// SERVERDATA_AUTH_RESPONSE, so skip anything that isn't the latter
+	for {
+		p, err := readPacket(conn)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+
+		if p.kind != typeAuthResponse {
+			continue
+		}
+
+		if p.id == -1 {
+			conn.Close()
+			return ErrAuthFailed
+		}
+
+		if p.id == id {
+			break
+		}
+	}
+
+	conn.SetDeadline(time.Time{})
+	c.conn = conn
+	logger.LogDebug(c, "Connected and authenticated")
+	return nil
+}
+
+// exec sends a command followed by an empty SERVERDATA_RESPONSE_VALUE packet.
+// Servers respond to packets in order, so once the empty packet is mirrored
+// back we know that the full response to the command has been received. The
+// returned boolean reports whether any packet was read before an error.
+func (c *Client) exec(ctx context.Context, cmd string) (string, bool, error) {
+	var (
+		out      strings.Builder
+		conn     = c.conn
+		received = false
+		done     = make(chan struct{})
+	)
+
+	defer close(done)
+	conn.SetDeadline(c.deadline(ctx))
+
+	// Unblock any pending reads or writes when the caller gives up
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+
+	id, end := c.nextID(), c.nextID()
+
+	if err := writePacket(conn, packet{id: id, kind: typeExecCommand,
+		body: cmd}); err != nil {
+		return "", received, err
+	}
+
+	if err := writePacket(conn, packet{id: end,
+		kind: typeResponseValue}); err != nil {
+		return "", received, err
+	}
+
+	for {
+		p, err := readPacket(conn)
+		if err != nil {
+			return "", received, err
+		}
+
+		received = true
+
+		switch p.id {
+		case id:
+			out.WriteString(p.body)
+
+		case end:
+			conn.SetDeadline(time.Time{})
+			return out.String(), received, nil
+
+		// Left over from a previous call that was interrupted
+		default:
+			logger.LogDebug(c, sprintf("Discarding stale packet (%d)", p.id))
+		}
+	}
+}
+
+// contextError prefers the context error over err when the context is done, as
+// the former is the actual reason that the call failed. Sockets share the
+// deadline of the context, so their timeout can fire before the context is
+// marked as done.
+func contextError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if d, ok := ctx.Deadline(); ok && errors.Is(err, os.ErrDeadlineExceeded) &&
+		!time.Now().Before(d) {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// deadline returns the deadline for a call, which is the earlier of the
+// context deadline or the configured timeout
+func (c *Client) deadline(ctx context.Context) time.Time {
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	return deadline
+}
+
+// nextID returns the next request id. Ids are always positive, as -1 is used
+// by the server to denote failed authentication.
+func (c *Client) nextID() int32 {
+	c.id++
+	if c.id <= 0 {
+		c.id = 1
+	}
+	return c.id
+}
+
+/************************/
+/* IFace logger.ILogger */
+/************************/
+
+// UUID returns the UUID of an rcon.Client
+func (c *Client) UUID() string {
+	return "RCON:" + c.addr
+}
+
+// Loglevel returns the loglevel of an rcon.Client
+func (c *Client) Loglevel() int {
+	return c.loglevel
+}
+
+// SetLoglevel sets the loglevel of an rcon.Client
+func (c *Client) SetLoglevel(l int) {
+	c.loglevel = l
+}
@@ -0,0 +1,303 @@
+package rcon
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testPassword = "hunter2"
+
+// rawPacket reads a packet off the wire without readPacket, checking the
+// framing that the client is expected to produce
+func rawPacket(r io.Reader) (packet, error) {
+	var size int32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return packet{}, err
+	}
+
+	if size < 10 || size > 4096 {
+		return packet{}, fmt.Errorf("packet size %d is out of range", size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return packet{}, err
+	}
+
+	if !bytes.Equal(data[size-2:], []byte{0, 0}) {
+		return packet{}, fmt.Errorf("packet is not terminated by two null "+
+			"bytes: %q", data[size-2:])
+	}
+
+	return packet{
+		id:   int32(binary.LittleEndian.Uint32(data[0:4])),
+		kind: int32(binary.LittleEndian.Uint32(data[4:8])),
+		body: string(data[8 : size-2])}, nil
+}
+
+// fakeServer is a Source RCON server on a local listener. Commands are
+// answered with handler, split into chunks of at most chunk bytes.
+type fakeServer struct {
+	t        *testing.T
+	ln       net.Listener
+	password string
+	chunk    int
+	handler  func(string) string
+	conns    chan net.Conn
+}
+
+func newFakeServer(t *testing.T, handler func(string) string) *fakeServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fakeServer{
+		t:        t,
+		ln:       ln,
+		password: testPassword,
+		chunk:    4096,
+		handler:  handler,
+		conns:    make(chan net.Conn, 8)}
+
+	go f.accept()
+	t.Cleanup(func() { ln.Close() })
+	return f
+}
+
+func (f *fakeServer) addr() string {
+	return f.ln.Addr().String()
+}
+
+func (f *fakeServer) accept() {
+	for {
+		conn, err := f.ln.Accept()
+		if err != nil {
+			return
+		}
+		f.conns <- conn
+		go f.serve(conn)
+	}
+}
+
+func (f *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	auth, err := rawPacket(conn)
+	if err != nil {
+		f.t.Errorf("auth packet: %v", err)
+		return
+	}
+
+	if auth.kind != typeAuth {
+		f.t.Errorf("first packet has type %d, expected auth (%d)", auth.kind,
+			typeAuth)
+		return
+	}
+
+	// Real servers send an empty response value before the auth response
+	writePacket(conn, packet{id: auth.id, kind: typeResponseValue})
+	if auth.body != f.password {
+		writePacket(conn, packet{id: -1, kind: typeAuthResponse})
+		return
+	}
+	writePacket(conn, packet{id: auth.id, kind: typeAuthResponse})
+
+	for {
+		p, err := rawPacket(conn)
+		if err == io.EOF || isClosed(err) {
+			return
+		}
+
+		if err != nil {
+			f.t.Errorf("command packet: %v", err)
+			return
+		}
+
+		// The terminating empty packet is mirrored back as is
+		if p.kind == typeResponseValue {
+			writePacket(conn, packet{id: p.id, kind: typeResponseValue})
+			continue
+		}
+
+		out := f.handler(p.body)
+		for len(out) > f.chunk {
+			writePacket(conn, packet{id: p.id, kind: typeResponseValue,
+				body: out[:f.chunk]})
+			out = out[f.chunk:]
+		}
+		writePacket(conn, packet{id: p.id, kind: typeResponseValue, body: out})
+	}
+}
+
+// isClosed returns whether err comes from a connection that was closed or reset
+func isClosed(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+func TestPacketFraming(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writePacket(&buf, packet{id: 7, kind: typeExecCommand,
+		body: "status"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 4 byte size, 4 byte id, 4 byte type, body, two null terminators
+	if buf.Len() != 4+4+4+len("status")+2 {
+		t.Fatalf("packet is %d bytes long", buf.Len())
+	}
+
+	size := int32(binary.LittleEndian.Uint32(buf.Bytes()[0:4]))
+	if int(size) != buf.Len()-4 {
+		t.Fatalf("size field is %d, expected %d", size, buf.Len()-4)
+	}
+
+	p, err := readPacket(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.id != 7 || p.kind != typeExecCommand || p.body != "status" {
+		t.Fatalf("read back %+v", p)
+	}
+}
+
+func TestReadPacketRejectsBadSizes(t *testing.T) {
+	for _, size := range []int32{0, 9, -1, maxPacketSize + 1} {
+		var buf bytes.Buffer
+		binary.Write(&buf, binary.LittleEndian, size)
+		buf.Write(make([]byte, 16))
+
+		if _, err := readPacket(&buf); err != errPacketSize {
+			t.Errorf("size %d: got %v, expected errPacketSize", size, err)
+		}
+	}
+}
+
+func TestExec(t *testing.T) {
+	f := newFakeServer(t, func(cmd string) string {
+		return "ran " + cmd
+	})
+
+	c := New(f.addr(), testPassword, time.Second)
+	defer c.Close()
+
+	for _, cmd := range []string{"status", "say hello"} {
+		out, err := c.Exec(context.Background(), cmd)
+		if err != nil {
+			t.Fatalf("%s: %v", cmd, err)
+		}
+
+		if out != "ran "+cmd {
+			t.Fatalf("%s: got %q", cmd, out)
+		}
+	}
+
+	if n := len(f.conns); n != 1 {
+		t.Fatalf("client made %d connections, expected 1", n)
+	}
+}
+
+func TestExecMultiPacket(t *testing.T) {
+	long := strings.Repeat("0123456789", 1000)
+	f := newFakeServer(t, func(cmd string) string { return long })
+	f.chunk = 4000
+
+	c := New(f.addr(), testPassword, time.Second)
+	defer c.Close()
+
+	out, err := c.Exec(context.Background(), "playerinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out != long {
+		t.Fatalf("got %d bytes, expected %d", len(out), len(long))
+	}
+}
+
+func TestAuthFailed(t *testing.T) {
+	f := newFakeServer(t, func(cmd string) string { return "" })
+
+	c := New(f.addr(), "wrong", time.Second)
+	defer c.Close()
+
+	if _, err := c.Exec(context.Background(), "status"); err != ErrAuthFailed {
+		t.Fatalf("got %v, expected ErrAuthFailed", err)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	f := newFakeServer(t, func(cmd string) string { return "ok" })
+
+	c := New(f.addr(), testPassword, time.Second)
+	defer c.Close()
+
+	if _, err := c.Exec(context.Background(), "status"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Drop the connection from the server side
+	(<-f.conns).Close()
+
+	out, err := c.Exec(context.Background(), "status")
+	if err != nil {
+		t.Fatalf("did not reconnect: %v", err)
+	}
+
+	if out != "ok" {
+		t.Fatalf("got %q", out)
+	}
+}
+
+func TestCommandTooLong(t *testing.T) {
+	c := New("127.0.0.1:1", testPassword, time.Second)
+	if _, err := c.Exec(context.Background(),
+		strings.Repeat("x", maxCommandSize+1)); err != ErrCommandTooLong {
+		t.Fatalf("got %v, expected ErrCommandTooLong", err)
+	}
+}
+
+func TestExecDeadline(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	// Accept connections but never answer them
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	c := New(ln.Addr().String(), testPassword, time.Minute)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := c.Exec(ctx, "status"); err != context.DeadlineExceeded {
+		t.Fatalf("got %v, expected context.DeadlineExceeded", err)
+	}
+
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("the call did not respect the deadline of its context")
+	}
+}
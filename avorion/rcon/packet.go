@@ -0,0 +1,80 @@
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Packet types as defined by the Source RCON protocol. Note that the
+// SERVERDATA_EXECCOMMAND and SERVERDATA_AUTH_RESPONSE share a value, and are
+// only distinguishable by the direction that they are sent in. See:
+// https://developer.valvesoftware.com/wiki/Source_RCON_Protocol
+const (
+	typeResponseValue = int32(0)
+	typeExecCommand   = int32(2)
+	typeAuthResponse  = int32(2)
+	typeAuth          = int32(3)
+
+	// The size field of a packet counts the id and type fields (4 bytes each),
+	// the body, and the two null terminators that follow the body. It doesn't
+	// count itself.
+	packetHeaderSize  = 8
+	packetPaddingSize = 2
+
+	// Sanity limit for incoming packets. Servers should never send more than
+	// 4096 bytes of body in a single packet, but we leave a bit of room for
+	// implementations that aren't as strict.
+	maxPacketSize = 1 << 16
+)
+
+var errPacketSize = errors.New("rcon: invalid packet size")
+
+// packet describes a single Source RCON packet
+type packet struct {
+	id   int32
+	kind int32
+	body string
+}
+
+// writePacket serializes a packet into its wire format and writes it to w
+func writePacket(w io.Writer, p packet) error {
+	size := int32(len(p.body) + packetHeaderSize + packetPaddingSize)
+	buf := bytes.NewBuffer(make([]byte, 0, size+4))
+
+	binary.Write(buf, binary.LittleEndian, size)
+	binary.Write(buf, binary.LittleEndian, p.id)
+	binary.Write(buf, binary.LittleEndian, p.kind)
+	buf.WriteString(p.body)
+	buf.Write([]byte{0x00, 0x00})
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// readPacket reads a single packet from r
+func readPacket(r io.Reader) (packet, error) {
+	var (
+		p    packet
+		size int32
+	)
+
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return p, err
+	}
+
+	if size < packetHeaderSize+packetPaddingSize || size > maxPacketSize {
+		return p, errPacketSize
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return p, err
+	}
+
+	p.id = int32(binary.LittleEndian.Uint32(data[0:4]))
+	p.kind = int32(binary.LittleEndian.Uint32(data[4:8]))
+	p.body = string(bytes.TrimRight(data[packetHeaderSize:], "\x00"))
+	return p, nil
+}
@@ -0,0 +1,227 @@
+package rcon
+
+import (
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrStale is returned for commands that sat in the queue for longer than
+	// the maximum age configured for their priority
+	ErrStale = errors.New("rcon: command expired before it could be run")
+
+	// ErrQueueClosed is returned for commands that are queued after the queue
+	// has been closed, or that were still pending when it was
+	ErrQueueClosed = errors.New("rcon: command queue is closed")
+
+	// ErrBadPriority is returned when a command is queued with an unknown
+	// priority
+	ErrBadPriority = errors.New("rcon: invalid command priority")
+)
+
+// request is a single queued command
+type request struct {
+	ctx    context.Context
+	cmd    string
+	queued time.Time
+	result chan result
+}
+
+type result struct {
+	out string
+	err error
+}
+
+// Queue runs commands against a Commander one at a time. Pending commands are
+// run in order of priority (see ifaces.CommandPriority*), and in the order
+// that they were queued within the same priority. Commands whose context is
+// done, or that have been waiting for longer than the maximum age set for
+// their priority, are dropped without being run.
+type Queue struct {
+	client  Commander
+	pending [ifaces.CommandPriorityCount][]*request
+	maxage  [ifaces.CommandPriorityCount]time.Duration
+	stats   ifaces.CommandQueueStats
+	closed  bool
+
+	mutex  *sync.Mutex
+	notify chan struct{}
+	close  chan struct{}
+
+	loglevel int
+}
+
+// NewQueue returns a new Queue that runs commands using c, and starts the
+// goroutine that processes it
+func NewQueue(c Commander) *Queue {
+	q := &Queue{
+		client: c,
+		mutex:  new(sync.Mutex),
+		notify: make(chan struct{}, 1),
+		close:  make(chan struct{})}
+
+	go q.process()
+	return q
+}
+
+// SetMaxAge sets the maximum amount of time that a command of the given
+// priority may wait in the queue. A zero duration disables the limit.
+func (q *Queue) SetMaxAge(priority int, d time.Duration) {
+	if priority < 0 || priority >= ifaces.CommandPriorityCount {
+		return
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.maxage[priority] = d
+}
+
+// Exec queues a command with the given priority and waits for its result. If
+// ctx is done before the command has run, it is removed from the queue and the
+// context error is returned.
+func (q *Queue) Exec(ctx context.Context, priority int, cmd string) (string,
+	error) {
+	if priority < 0 || priority >= ifaces.CommandPriorityCount {
+		return "", ErrBadPriority
+	}
+
+	r := &request{
+		ctx:    ctx,
+		cmd:    cmd,
+		queued: time.Now(),
+		result: make(chan result, 1)}
+
+	q.mutex.Lock()
+	if q.closed {
+		q.mutex.Unlock()
+		return "", ErrQueueClosed
+	}
+	q.pending[priority] = append(q.pending[priority], r)
+	q.mutex.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+
+	select {
+	case res := <-r.result:
+		return res.out, res.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Stats returns the current queue depth for each priority, along with the
+// running totals of commands that have been processed or dropped
+func (q *Queue) Stats() ifaces.CommandQueueStats {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	stats := q.stats
+	for i, p := range q.pending {
+		stats.Depth[i] = len(p)
+	}
+	return stats
+}
+
+// Close stops the queue. Any commands that are still pending will fail with
+// ErrQueueClosed.
+func (q *Queue) Close() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return
+	}
+
+	q.closed = true
+	close(q.close)
+
+	for i, p := range q.pending {
+		for _, r := range p {
+			r.result <- result{err: ErrQueueClosed}
+		}
+		q.pending[i] = nil
+	}
+}
+
+// next pops the highest priority command that is still worth running. Anything
+// that is skipped along the way is answered immediately.
+func (q *Queue) next() *request {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i := range q.pending {
+		for len(q.pending[i]) > 0 {
+			r := q.pending[i][0]
+			q.pending[i][0] = nil
+			q.pending[i] = q.pending[i][1:]
+
+			if err := r.ctx.Err(); err != nil {
+				q.stats.Cancelled++
+				r.result <- result{err: err}
+				continue
+			}
+
+			if q.maxage[i] > 0 && time.Since(r.queued) > q.maxage[i] {
+				q.stats.Stale++
+				logger.LogDebug(q, sprintf("Dropped stale command: %s", r.cmd))
+				r.result <- result{err: ErrStale}
+				continue
+			}
+
+			return r
+		}
+	}
+
+	return nil
+}
+
+// process is the goroutine responsible for running queued commands
+func (q *Queue) process() {
+	for {
+		select {
+		case <-q.close:
+			return
+		case <-q.notify:
+		}
+
+		for r := q.next(); r != nil; r = q.next() {
+			out, err := q.client.Exec(r.ctx, r.cmd)
+
+			q.mutex.Lock()
+			if err != nil {
+				q.stats.Failed++
+			} else {
+				q.stats.Processed++
+			}
+			q.mutex.Unlock()
+
+			r.result <- result{out: out, err: err}
+		}
+	}
+}
+
+/************************/
+/* IFace logger.ILogger */
+/************************/
+
+// UUID returns the UUID of an rcon.Queue
+func (q *Queue) UUID() string {
+	return "RCONQueue"
+}
+
+// Loglevel returns the loglevel of an rcon.Queue
+func (q *Queue) Loglevel() int {
+	return q.loglevel
+}
+
+// SetLoglevel sets the loglevel of an rcon.Queue
+func (q *Queue) SetLoglevel(l int) {
+	q.loglevel = l
+}
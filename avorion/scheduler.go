@@ -0,0 +1,391 @@
+package avorion
+
+import (
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	errTaskNotFound = "No scheduled task with that name: %s"
+	errTaskNotDue   = "Scheduled task %s has no upcoming run"
+)
+
+// scheduleVerbs describes each scheduled action in the notifications that are
+// sent to players
+var scheduleVerbs = map[string]string{
+	ifaces.ScheduleActionSave:    "save",
+	ifaces.ScheduleActionStop:    "shutdown",
+	ifaces.ScheduleActionRestart: "restart",
+	ifaces.ScheduleActionBackup:  "backup"}
+
+// scheduledTask is a configured ScheduledTask along with the state of its next
+// run
+type scheduledTask struct {
+	*ifaces.ScheduledTask
+	cron *cronSchedule
+
+	next      time.Time
+	postponed time.Duration
+	warned    int
+	skip      bool
+}
+
+// Scheduler runs the ScheduledTasks for a Server
+type Scheduler struct {
+	server *Server
+	tasks  []*scheduledTask
+	mutex  *sync.Mutex
+	wake   chan struct{}
+
+	loglevel int
+	uuid     string
+}
+
+// newScheduler returns a Scheduler for the given Server
+func newScheduler(s *Server) *Scheduler {
+	return &Scheduler{
+		server:   s,
+		mutex:    new(sync.Mutex),
+		wake:     make(chan struct{}, 1),
+		tasks:    make([]*scheduledTask, 0),
+		loglevel: s.config.Loglevel(),
+		uuid:     s.uuid + ":Scheduler"}
+}
+
+// Load replaces the current tasks with the configured ones. Tasks that have not
+// changed keep any pending skip or postponement.
+func (sc *Scheduler) Load(in []*ifaces.ScheduledTask) {
+	loc, err := time.LoadLocation(sc.server.config.TimeZone())
+	if err != nil {
+		logger.LogError(sc, "Failed to load timezone from config, using local time")
+		loc = time.Local
+	}
+
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+
+	now := time.Now()
+	tasks := make([]*scheduledTask, 0, len(in))
+	for _, t := range in {
+		cron, err := parseCron(t.Spec, loc)
+		if err != nil {
+			logger.LogError(sc, sprintf("Scheduled task %s: %s", t.Name, err.Error()))
+			continue
+		}
+
+		// The configured task is shared by the schedulers of every galaxy, so
+		// each scheduler works on its own copy
+		tc := *t
+		task := &scheduledTask{ScheduledTask: &tc, cron: cron}
+		for _, old := range sc.tasks {
+			if old.Name == t.Name && old.Spec == t.Spec && old.Action == t.Action &&
+				old.cron.loc.String() == loc.String() {
+				task.next = old.next
+				task.postponed = old.postponed
+				task.skip = old.skip
+			}
+		}
+
+		// Warnings are sent from the furthest out to the closest
+		task.Warnings = append([]time.Duration{}, tc.Warnings...)
+		sort.Slice(task.Warnings, func(i, j int) bool {
+			return task.Warnings[i] > task.Warnings[j]
+		})
+
+		if task.next.IsZero() {
+			task.next = cron.Next(now)
+		}
+
+		task.skipWarnings(now)
+		tasks = append(tasks, task)
+		logger.LogDebug(sc, sprintf("Scheduled %s (%s) for %s", t.Name, t.Action,
+			task.next.String()))
+	}
+
+	sc.tasks = tasks
+	sc.poke()
+}
+
+// Skip prevents the next run of a task
+func (sc *Scheduler) Skip(name string) error {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+
+	task := sc.task(name)
+	if task == nil {
+		return errors.New(sprintf(errTaskNotFound, name))
+	}
+
+	if task.next.IsZero() {
+		return errors.New(sprintf(errTaskNotDue, task.Name))
+	}
+
+	task.skip = true
+	logger.LogInfo(sc, sprintf("Skipping the next run of %s (%s)", task.Name,
+		task.next.String()))
+
+	if task.warned > 0 {
+		if verb, ok := scheduleVerbs[task.Action]; ok {
+			go sc.server.NotifyServer(sprintf("The scheduled server %s has been "+
+				"cancelled", verb))
+		}
+	}
+
+	return nil
+}
+
+// Postpone delays the next run of a task
+func (sc *Scheduler) Postpone(name string, d time.Duration) error {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+
+	task := sc.task(name)
+	if task == nil {
+		return errors.New(sprintf(errTaskNotFound, name))
+	}
+
+	if task.next.IsZero() {
+		return errors.New(sprintf(errTaskNotDue, task.Name))
+	}
+
+	warned := task.warned
+	task.next = task.next.Add(d)
+	task.postponed += d
+	task.skipWarnings(time.Now())
+	logger.LogInfo(sc, sprintf("Postponed %s by %s until %s", task.Name,
+		d.String(), task.next.String()))
+
+	if warned > 0 {
+		if verb, ok := scheduleVerbs[task.Action]; ok {
+			go sc.server.NotifyServer(sprintf("The scheduled server %s has been "+
+				"postponed by %s", verb, countdown(d)))
+		}
+	}
+
+	sc.poke()
+	return nil
+}
+
+// List returns the status of the current tasks, ordered by their next run
+func (sc *Scheduler) List() []ifaces.ScheduledTaskStatus {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+
+	list := make([]ifaces.ScheduledTaskStatus, 0, len(sc.tasks))
+	for _, t := range sc.tasks {
+		list = append(list, ifaces.ScheduledTaskStatus{
+			Name:      t.Name,
+			Action:    t.Action,
+			Spec:      t.Spec,
+			Next:      t.next,
+			Skipped:   t.skip,
+			Postponed: t.postponed})
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Next.Before(list[j].Next)
+	})
+
+	return list
+}
+
+// supervise runs tasks and sends their warnings as they come due
+func (sc *Scheduler) supervise(exit chan struct{}) {
+	logger.LogInit(sc, "Starting scheduler")
+
+	for {
+		wait := sc.tick(time.Now())
+
+		select {
+		case <-exit:
+			logger.LogDebug(sc, "Stopping scheduler")
+			return
+		case <-sc.wake:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// tick handles any tasks or warnings that are due, and returns how long to wait
+// until the next one
+func (sc *Scheduler) tick(now time.Time) time.Duration {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+
+	// Wake up at least once an hour so that clock changes are picked up
+	wait := time.Hour
+	for _, t := range sc.tasks {
+		if t.next.IsZero() {
+			continue
+		}
+
+		if !now.Before(t.next) {
+			if t.skip {
+				logger.LogInfo(sc, "Skipped scheduled task: "+t.Name)
+			} else {
+				go sc.run(t.ScheduledTask)
+			}
+
+			t.next = t.cron.Next(now)
+			t.postponed = 0
+			t.skip = false
+			t.skipWarnings(now)
+		}
+
+		if t.warned < len(t.Warnings) {
+			if at := t.next.Add(-t.Warnings[t.warned]); !now.Before(at) {
+				if verb, ok := scheduleVerbs[t.Action]; ok && !t.skip {
+					go sc.server.NotifyServer(sprintf("Scheduled server %s in %s",
+						verb, countdown(t.next.Sub(now))))
+				}
+				t.skipWarnings(now)
+			}
+		}
+
+		if t.next.IsZero() {
+			continue
+		}
+
+		next := t.next
+		if t.warned < len(t.Warnings) {
+			next = t.next.Add(-t.Warnings[t.warned])
+		}
+
+		if d := next.Sub(now); d < wait {
+			wait = d
+		}
+	}
+
+	if wait < time.Second {
+		wait = time.Second
+	}
+
+	return wait
+}
+
+// run performs the action of a task
+func (sc *Scheduler) run(t *ifaces.ScheduledTask) {
+	var (
+		err error
+		s   = sc.server
+	)
+
+	logger.LogInfo(sc, sprintf("Running scheduled task %s (%s)", t.Name, t.Action))
+
+	if t.Action != ifaces.ScheduleActionStart &&
+		t.Action != ifaces.ScheduleActionBackup && !s.IsUp() {
+		logger.LogInfo(sc, sprintf("Server is offline, not running %s", t.Name))
+		return
+	}
+
+	switch t.Action {
+	case ifaces.ScheduleActionSave:
+		_, err = s.RunCommandContext(context.Background(),
+			ifaces.CommandPriorityHealth, "save")
+		if err == nil && s.config.BackupAfterSave() {
+			_, err = s.CreateBackup()
+		}
+	case ifaces.ScheduleActionBackup:
+		_, err = s.CreateBackup()
+	case ifaces.ScheduleActionStop:
+		err = s.Stop(true)
+	case ifaces.ScheduleActionStart:
+		if !s.IsUp() {
+			err = s.Start(true)
+		}
+	case ifaces.ScheduleActionRestart:
+		if t.Empty {
+			err = s.SoftRestart(s.config.SoftRestartDeadline())
+		} else {
+			err = s.Restart()
+		}
+	}
+
+	if err != nil {
+		logger.LogError(sc, sprintf("Scheduled task %s failed: %s", t.Name,
+			err.Error()))
+		s.SendLog(ifaces.ChatData{Msg: sprintf("**Scheduled task `%s` failed:** %s",
+			t.Name, err.Error())})
+		return
+	}
+
+	s.SendLog(ifaces.ChatData{Msg: sprintf("Ran scheduled task `%s` (%s)",
+		t.Name, t.Action)})
+}
+
+// task returns the task with the given name. The mutex must be held.
+func (sc *Scheduler) task(name string) *scheduledTask {
+	for _, t := range sc.tasks {
+		if strings.EqualFold(t.Name, name) {
+			return t
+		}
+	}
+	return nil
+}
+
+// poke wakes the scheduler so that it recalculates its next wakeup
+func (sc *Scheduler) poke() {
+	select {
+	case sc.wake <- struct{}{}:
+	default:
+	}
+}
+
+// skipWarnings marks every warning that is already past due as sent, so that
+// only the most recent one is sent when several come due at once
+func (t *scheduledTask) skipWarnings(now time.Time) {
+	t.warned = 0
+	for t.warned < len(t.Warnings) &&
+		!now.Before(t.next.Add(-t.Warnings[t.warned])) {
+		t.warned++
+	}
+}
+
+// countdown formats a duration for player notifications
+func countdown(d time.Duration) string {
+	d = d.Round(time.Second)
+
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return plural(int(d/time.Hour), "hour")
+	case d >= time.Minute && d%time.Minute == 0:
+		return plural(int(d/time.Minute), "minute")
+	case d >= time.Minute:
+		return plural(int(d/time.Minute), "minute") + " " +
+			plural(int(d%time.Minute/time.Second), "second")
+	}
+
+	return plural(int(d/time.Second), "second")
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return sprintf("%d %s", n, unit)
+	}
+	return sprintf("%d %ss", n, unit)
+}
+
+/************************/
+/* IFace logger.ILogger */
+/************************/
+
+// UUID returns the UUID of the Scheduler
+func (sc *Scheduler) UUID() string {
+	return sc.uuid
+}
+
+// Loglevel returns the loglevel of the Scheduler
+func (sc *Scheduler) Loglevel() int {
+	return sc.loglevel
+}
+
+// SetLoglevel sets the loglevel of the Scheduler
+func (sc *Scheduler) SetLoglevel(l int) {
+	sc.loglevel = l
+}
@@ -0,0 +1,269 @@
+package avorion
+
+import (
+	gamedb "avorioncontrol/avorion/database"
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"avorioncontrol/randstring"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	seasonSeedLength = 10
+	seasonWarning    = 30 * time.Second
+
+	errSeasonBadName  = "Invalid galaxy name: %s (use letters, numbers, - and _)"
+	errSeasonSameName = "%s is already the current galaxy"
+	errSeasonExists   = "A galaxy named %s already exists"
+	errSeasonBadSeed  = "Invalid seed: %s (use letters and numbers)"
+)
+
+var (
+	seasonNameRe = regexp.MustCompile(`^[\w-]+$`)
+	seasonSeedRe = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+)
+
+// copyFile copies a file, replacing the destination
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		os.Remove(dst)
+	}
+
+	return err
+}
+
+// announceSeason tells the players in game and on Discord about the progress of
+// a new galaxy
+func (s *Server) announceSeason(msg string) {
+	logger.LogInfo(s, "New galaxy: "+msg)
+	s.SendLog(ifaces.ChatData{Msg: sprintf("**%s:** %s", s.config.Galaxy(), msg)})
+	s.SendChat(ifaces.ChatData{Name: "Server", Msg: msg})
+}
+
+// trackingDBPath returns the path of the tracking DB of the current galaxy
+func (s *Server) trackingDBPath() string {
+	return strings.TrimSuffix(s.config.DataPath(), "/") + "/" + s.config.DBName()
+}
+
+// trackingDB returns the tracking DB of the server, or nil if it hasn't been
+// opened. While a new galaxy is being created this waits for its DB.
+func (s *Server) trackingDB() *gamedb.TrackingDB {
+	s.trackmutex.RLock()
+	defer s.trackmutex.RUnlock()
+	return s.tracking
+}
+
+// setTrackingDB replaces the tracking DB of the server
+func (s *Server) setTrackingDB(db *gamedb.TrackingDB) {
+	s.trackmutex.Lock()
+	defer s.trackmutex.Unlock()
+	s.tracking = db
+}
+
+// archiveTrackingDB copies the tracking DB at src, which belonged to the
+// previous galaxy, next to its backups. It is then handed over to the current
+// galaxy with everything that was tracked in it cleared. The DB is locked
+// while that happens, and left closed if it fails.
+func (s *Server) archiveTrackingDB(previous, src, stamp string) (string, error) {
+	var (
+		next = s.trackingDBPath()
+		dst  = s.config.BackupPath() + "/" + previous + "-" + stamp + ".db"
+	)
+
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return "", nil
+	}
+
+	if err := os.MkdirAll(s.config.BackupPath(), 0755); err != nil {
+		return "", err
+	}
+
+	s.trackmutex.Lock()
+	defer s.trackmutex.Unlock()
+
+	s.tracking = nil
+	if err := copyFile(src, dst); err != nil {
+		return "", err
+	}
+
+	if next != src {
+		if err := os.Rename(src, next); err != nil {
+			return dst, err
+		}
+	}
+
+	db, err := gamedb.New(next)
+	if err != nil {
+		return dst, err
+	}
+
+	if _, err := db.Init(); err != nil {
+		return dst, err
+	}
+
+	if err := db.Reset(); err != nil {
+		return dst, err
+	}
+
+	db.SetLoglevel(s.loglevel)
+	s.tracking = db
+	return dst, nil
+}
+
+// rollbackSeason switches back to the previous galaxy after a new one failed to
+// be created, and starts the server again when it was running
+func (s *Server) rollbackSeason(previous, name string, wasup bool, err error) error {
+	s.config.SetGalaxy(previous)
+
+	// The galaxy didn't exist before we started, so nothing is lost
+	os.RemoveAll(strings.TrimSuffix(s.config.DataPath(), "/") + "/" + name)
+
+	if wasup {
+		if serr := s.Start(true); serr != nil {
+			logger.LogError(s, "Failed to restart after a failed new galaxy: "+
+				serr.Error())
+		} else {
+			s.announceSeason(sprintf("Creating %s failed, %s is back online", name,
+				previous))
+		}
+	}
+
+	return err
+}
+
+/******************************/
+/* IFace ifaces.ISeasonServer */
+/******************************/
+
+// NewGalaxy replaces the galaxy with a freshly seeded one. The current galaxy
+// is backed up, the configuration is switched over to the new galaxy, and the
+// tracking DB is archived. A random seed is used when none is given. If the
+// server was running, it is started again on the new galaxy, or on the old one
+// when the new galaxy could not be created.
+func (s *Server) NewGalaxy(name, seed string) (string, error) {
+	logger.LogDebug(s, "NewGalaxy() was called")
+	s.seasonmutex.Lock()
+	defer s.seasonmutex.Unlock()
+
+	var (
+		previous = s.config.Galaxy()
+		datapath = strings.TrimSuffix(s.config.DataPath(), "/")
+		olddb    = s.trackingDBPath()
+		stamp    = time.Now().UTC().Format(backupTimeFormat)
+	)
+
+	if !seasonNameRe.MatchString(name) {
+		return "", errors.New(sprintf(errSeasonBadName, name))
+	}
+
+	if strings.EqualFold(name, previous) {
+		return "", errors.New(sprintf(errSeasonSameName, name))
+	}
+
+	if _, err := os.Stat(datapath + "/" + name); err == nil {
+		return "", errors.New(sprintf(errSeasonExists, name))
+	}
+
+	if seed == "" {
+		seed = randstring.New(seasonSeedLength)
+	} else if !seasonSeedRe.MatchString(seed) {
+		return "", errors.New(sprintf(errSeasonBadSeed, seed))
+	}
+
+	wasup := s.IsUp()
+	if wasup {
+		msg := sprintf("The galaxy is being reset for a new season. The server "+
+			"will go down in %s", seasonWarning)
+		if s.onlineplayercount > 0 {
+			if err := s.NotifyServer(msg); err != nil {
+				logger.LogWarning(s, "Failed to notify players: "+err.Error())
+			}
+			s.announceSeason(msg)
+			time.Sleep(seasonWarning)
+		} else {
+			s.announceSeason("The galaxy is being reset for a new season")
+		}
+
+		if err := s.Stop(true); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := os.Stat(s.galaxyPath()); err == nil {
+		info, err := s.CreateBackup()
+		if err != nil {
+			return "", s.rollbackSeason(previous, name, wasup,
+				errors.New("Failed to back up the galaxy: "+err.Error()))
+		}
+		s.announceSeason(sprintf("Archived %s as `%s`", previous, info.Name))
+	}
+
+	s.config.SetGalaxy(name)
+	if err := s.config.SeedGameConfig(previous, seed); err != nil {
+		return "", s.rollbackSeason(previous, name, wasup,
+			errors.New("Failed to write server.ini: "+err.Error()))
+	}
+
+	if err := s.config.BuildModConfig(); err != nil {
+		return "", s.rollbackSeason(previous, name, wasup,
+			errors.New("Failed to generate modconfig.lua file"))
+	}
+
+	// The tracking DB is cleared last, as that can't be rolled back. The admins,
+	// access list and Discord integrations carry over to the new galaxy.
+	db, err := s.archiveTrackingDB(previous, olddb, stamp)
+	if err != nil {
+		if db == "" {
+			return "", s.rollbackSeason(previous, name, wasup,
+				errors.New("Failed to archive the tracking DB: "+err.Error()))
+		}
+		logger.LogError(s, "Failed to hand the tracking DB over: "+err.Error())
+	}
+
+	if db != "" {
+		s.announceSeason(sprintf("Archived the tracking DB of %s", previous))
+	}
+
+	// Nothing that was tracked in the old galaxy carries over
+	s.SetSeed(seed)
+	s.name = name
+	s.players = make([]*Player, 0)
+	s.alliances = make([]*Alliance, 0)
+	if err := s.config.SaveConfiguration(); err != nil {
+		logger.LogError(s, "Failed to save configuration: "+err.Error())
+	}
+
+	s.announceSeason(sprintf("Created the galaxy %s with the seed `%s`", name,
+		seed))
+
+	if wasup {
+		if err := s.Start(true); err != nil {
+			return seed, err
+		}
+		s.announceSeason(sprintf("%s is now online, welcome to the new season!",
+			name))
+	}
+
+	return seed, nil
+}
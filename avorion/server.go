@@ -3,6 +3,7 @@ package avorion
 import (
 	gamedb "avorioncontrol/avorion/database"
 	"avorioncontrol/avorion/events"
+	"avorioncontrol/avorion/rcon"
 	"avorioncontrol/discord"
 	"avorioncontrol/ifaces"
 	"avorioncontrol/logger"
@@ -11,6 +12,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"os"
 	"os/exec"
 	"regexp"
@@ -43,38 +45,22 @@ const (
 	rconGetPlayerData   = `getplayerdata -p %s`
 	rconGetAllianceData = `getplayerdata -a %s`
 	rconGetAllData      = `getplayerdata`
+
+	rconTimeout = time.Minute
+
+	// Avorion defaults its query port to three above the game port, so we
+	// derive it the same way to keep multiple galaxies from colliding
+	queryPortOffset = 3
 )
 
 var (
 	sprintf          = fmt.Sprintf
 	regexpDiscordPin = regexp.MustCompile(regexIntegration)
 
-	state    RunState
-	cmdmutex *sync.Mutex
+	regexPlayerIndex   = regexp.MustCompile(`^player:([0-9]+)$`)
+	regexAllianceIndex = regexp.MustCompile(`^alliance:([0-9]+)$`)
 )
 
-func init() {
-	state = RunState{
-		mutex: new(sync.Mutex),
-		last:  time.Now()}
-	cmdmutex = new(sync.Mutex)
-}
-
-// RunState describes the current state of commands being run
-type RunState struct {
-	mutex *sync.Mutex
-	state bool
-
-	//TODO: Migrate all of these into a singular int at
-	isrestarting bool
-	isstopping   bool
-	isstarting   bool
-	iscrashed    bool
-
-	// Track the last time the server was started
-	last time.Time
-}
-
 // Server - Avorion server definition
 type Server struct {
 	ifaces.IGameServer
@@ -92,12 +78,29 @@ type Server struct {
 	stdout  io.Reader
 	output  chan []byte
 	chatout chan ifaces.ChatData
+	recent  *outputBuffer
 
 	// Logger
 	loglevel int
 	uuid     string
 
+	// Lifecycle
+	state       *RunState
+	scheduler   *Scheduler
+	backupmutex *sync.Mutex
+	seasonmutex *sync.Mutex
+	adminmutex  *sync.Mutex
+	modmutex    *sync.Mutex
+	crashes     *crashLoop
+	softrestart *pendingRestart
+	softmutex   *sync.Mutex
+	resources   *resourceHistory
+	memalerts   int
+	heartbeats  *heartbeatMonitor
+
 	//RCON support
+	rcon     rcon.Commander
+	commands *rcon.Queue
 	rconpass string
 	rconaddr string
 	rconport int
@@ -106,7 +109,11 @@ type Server struct {
 	players   []*Player
 	alliances []*Alliance
 	sectors   map[int]map[int]*ifaces.Sector
-	tracking  *gamedb.TrackingDB
+
+	// The tracking DB is swapped out when a new galaxy is created, so it is
+	// only read through trackingDB
+	tracking   *gamedb.TrackingDB
+	trackmutex *sync.RWMutex
 
 	// Cached values so we don't run loops constantly
 	onlineplayers     string
@@ -121,15 +128,24 @@ type Server struct {
 	config     ifaces.IConfigurator
 
 	// Game information
-	password string
-	version  string
-	seed     string
-	motd     string
-	time     string
+	version   string
+	seed      string
+	motd      string
+	motdindex int
+	motdmutex *sync.Mutex
+
+	// Workshop mods
+	modversions map[int64]string
+	time        string
+
+	// Events that were parsed from the output of Avorion
+	bus           *events.Bus
+	recorderdrops uint64
 
 	// Discord
-	bot      *discord.Bot
-	requests map[string]string
+	accessresolver func(string) bool
+	bot            *discord.Bot
+	requests       map[string]string
 
 	// Close goroutines
 	close chan struct{}
@@ -157,15 +173,10 @@ func New(c ifaces.IConfigurator, wg *sync.WaitGroup, exit chan struct{},
 		log.Fatal(sprintf(errExecFailed, path, cmnd))
 	}
 
-	_, err = exec.Command(c.RCONBin(), "-h").Output()
-	if err != nil {
-		log.Fatal(sprintf(`Failed to run %s`, c.RCONBin()))
-	}
-
 	s := &Server{
 		wg:         wg,
 		exit:       exit,
-		uuid:       logUUID,
+		uuid:       logUUID + ":" + c.Galaxy(),
 		config:     c,
 		serverpath: strings.TrimSuffix(path, "/"),
 		executable: cmnd,
@@ -174,7 +185,40 @@ func New(c ifaces.IConfigurator, wg *sync.WaitGroup, exit chan struct{},
 		rconpass: c.RCONPass(),
 		rconaddr: c.RCONAddr(),
 		rconport: c.RCONPort(),
-		requests: make(map[string]string)}
+		requests: make(map[string]string),
+
+		recent:      newOutputBuffer(c.CrashReportLines()),
+		crashes:     newCrashLoop(),
+		softmutex:   new(sync.Mutex),
+		resources:   newResourceHistory(c.ResourceHistorySize()),
+		heartbeats:  newHeartbeatMonitor(),
+		backupmutex: new(sync.Mutex),
+		seasonmutex: new(sync.Mutex),
+		adminmutex:  new(sync.Mutex),
+		modmutex:    new(sync.Mutex),
+		motdindex:   -1,
+		motdmutex:   new(sync.Mutex),
+		trackmutex:  new(sync.RWMutex),
+		state:       newRunState()}
+
+	s.loadStateHistory()
+
+	client := rcon.New(net.JoinHostPort(s.rconaddr, strconv.Itoa(s.rconport)),
+		s.rconpass, rconTimeout)
+	client.SetLoglevel(c.Loglevel())
+	s.rcon = client
+
+	s.commands = rcon.NewQueue(client)
+	s.commands.SetLoglevel(c.Loglevel())
+	s.commands.SetMaxAge(ifaces.CommandPriorityChat, c.ChatStaleDuration())
+
+	s.scheduler = newScheduler(s)
+	s.scheduler.Load(c.ScheduledTasks())
+	go s.scheduler.supervise(exit)
+
+	s.bus = events.NewBus(s)
+	s.subscribeEvents()
+	go func() { <-exit; s.bus.Close() }()
 
 	s.SetLoglevel(s.config.Loglevel())
 	return s
@@ -183,7 +227,8 @@ func New(c ifaces.IConfigurator, wg *sync.WaitGroup, exit chan struct{},
 // NotifyServer sends an ingame notification
 func (s *Server) NotifyServer(in string) error {
 	cmd := sprintf("say [NOTIFICATION] %s", in)
-	_, err := s.RunCommand(cmd)
+	_, err := s.RunCommandContext(context.Background(),
+		ifaces.CommandPriorityChat, cmd)
 	return err
 }
 
@@ -194,19 +239,24 @@ func (s *Server) NotifyServer(in string) error {
 // Start starts the Avorion server process
 func (s *Server) Start(sendchat bool) error {
 	logger.LogDebug(s, "Start() was called")
-	state.mutex.Lock()
-	state.isstarting = true
+	return s.start(sendchat, false)
+}
+
+// start starts the Avorion server process. When restarting, the server stays
+// in the restarting state until it is online.
+func (s *Server) start(sendchat, restarting bool) error {
+	s.state.mutex.Lock()
 	logger.LogDebug(s, "Start() is locking Avorion command state")
 
 	defer func() {
-		state.isstarting = false
-		state.mutex.Unlock()
+		s.state.mutex.Unlock()
 		logger.LogDebug(s, "Unlocked Avorion state from Start()")
 	}()
 
 	var (
 		sectors []*ifaces.Sector
 		err     error
+		online  bool
 	)
 
 	// Catch cases where Avorion is already running
@@ -214,6 +264,24 @@ func (s *Server) Start(sendchat bool) error {
 		return errors.New("Cannot start server thats already running")
 	}
 
+	if !restarting {
+		if err := s.setState(s.crashState(ifaces.ServerStarting),
+			"Starting"); err != nil {
+			return err
+		}
+	}
+
+	// Any failure to start leaves the server offline
+	defer func() {
+		if !online {
+			s.setState(s.crashState(ifaces.ServerOffline), "Failed to start")
+		}
+	}()
+
+	if report := s.Preflight(); !report.Passed() {
+		return s.preflightError(report)
+	}
+
 	if s.players != nil {
 		s.players = nil
 	}
@@ -247,14 +315,32 @@ func (s *Server) Start(sendchat bool) error {
 		return errors.New("Failed to generate modconfig.lua file")
 	}
 
-	s.tracking, err = gamedb.New(sprintf("%s/%s",
+	// Write the server.ini changes that were applied while the server was running
+	if changes, err := s.config.StagedGameSettings(); err == nil && len(changes) > 0 {
+		if _, err := s.config.ApplyGameSettings(); err != nil {
+			logger.LogWarning(s, "Failed to write staged changes to server.ini: "+
+				err.Error())
+		} else {
+			s.SendLog(ifaces.ChatData{Msg: sprintf("Wrote %d staged changes to "+
+				"**server.ini** of %s", len(changes), s.name)})
+		}
+	}
+
+	// The configured password wins over one that was set in server.ini by hand,
+	// which is only imported when no password is configured
+	if err := s.config.SyncServerPassword(); err != nil {
+		logger.LogWarning(s, "Failed to sync the password with server.ini: "+
+			err.Error())
+	}
+
+	db, err := gamedb.New(sprintf("%s/%s",
 		s.config.DataPath(),
 		s.config.DBName()))
 	if err != nil {
 		return err
 	}
 
-	sectors, err = s.tracking.Init()
+	sectors, err = db.Init()
 	if err != nil {
 		return errors.New("GameDB: " + err.Error())
 	}
@@ -268,7 +354,9 @@ func (s *Server) Start(sendchat bool) error {
 		s.sectorcount++
 	}
 
-	s.tracking.SetLoglevel(s.loglevel)
+	db.SetLoglevel(s.loglevel)
+	s.setTrackingDB(db)
+	s.pruneEvents()
 
 	s.Cmd = exec.Command(
 		s.serverpath+"/bin/"+s.executable,
@@ -277,7 +365,11 @@ func (s *Server) Start(sendchat bool) error {
 		"--admin", s.admin,
 		"--rcon-ip", s.config.RCONAddr(),
 		"--rcon-password", s.config.RCONPass(),
-		"--rcon-port", fmt.Sprint(s.config.RCONPort()))
+		"--rcon-port", fmt.Sprint(s.config.RCONPort()),
+		"--port", fmt.Sprint(s.config.GamePort()),
+		"--query-port", fmt.Sprint(s.config.GamePort()+queryPortOffset),
+		"--steam-query-port", fmt.Sprint(s.config.PingPort()),
+		"--steam-master-port", fmt.Sprint(s.config.PingPort()+1))
 
 	s.Cmd.Dir = s.serverpath
 	s.Cmd.Env = append(os.Environ(),
@@ -304,12 +396,26 @@ func (s *Server) Start(sendchat bool) error {
 	s.Cmd.Stdout = outw
 	s.stdout = outr
 
+	// Heartbeats from a previous process don't count towards this one
+	s.heartbeats.Reset()
+
 	// Make our intercom channels
 	ready := make(chan struct{})  // Avorion is fully up
 	s.close = make(chan struct{}) // Close all goroutines
 
-	go superviseAvorionOut(s, ready, s.close)
+	// Track when the output supervisor has consumed all of Avorions output, so
+	// that crash reports include the final lines
+	outdone := make(chan struct{})
+	go func() {
+		superviseAvorionOut(s, ready, s.close)
+		close(outdone)
+	}()
 	go updateAvorionStatus(s, s.close)
+	go superviseHeartbeats(s, s.close)
+	go superviseEvents(s, s.close)
+	go superviseResources(s, s.close)
+	go superviseMOTD(s, s.close)
+	go superviseModUpdates(s, s.close)
 
 	go func() {
 		defer func() {
@@ -353,21 +459,36 @@ func (s *Server) Start(sendchat bool) error {
 
 		logger.LogInit(s, "Started Server and waiting till ready")
 		s.Cmd.Wait()
+		s.rcon.Close()
+		outw.Close()
 		logger.LogWarning(s, sprintf("Avorion exited with status code (%d)",
 			s.Cmd.ProcessState.ExitCode()))
 		code := s.Cmd.ProcessState.ExitCode()
+
+		// Exiting while online means that nobody asked Avorion to stop
+		if s.state.base() == ifaces.ServerOnline {
+			s.setState(ifaces.ServerCrashedOffline,
+				sprintf("Avorion exited unexpectedly (%d)", code))
+		}
+
 		if code != 0 {
 			s.Crashed()
-			s.SendLog(ifaces.ChatData{Msg: sprintf(
-				"**Server Error**: Avorion has exited with non-zero status code: `%d`",
-				code)})
+
+			select {
+			case <-outdone:
+			case <-time.After(5 * time.Second):
+				logger.LogWarning(s, "Timed out waiting for the remaining output")
+			}
+
+			s.writeCrashReport(code)
 		}
 		close(s.close)
 	}()
 
 	select {
 	case <-ready:
-		state.iscrashed = false
+		online = true
+		s.setState(ifaces.ServerOnline, "Server is online")
 		logger.LogInit(s, "Server is online")
 		s.config.LoadGameConfig()
 
@@ -379,6 +500,8 @@ func (s *Server) Start(sendchat bool) error {
 		}()
 
 		s.loadSectors()
+		go s.setHeartbeatInterval()
+		go s.RotateMOTD()
 
 		// If we have a Post-Up command configured, start that script in a goroutine.
 		// We start it there, so that in the event that the script is intende to
@@ -452,12 +575,11 @@ func (s *Server) Start(sendchat bool) error {
 			}()
 		}
 
-		state.last = time.Now()
+		s.state.last = time.Now()
 		return nil
 
 	case <-s.close:
 		close(ready)
-		state.isstarting = false
 		return errors.New("avorion initialization failed")
 
 	case <-time.After(5 * time.Minute):
@@ -470,25 +592,39 @@ func (s *Server) Start(sendchat bool) error {
 // Stop gracefully stops the Avorion process
 func (s *Server) Stop(sendchat bool) error {
 	logger.LogDebug(s, "Stop() was called")
+	return s.stop(sendchat, false)
+}
 
+// stop gracefully stops the Avorion process. When restarting, the server stays
+// in the restarting state once it has stopped.
+func (s *Server) stop(sendchat, restarting bool) error {
 	// Lock until any previous state operations are completed
-	state.mutex.Lock()
-	defer func() {
-		state.isstopping = false
-		state.mutex.Unlock()
-	}()
-	state.isstopping = true
+	s.state.mutex.Lock()
+	defer s.state.mutex.Unlock()
 
 	if s.IsUp() != true {
 		logger.LogOutput(s, "Server is already offline")
 		return nil
 	}
 
+	if !restarting {
+		if err := s.setState(s.crashState(ifaces.ServerStopping),
+			"Stopping"); err != nil {
+			return err
+		}
+
+		defer func() {
+			s.setState(s.crashState(ifaces.ServerOffline), "Stopped")
+		}()
+	}
+
 	logger.LogInfo(s, "Stopping Avorion server and waiting for it to exit")
 	go func() {
-		_, err := s.RunCommand("save")
+		_, err := s.RunCommandContext(context.Background(),
+			ifaces.CommandPriorityHealth, "save")
 		if err == nil {
-			s.RunCommand("stop")
+			s.RunCommandContext(context.Background(),
+				ifaces.CommandPriorityHealth, "stop")
 			return
 		}
 		logger.LogError(s, err.Error())
@@ -502,7 +638,7 @@ func (s *Server) Stop(sendchat bool) error {
 	// and writes have completed
 	select {
 	case <-stopt:
-		state.iscrashed = true
+		s.setCrashed(true, "Killed after taking too long to exit")
 		s.Cmd.Process.Kill()
 		<-s.close
 		return errors.New("Avorion took too long to exit and had to be killed")
@@ -520,19 +656,22 @@ func (s *Server) Restart() error {
 	logger.LogDebug(s, "Restart() was called")
 
 	// We don't want to restart if the server was started in the last 10 seconds
-	if time.Now().Sub(state.last) > 10 {
-		if state.isrestarting || state.isstarting {
+	if time.Now().Sub(s.state.last) > 10 {
+		if st := s.state.base(); st == ifaces.ServerRestarting ||
+			st == ifaces.ServerStarting {
 			return nil
 		}
 
-		if err := s.Stop(false); err != nil {
-			logger.LogError(s, err.Error())
+		if err := s.setState(s.crashState(ifaces.ServerRestarting),
+			"Restarting"); err != nil {
+			return err
 		}
 
-		defer func() { state.isrestarting = false }()
-		state.isrestarting = true
+		if err := s.stop(false, true); err != nil {
+			logger.LogError(s, err.Error())
+		}
 
-		if err := s.Start(false); err != nil {
+		if err := s.start(false, true); err != nil {
 			logger.LogError(s, err.Error())
 			return err
 		}
@@ -587,7 +726,8 @@ func (s *Server) UpdatePlayerDatabase(notify bool) error {
 		s.NotifyServer(noticeDBUpate)
 	}
 
-	if out, err = s.RunCommand(rconGetAllData); err != nil {
+	if out, err = s.RunCommandContext(context.Background(),
+		ifaces.CommandPriorityBulk, rconGetAllData); err != nil {
 		logger.LogError(s, err.Error())
 		return err
 	}
@@ -627,8 +767,11 @@ func (s *Server) UpdatePlayerDatabase(notify bool) error {
 	s.playercount = playerCount
 	s.alliancecount = allianceCount
 
+	db := s.trackingDB()
 	for _, p := range s.players {
-		s.tracking.SetDiscordToPlayer(p)
+		if db != nil {
+			db.SetDiscordToPlayer(p)
+		}
 		p.SteamUID()
 		logger.LogDebug(s, "Processed player: "+p.Name())
 	}
@@ -651,15 +794,22 @@ func (s *Server) Status() ifaces.ServerStatus {
 
 	config, _ := s.config.GameConfig()
 
+	var process ifaces.ProcessStats
+	if s.IsUp() {
+		process, _ = s.resources.Latest()
+	}
+
 	return ifaces.ServerStatus{
 		Name:          name,
-		Status:        s.statusInt(),
+		Status:        s.state.get(),
 		Players:       s.onlineplayers,
 		TotalPlayers:  s.playercount,
 		PlayersOnline: s.onlineplayercount,
 		Alliances:     s.alliancecount,
 		Output:        s.statusoutput,
 		Sectors:       s.sectorcount,
+		Process:       process,
+		Locked:        s.config.ServerPassword() != "",
 		INI:           config}
 }
 
@@ -674,7 +824,9 @@ func (s *Server) CompareStatus(a, b ifaces.ServerStatus) bool {
 		a.PlayersOnline == b.PlayersOnline &&
 		a.Alliances == b.Alliances &&
 		a.Output == b.Output &&
-		a.Sectors == b.Sectors {
+		a.Sectors == b.Sectors &&
+		a.Locked == b.Locked &&
+		a.Process.Time == b.Process.Time {
 		return true
 	}
 	return false
@@ -683,19 +835,26 @@ func (s *Server) CompareStatus(a, b ifaces.ServerStatus) bool {
 // IsCrashed returns the current crash status of the server
 func (s *Server) IsCrashed() bool {
 	logger.LogDebug(s, "IsCrashed() was called")
-	return state.iscrashed
+	return s.state.crashed()
 }
 
 // Crashed sets the server status to crashed
 func (s *Server) Crashed() {
 	logger.LogDebug(s, "Crashed() was called")
-	state.iscrashed = true
+	s.setCrashed(true, "Crashed")
 }
 
 // Recovered sets the server status to be normal (from crashed)
 func (s *Server) Recovered() {
 	logger.LogDebug(s, "Recovered() was called")
-	state.iscrashed = false
+	s.setCrashed(false, "Recovered")
+}
+
+// ClearCrashLoop clears the crash history of the server, and cancels any
+// delayed restart. Returns true if the server was in a crash loop.
+func (s *Server) ClearCrashLoop() bool {
+	logger.LogDebug(s, "ClearCrashLoop() was called")
+	return s.crashes.Reset()
 }
 
 /************************/
@@ -721,44 +880,66 @@ func (s *Server) SetLoglevel(l int) {
 /* IFace ifaces.ICommandableServer */
 /***********************************/
 
-// RunCommand runs a command via rcon and returns the output
-//	TODO 1: Modify this to use the games rcon websocket interface or an rcon lib
-//	TODO 2: Modify this function to make use of permitted command levels
+// RunCommand runs a command via rcon and returns the output. Commands run this
+// way are queued with moderation priority.
+//	TODO: Modify this function to make use of permitted command levels
 func (s *Server) RunCommand(c string) (string, error) {
-	logger.LogDebug(s, sprintf(`RunCommand("%s") was called`, c))
+	return s.RunCommandContext(context.Background(),
+		ifaces.CommandPriorityModeration, c)
+}
 
-	cmdmutex.Lock()
-	logger.LogDebug(s, sprintf("RunCommand(%s) locking", c))
+// RunCommandContext queues a command with the given priority and returns its
+// output once it has run. The command is dropped from the queue if ctx is done
+// before that happens.
+func (s *Server) RunCommandContext(ctx context.Context, priority int,
+	c string) (string, error) {
+	logger.LogDebug(s, sprintf(`RunCommandContext(%d, "%s") was called`,
+		priority, c))
 
-	defer func() {
-		cmdmutex.Unlock()
-		logger.LogDebug(s, sprintf("Unlocking RunCommand(%s)", c))
-	}()
+	if !s.IsUp() {
+		return "", errors.New("Server is not online")
+	}
 
-	if s.IsUp() {
-		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
-		defer cancel()
+	out, err := s.commands.Exec(ctx, priority, c)
+	if err != nil {
+		logger.LogError(s, "rcon: "+err.Error())
+		return "", errors.New("Failed to run the following command: " + c)
+	}
 
-		// TODO: Make this use an rcon lib
-		ret, err := exec.CommandContext(ctx, s.config.RCONBin(), "-H",
-			s.rconaddr, "-p", sprintf("%d", s.rconport),
-			"-P", s.rconpass, c).CombinedOutput()
-		out := string(ret)
+	if strings.HasPrefix(out, "Unknown command: ") {
+		return out, errors.New("Invalid command provided")
+	}
 
-		if err != nil {
-			logger.LogError(s, "rcon: "+err.Error())
-			logger.LogError(s, "rcon: "+out)
-			return "", errors.New("Failed to run the following command: " + c)
-		}
+	return strings.TrimSuffix(out, "\n"), nil
+}
 
-		if strings.HasPrefix(out, "Unknown command: ") {
-			return out, errors.New("Invalid command provided")
-		}
+// CommandQueueStats returns the current state of the RCON command queue
+func (s *Server) CommandQueueStats() ifaces.CommandQueueStats {
+	return s.commands.Stats()
+}
 
-		return strings.TrimSuffix(out, "\n"), nil
-	}
+/*********************************/
+/* IFace ifaces.IScheduledServer */
+/*********************************/
+
+// Schedule returns the upcoming runs of the servers ScheduledTasks
+func (s *Server) Schedule() []ifaces.ScheduledTaskStatus {
+	return s.scheduler.List()
+}
 
-	return "", errors.New("Server is not online")
+// ReloadSchedule reloads the ScheduledTasks from the configuration
+func (s *Server) ReloadSchedule() {
+	s.scheduler.Load(s.config.ScheduledTasks())
+}
+
+// SkipScheduled skips the next run of a ScheduledTask
+func (s *Server) SkipScheduled(name string) error {
+	return s.scheduler.Skip(name)
+}
+
+// PostponeScheduled delays the next run of a ScheduledTask
+func (s *Server) PostponeScheduled(name string, d time.Duration) error {
+	return s.scheduler.Postpone(name, d)
 }
 
 /*********************************/
@@ -796,12 +977,24 @@ func (s *Server) SetSeed(seed string) {
 
 // Password - Return the current password
 func (s *Server) Password() string {
-	return s.password
+	return s.config.ServerPassword()
 }
 
-// SetPassword - Set the server password
+// SetPassword - Set the server password. Avorion reads it from server.ini, so
+// while the server is running the change is staged, and written when it is next
+// started.
 func (s *Server) SetPassword(p string) {
-	s.password = p
+	set := s.config.SetServerPassword
+	if s.IsUp() {
+		set = s.config.StageServerPassword
+	}
+
+	if err := set(p); err != nil {
+		logger.LogError(s, "Failed to write the password to server.ini: "+
+			err.Error())
+		s.SendLog(ifaces.ChatData{Msg: sprintf("Failed to update the password "+
+			"of %s: %s", s.config.Galaxy(), err.Error())})
+	}
 }
 
 /****************************/
@@ -810,12 +1003,36 @@ func (s *Server) SetPassword(p string) {
 
 // MOTD - Return the current MOTD
 func (s *Server) MOTD() string {
+	s.motdmutex.Lock()
+	defer s.motdmutex.Unlock()
 	return s.motd
 }
 
-// SetMOTD - Set the server MOTD
+// SetMOTD - Set the server MOTD, which is shown to players when they log in
 func (s *Server) SetMOTD(m string) {
+	s.motdmutex.Lock()
 	s.motd = m
+	s.motdmutex.Unlock()
+
+	if s.IsUp() {
+		s.sendMOTD()
+	}
+}
+
+// RotateMOTD - Move on to the next configured MOTD. Nothing is changed when
+// there are none configured, so that an MOTD that was set by hand stays put.
+func (s *Server) RotateMOTD() {
+	motds := s.config.MOTDList()
+	if len(motds) == 0 {
+		return
+	}
+
+	s.motdmutex.Lock()
+	s.motdindex = (s.motdindex + 1) % len(motds)
+	motd := motds[s.motdindex]
+	s.motdmutex.Unlock()
+
+	s.SetMOTD(motd)
 }
 
 /********************************/
@@ -905,7 +1122,8 @@ func (s *Server) NewPlayer(index string, d []string) ifaces.IPlayer {
 	cmd := sprintf(rconGetPlayerData, index)
 
 	if len(d) < 15 {
-		if data, err := s.RunCommand(cmd); err != nil {
+		if data, err := s.RunCommandContext(context.Background(),
+			ifaces.CommandPriorityBulk, cmd); err != nil {
 			logger.LogError(s, sprintf(errFailedRCON, err.Error()))
 		} else {
 			if d = rePlayerData.FindStringSubmatch(data); d == nil {
@@ -929,8 +1147,10 @@ func (s *Server) NewPlayer(index string, d []string) ifaces.IPlayer {
 	copy(darr[:], d)
 	p.UpdateFromData(darr)
 	s.players = append(s.players, p)
-	if err := s.tracking.TrackPlayer(p); err != nil {
-		logger.LogError(s, err.Error())
+	if db := s.trackingDB(); db != nil {
+		if err := db.TrackPlayer(p); err != nil {
+			logger.LogError(s, err.Error())
+		}
 	}
 	logger.LogInfo(p, "Registered player")
 	s.playercount++
@@ -954,7 +1174,8 @@ func (s *Server) NewAlliance(index string, d []string) ifaces.IAlliance {
 	}
 
 	if len(d) < 13 {
-		if data, err := s.RunCommand("getplayerdata -a " + index); err != nil {
+		if data, err := s.RunCommandContext(context.Background(),
+			ifaces.CommandPriorityBulk, "getplayerdata -a "+index); err != nil {
 			logger.LogError(s, sprintf("Failed to get alliance data: (%s)", err.Error()))
 		} else {
 			if d = rePlayerData.FindStringSubmatch(data); d != nil {
@@ -979,7 +1200,9 @@ func (s *Server) NewAlliance(index string, d []string) ifaces.IAlliance {
 		jumphistory: make([]ifaces.ShipCoordData, 0),
 		loglevel:    s.Loglevel()}
 
-	s.tracking.TrackAlliance(a)
+	if db := s.trackingDB(); db != nil {
+		db.TrackAlliance(a)
+	}
 	s.alliances = append(s.alliances, a)
 	logger.LogInfo(a, "Registered alliance")
 	return a
@@ -989,6 +1212,13 @@ func (s *Server) NewAlliance(index string, d []string) ifaces.IAlliance {
 func (s *Server) AddPlayerOnline() {
 	s.onlineplayercount++
 	s.updateOnlineString()
+
+	// Let joining players know that they're about to be kicked
+	if deadline, ok := s.PendingRestart(); ok {
+		go s.NotifyServer(sprintf("A server restart is pending, and will happen "+
+			"once everyone has left or in %s at the latest",
+			countdown(time.Until(deadline))))
+	}
 }
 
 // SubPlayerOnline decrements the count of online players
@@ -1030,7 +1260,9 @@ func (s *Server) ValidateIntegrationPin(in, discordID string) bool {
 
 	if val, ok := s.requests[m[1]]; ok {
 		if val == m[2] {
-			s.tracking.AddIntegration(discordID, s.Player(m[1]))
+			if db := s.trackingDB(); db != nil {
+				db.AddIntegration(discordID, s.Player(m[1]))
+			}
 			s.addIntegration(m[1], discordID)
 			return true
 		}
@@ -1057,7 +1289,9 @@ func (s *Server) Sector(x, y int) *ifaces.Sector {
 
 		// TODO: This performs unnecessarily expensive DB calls here. Granted,
 		// that ONLY affects initilization, but it should still be optimized
-		s.tracking.TrackSector(s.sectors[x][y])
+		if db := s.trackingDB(); db != nil {
+			db.TrackSector(s.sectors[x][y])
+		}
 		s.sectorcount++
 	}
 
@@ -1104,29 +1338,36 @@ func (s *Server) SendLog(input ifaces.ChatData) {
 
 // addIntegration is a helper function that registers an integration
 func (s *Server) addIntegration(index, discordID string) {
-	s.RunCommand(sprintf(rconPlayerDiscord, index, discordID))
+	s.RunCommandContext(context.Background(), ifaces.CommandPriorityBulk,
+		sprintf(rconPlayerDiscord, index, discordID))
 }
 
-// InitializeEvents runs the event initializer
+// InitializeEvents registers the built in events along with the events that are
+// defined in the configuration. The events are replaced all at once, so this is
+// safe to run while Avorion is running.
 func (s *Server) InitializeEvents() {
-	// Re-init our events and apply custom logged events
-	events.Initialize()
+	registerEvents(s.config, s)
+}
 
-	var (
-		regexPlayerIndex   = regexp.MustCompile(`^player:([0-9]+)$`)
-		regexAllianceIndex = regexp.MustCompile(`^alliance:([0-9]+)$`)
-	)
+// registerEvents installs the built in events along with the events that are
+// defined in the configuration, logging as l
+func registerEvents(c ifaces.IConfigurator, l logger.ILogger) {
+	// Build the new set of events before swapping it in
+	r := events.NewRegistry()
 
-	for _, ed := range s.config.GetEvents() {
+	for _, ed := range c.GetEvents() {
+		def := ed
 		ge := &events.Event{
 			FString: ed.FString,
 			Capture: ed.Regex,
+			Kind:    events.KindCustom,
 			Handler: func(srv ifaces.IGameServer, e *events.Event,
 				in string, oc chan string) {
-
-				logger.LogOutput(s, in)
+				logger.LogOutput(srv, in)
 				logger.LogDebug(e, "Got event: "+e.FString)
-				m := e.Capture.FindStringSubmatch(in)
+			},
+			Decode: func(srv ifaces.IGameServer, e *events.Event,
+				m []string) interface{} {
 				strings := make([]interface{}, 0)
 
 				// Attempt to match against our player/alliance database and set that
@@ -1135,14 +1376,14 @@ func (s *Server) InitializeEvents() {
 					switch {
 					case regexPlayerIndex.MatchString(v):
 						v = regexPlayerIndex.FindStringSubmatch(v)[1]
-						p := s.Player(v)
+						p := srv.Player(v)
 						if p != nil {
 							v = p.Name()
 						}
 
 					case regexAllianceIndex.MatchString(v):
 						v = regexAllianceIndex.FindStringSubmatch(v)[1]
-						a := s.Alliance(v)
+						a := srv.Alliance(v)
 						if a != nil {
 							v = a.Name()
 						}
@@ -1151,26 +1392,30 @@ func (s *Server) InitializeEvents() {
 					strings = append(strings, v)
 				}
 
-				srv.SendLog(ifaces.ChatData{
-					Msg: sprintf(e.FString, strings[1:]...)})
+				return &events.Custom{
+					Name:       e.Name(),
+					Message:    sprintf(e.FString, strings[1:]...),
+					Matches:    m,
+					Definition: def}
 			}}
 
-		ge.SetLoglevel(s.Loglevel())
+		ge.SetLoglevel(l.Loglevel())
 
-		if err := events.Add(ed.Name, ge); err != nil {
-			logger.LogWarning(s, "Failed to register event: "+err.Error())
+		if err := r.Add(ed.Name, ge); err != nil {
+			logger.LogWarning(l, "Failed to register event: "+err.Error())
 			continue
 		}
 	}
 
 	// Handle unmanaged text. We initilialize this last so that all other events
 	// are handled first.
-	events.New("EventNone", ".*", func(srv ifaces.IGameServer, e *events.Event,
+	r.New("EventNone", ".*", func(srv ifaces.IGameServer, e *events.Event,
 		in string, oc chan string) {
 		logger.LogOutput(srv, in)
 	})
 
-	logger.LogInit(s, "Completed event registration")
+	events.Install(r)
+	logger.LogInit(l, sprintf("Completed event registration (%d events)", r.Len()))
 }
 
 // TODO: Make this less godawful
@@ -1203,24 +1448,3 @@ func (s *Server) loadSectors() {
 		sort.Sort(jumpsByTime(a.jumphistory))
 	}
 }
-
-func (s *Server) statusInt() int {
-	var sint = ifaces.ServerOffline
-
-	switch {
-	case state.isrestarting:
-		sint = ifaces.ServerRestarting
-	case state.isstopping:
-		sint = ifaces.ServerStopping
-	case state.isstarting:
-		sint = ifaces.ServerStarting
-	case s.IsUp():
-		sint = ifaces.ServerOnline
-	}
-
-	if state.iscrashed {
-		sint = ifaces.ServerCrashedOffline + sint
-	}
-
-	return sint
-}
@@ -0,0 +1,148 @@
+package avorion
+
+import (
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"errors"
+	"time"
+)
+
+const (
+	softRestartPoll = 5 * time.Second
+
+	errSoftRestartPending = "A restart is already pending"
+	errServerOffline      = "Server is not online"
+)
+
+// pendingRestart is a restart that is waiting for the server to empty
+type pendingRestart struct {
+	created  time.Time
+	deadline time.Time
+	cancel   chan struct{}
+}
+
+// awaitEmpty restarts the server once all players have left, or when the
+// deadline passes
+func (s *Server) awaitEmpty(p *pendingRestart) {
+	ticker := time.NewTicker(softRestartPoll)
+	defer ticker.Stop()
+
+	warned := false
+	for {
+		select {
+		case <-p.cancel:
+			return
+		case <-s.exit:
+			return
+		case <-ticker.C:
+		}
+
+		// Don't restart a server that went down, or was restarted, while waiting
+		if !s.IsUp() || s.state.last.After(p.created) {
+			logger.LogInfo(s, "Server restarted while a restart was pending, dropping it")
+			s.clearPendingRestart(p)
+			return
+		}
+
+		now := time.Now()
+		if s.onlineplayercount <= 0 {
+			logger.LogInfo(s, "Server is empty, running pending restart")
+			break
+		}
+
+		if !now.Before(p.deadline) {
+			logger.LogInfo(s, "Deadline passed, running pending restart")
+			break
+		}
+
+		if !warned && p.deadline.Sub(now) <= time.Minute {
+			warned = true
+			s.NotifyServer(sprintf("The server will restart in %s",
+				countdown(p.deadline.Sub(now))))
+		}
+	}
+
+	if !s.clearPendingRestart(p) {
+		return
+	}
+
+	if err := s.Restart(); err != nil {
+		logger.LogError(s, "Pending restart failed: "+err.Error())
+		s.SendLog(ifaces.ChatData{Msg: "**Pending restart failed:** " + err.Error()})
+	}
+}
+
+// clearPendingRestart removes the pending restart if it is still p. Returns
+// false if it was already cancelled or replaced.
+func (s *Server) clearPendingRestart(p *pendingRestart) bool {
+	s.softmutex.Lock()
+	defer s.softmutex.Unlock()
+
+	if s.softrestart != p {
+		return false
+	}
+
+	s.softrestart = nil
+	return true
+}
+
+// SoftRestart restarts the server once it is empty, or once max has passed.
+// Players are told about the pending restart, and it can be cancelled with
+// CancelSoftRestart.
+func (s *Server) SoftRestart(max time.Duration) error {
+	logger.LogDebug(s, "SoftRestart() was called")
+
+	if !s.IsUp() {
+		return errors.New(errServerOffline)
+	}
+
+	s.softmutex.Lock()
+	defer s.softmutex.Unlock()
+
+	if s.softrestart != nil {
+		return errors.New(errSoftRestartPending)
+	}
+
+	now := time.Now()
+	s.softrestart = &pendingRestart{
+		created:  now,
+		deadline: now.Add(max),
+		cancel:   make(chan struct{})}
+
+	logger.LogInfo(s, sprintf("Restarting once the server is empty, or in %s",
+		max.String()))
+	go s.NotifyServer(sprintf("The server will restart once everyone has left, "+
+		"or in %s at the latest", countdown(max)))
+	go s.awaitEmpty(s.softrestart)
+	return nil
+}
+
+// CancelSoftRestart cancels a pending restart. Returns false if no restart was
+// pending.
+func (s *Server) CancelSoftRestart() bool {
+	s.softmutex.Lock()
+	defer s.softmutex.Unlock()
+
+	if s.softrestart == nil {
+		return false
+	}
+
+	close(s.softrestart.cancel)
+	s.softrestart = nil
+
+	logger.LogInfo(s, "Cancelled pending restart")
+	go s.NotifyServer("The pending server restart has been cancelled")
+	return true
+}
+
+// PendingRestart returns the deadline of a pending restart, if there is one
+func (s *Server) PendingRestart() (time.Time, bool) {
+	s.softmutex.Lock()
+	defer s.softmutex.Unlock()
+
+	if s.softrestart == nil {
+		return time.Time{}, false
+	}
+
+	return s.softrestart.deadline, true
+}
@@ -8,13 +8,14 @@ import (
 	"io/ioutil"
 	"log"
 	"math/rand"
+	"net/url"
 	"os"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/go-ini/ini"
-
 	"gopkg.in/yaml.v2"
 )
 
@@ -33,20 +34,44 @@ const (
 	defaultGamePort           = 27000
 	defaultRconPort           = 27015
 	defaultGamePingPort       = 27020
-	defaultRconBin            = "/usr/local/bin/rcon"
 	defaultRconAddress        = "127.0.0.1"
 	defaultGalaxyName         = "Galaxy"
 	defaultDataDirectory      = "/srv/avorion/"
 	defaultServerInstallation = "/srv/avorion/server_files/"
 	defaultTimeDatabaseUpdate = int64(3600)
 	defaultTimeHangCheck      = int64(300)
+	defaultTimeChatStale      = int64(10)
+	defaultTimeSoftRestart    = int64(3600)
+	defaultTimeMOTD           = int64(900)
+	defaultTimeLinkGrace      = int64(300)
+	defaultTimeModUpdates     = int64(1800)
+	defaultTimeResourceSample = int64(30)
+	defaultTimeHeartbeat      = int64(10)
+	defaultTimeHeartbeatGrace = int64(60)
+	defaultTimeStartupGrace   = int64(300)
+	defaultResourceHistory    = 2880
+	defaultCrashReportLines   = 200
+	defaultCrashLimit         = 3
+	defaultTimeCrashWindow    = int64(600)
+	defaultTimeCrashBackoff   = int64(30)
+	defaultTimeCrashBackoffMx = int64(1800)
+	defaultEventRetention     = int64(90)
+	defaultBackupsHourly      = 24
+	defaultBackupsDaily       = 7
 	defaultCommandPrefix      = "mention"
 	defaultStatusClear        = false
 	defaultEnforceMods        = false
+	defaultModUpdates         = ifaces.ModUpdatesLocal
+	defaultSteamCMD           = "steamcmd"
+	defaultAutostart          = true
 	defaultSentReact          = false
 
 	defaultTimeZone = "America/New_York"
 	defaultDBName   = "data.db"
+
+	// The heartbeat grace periods have to cover at least this many heartbeat
+	// intervals, or a healthy server would look hung
+	minHeartbeatGraceIntervals = int64(3)
 )
 
 var sprintf = fmt.Sprintf
@@ -57,6 +82,8 @@ func init() {
 
 // Conf is a struct representing a server configuration
 type Conf struct {
+	*gameINI
+
 	// Conf
 	ConfigFile string
 
@@ -74,8 +101,27 @@ type Conf struct {
 	gameconfig          *ifaces.ServerGameConfig
 	hangtimeseconds     int64
 	dbupdatetimeseconds int64
+	chatstaleseconds    int64
+
+	autostart           bool
+	softrestartseconds  int64
+	password            string
+	motds               []string
+	motdseconds         int64
+	heartbeatseconds    int64
+	heartbeatgrace      int64
+	startupgrace        int64
+	ticklagalert        int64
+	resourceseconds     int64
+	resourcehistory     int
+	memoryalerts        []uint64
+	crashreportlines    int
+	crashlimit          int
+	crashwindowseconds  int64
+	crashbackoffseconds int64
+	crashbackoffmax     int64
+	eventretention      int64
 
-	rconbin  string
 	rconpass string
 	rconaddr string
 	rconport int
@@ -98,6 +144,10 @@ type Conf struct {
 
 	roleAuthLevels   map[string]int
 	cmndAuthLevels   map[string]int
+	ingameadminlevel int
+	linkedonly       bool
+	linkedonlyrole   string
+	linkgraceseconds int64
 	aliasedCommands  map[string][]string
 	disabledCommands []string
 
@@ -107,8 +157,22 @@ type Conf struct {
 	enabledMods     []int64
 	allowedMods     []int64
 	enabledModPaths []string
+	modupdates      string
+	modupdatesecs   int64
+	workshopdir     string
+	steamcmd        string
 
 	loggedevents []*ifaces.LoggedServerEvent
+	scheduled    []*ifaces.ScheduledTask
+
+	// Backups
+	backupdir       string
+	backuphourly    int
+	backupdaily     int
+	backupaftersave bool
+
+	// Additional galaxies
+	galaxies []*GalaxyConf
 
 	// Chat
 	chatpipe chan ifaces.ChatData
@@ -127,8 +191,26 @@ func New() *Conf {
 		datadir:             defaultDataDirectory,
 		dbupdatetimeseconds: defaultTimeDatabaseUpdate,
 		hangtimeseconds:     defaultTimeHangCheck,
+		chatstaleseconds:    defaultTimeChatStale,
+		autostart:           defaultAutostart,
+		softrestartseconds:  defaultTimeSoftRestart,
+		motds:               make([]string, 0),
+		motdseconds:         defaultTimeMOTD,
+		linkgraceseconds:    defaultTimeLinkGrace,
+		heartbeatseconds:    defaultTimeHeartbeat,
+		heartbeatgrace:      defaultTimeHeartbeatGrace,
+		startupgrace:        defaultTimeStartupGrace,
+		resourceseconds:     defaultTimeResourceSample,
+		resourcehistory:     defaultResourceHistory,
+		crashreportlines:    defaultCrashReportLines,
+		crashlimit:          defaultCrashLimit,
+		crashwindowseconds:  defaultTimeCrashWindow,
+		crashbackoffseconds: defaultTimeCrashBackoff,
+		crashbackoffmax:     defaultTimeCrashBackoffMx,
+		eventretention:      defaultEventRetention,
+		backuphourly:        defaultBackupsHourly,
+		backupdaily:         defaultBackupsDaily,
 
-		rconbin:     defaultRconBin,
 		rconpass:    makePass(),
 		rconaddr:    defaultRconAddress,
 		discordLink: defaultDiscordLink,
@@ -145,13 +227,18 @@ func New() *Conf {
 		enabledMods:     make([]int64, 0),
 		allowedMods:     make([]int64, 0),
 		enabledModPaths: make([]string, 0),
+		modupdates:      defaultModUpdates,
+		modupdatesecs:   defaultTimeModUpdates,
+		steamcmd:        defaultSteamCMD,
 
 		timezone:        defaultTimeZone,
 		roleAuthLevels:  make(map[string]int),
 		cmndAuthLevels:  make(map[string]int),
 		aliasedCommands: make(map[string][]string),
-		loggedevents:    make([]*ifaces.LoggedServerEvent, 0)}
+		loggedevents:    make([]*ifaces.LoggedServerEvent, 0),
+		galaxies:        make([]*GalaxyConf, 0)}
 
+	c.gameINI = newGameINI(c)
 	return c
 }
 
@@ -190,25 +277,58 @@ func (c *Conf) SetTimeZone(tz string) error {
 }
 
 // Validate confirms that the configuration object in its current state is a
-// working configuration
+// working configuration. This includes all of the configured galaxies, which
+// must not share a name or any ports.
 func (c *Conf) Validate() error {
-	ports := []int{c.gameport, c.rconport, c.pingport}
+	var (
+		names = map[string]bool{strings.ToLower(c.galaxyname): true}
+		ports = make(map[int]string)
+	)
+
+	check := func(name string, galaxyports ...int) error {
+		for _, port := range galaxyports {
+			if port <= 0 {
+				return fmt.Errorf("Galaxy %s is missing a port in its configuration",
+					name)
+			}
 
-	if _, err := os.Stat(c.rconbin); err != nil {
-		if os.IsNotExist(err) {
-			return errors.New("RCON binary does not exist at " + c.rconbin)
+			if other, ok := ports[port]; ok {
+				return fmt.Errorf("Port %d is used by both %s and %s", port, other,
+					name)
+			}
+
+			ports[port] = name
 		}
+		return nil
 	}
 
-	for _, port := range ports {
-		if !isPortAvailable(port) {
-			return fmt.Errorf("Port %d is not available", port)
+	if err := check(c.galaxyname, c.gameport, c.rconport, c.pingport); err != nil {
+		return err
+	}
+
+	for _, g := range c.galaxies {
+		if names[strings.ToLower(g.galaxyname)] {
+			return fmt.Errorf("Galaxy %s is configured more than once", g.galaxyname)
+		}
+		names[strings.ToLower(g.galaxyname)] = true
+
+		if err := check(g.galaxyname, g.gameport, g.rconport,
+			g.pingport); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// Galaxies returns the configurations for the galaxies that are managed in
+// addition to the primary one
+func (c *Conf) Galaxies() []*GalaxyConf {
+	galaxies := make([]*GalaxyConf, len(c.galaxies))
+	copy(galaxies, c.galaxies)
+	return galaxies
+}
+
 // CommandDisabled - Check if a given command is disabled
 //  @cmd string    Command to be checked
 func (c *Conf) CommandDisabled(cmd string) bool {
@@ -317,6 +437,85 @@ func (c *Conf) LoadConfiguration() error {
 		c.hangtimeseconds = out.Game.SecondsTillHangCheck
 	}
 
+	if out.Game.SecondsSoftRestart > 0 {
+		c.softrestartseconds = out.Game.SecondsSoftRestart
+	}
+
+	if out.Game.SecondsMOTD > 0 {
+		c.motdseconds = out.Game.SecondsMOTD
+	}
+
+	if out.Game.SecondsHeartbeat > 0 {
+		c.heartbeatseconds = out.Game.SecondsHeartbeat
+	}
+
+	if out.Game.SecondsHeartbeatGrace > 0 {
+		c.heartbeatgrace = out.Game.SecondsHeartbeatGrace
+	}
+
+	if out.Game.SecondsStartupGrace > 0 {
+		c.startupgrace = out.Game.SecondsStartupGrace
+	}
+
+	if least := c.heartbeatseconds * minHeartbeatGraceIntervals; c.heartbeatgrace < least {
+		logger.LogError(c, sprintf("seconds_heartbeat_grace (%d) has to cover at least "+
+			"%d heartbeat intervals, using %d", c.heartbeatgrace,
+			minHeartbeatGraceIntervals, least))
+		c.heartbeatgrace = least
+	}
+
+	if c.startupgrace < c.heartbeatgrace {
+		logger.LogError(c, sprintf("seconds_heartbeat_startup_grace (%d) is shorter than the "+
+			"heartbeat grace period, using %d", c.startupgrace, c.heartbeatgrace))
+		c.startupgrace = c.heartbeatgrace
+	}
+
+	if out.Game.TickLagAlertMS > 0 {
+		c.ticklagalert = out.Game.TickLagAlertMS
+	}
+
+	if out.Game.SecondsResourceSample > 0 {
+		c.resourceseconds = out.Game.SecondsResourceSample
+	}
+
+	if out.Game.ResourceHistory > 0 {
+		c.resourcehistory = out.Game.ResourceHistory
+	}
+
+	c.memoryalerts = make([]uint64, 0, len(out.Game.MemoryAlertsMB))
+	for _, mb := range out.Game.MemoryAlertsMB {
+		if mb > 0 {
+			c.memoryalerts = append(c.memoryalerts, mb)
+		}
+	}
+	sort.Slice(c.memoryalerts, func(i, j int) bool {
+		return c.memoryalerts[i] < c.memoryalerts[j]
+	})
+
+	if out.Game.CrashReportLines > 0 {
+		c.crashreportlines = out.Game.CrashReportLines
+	}
+
+	if out.Game.CrashLimit > 0 {
+		c.crashlimit = out.Game.CrashLimit
+	}
+
+	if out.Game.SecondsCrashWindow > 0 {
+		c.crashwindowseconds = out.Game.SecondsCrashWindow
+	}
+
+	if out.Game.SecondsCrashBackoff > 0 {
+		c.crashbackoffseconds = out.Game.SecondsCrashBackoff
+	}
+
+	if out.Game.SecondsCrashBackoffMax > 0 {
+		c.crashbackoffmax = out.Game.SecondsCrashBackoffMax
+	}
+
+	if out.Game.EventRetentionDays > 0 {
+		c.eventretention = out.Game.EventRetentionDays
+	}
+
 	if !out.Core.LogTime {
 		c.logtime = false
 		log.SetFlags(0)
@@ -375,14 +574,25 @@ func (c *Conf) LoadConfiguration() error {
 		c.rconaddr = out.RCON.Address
 	}
 
-	if out.RCON.Binary != "" {
-		c.rconbin = out.RCON.Binary
-	}
-
 	if out.RCON.Port != 0 {
 		c.rconport = out.RCON.Port
 	}
 
+	if out.RCON.SecondsTillChatStale != 0 {
+		c.chatstaleseconds = out.RCON.SecondsTillChatStale
+	}
+
+	if out.Backups.KeepHourly != 0 {
+		c.backuphourly = out.Backups.KeepHourly
+	}
+
+	if out.Backups.KeepDaily != 0 {
+		c.backupdaily = out.Backups.KeepDaily
+	}
+
+	c.backupdir = out.Backups.Directory
+	c.backupaftersave = out.Backups.AfterSave
+
 	if out.Discord.ChatChannel != "" {
 		c.SetChatChannel(out.Discord.ChatChannel)
 	}
@@ -415,6 +625,13 @@ func (c *Conf) LoadConfiguration() error {
 		c.roleAuthLevels = out.Discord.RoleAuthLevels
 	}
 
+	c.ingameadminlevel = out.Discord.InGameAdminLevel
+	c.linkedonly = out.Discord.LinkedOnly
+	c.linkedonlyrole = out.Discord.LinkedOnlyRole
+	if out.Discord.SecondsLinkGrace > 0 {
+		c.linkgraceseconds = out.Discord.SecondsLinkGrace
+	}
+
 	if out.Discord.DiscordLink != "" {
 		c.discordLink = out.Discord.DiscordLink
 	}
@@ -448,20 +665,20 @@ func (c *Conf) LoadConfiguration() error {
 	if out.Events != nil {
 		c.loggedevents = make([]*ifaces.LoggedServerEvent, 0)
 		for ename, edef := range out.Events {
-			if edef[0] == "" {
+			if edef.FString == "" {
 				logger.LogError(c, "Cannot use blank output definition for event")
 				continue
 			}
 
-			re, err := regexp.Compile(edef[1])
+			re, err := regexp.Compile(edef.Regex)
 			if err != nil {
 				logger.LogError(c, sprintf(`Failed to compile regex from string: [%s] (%s)`,
-					edef[1], err.Error()))
+					edef.Regex, err.Error()))
 				continue
 			}
 
-			c.loggedevents = append(c.loggedevents, &ifaces.LoggedServerEvent{
-				Name: ename, FString: edef[0], Regex: re})
+			c.loggedevents = append(c.loggedevents, c.loadEventActions(ename, edef,
+				&ifaces.LoggedServerEvent{Name: ename, FString: edef.FString, Regex: re}))
 		}
 	}
 
@@ -469,32 +686,170 @@ func (c *Conf) LoadConfiguration() error {
 		c.steamID = out.Mods.SteamID
 	}
 
+	switch out.Mods.UpdateCheck {
+	case "":
+	case ifaces.ModUpdatesOff, ifaces.ModUpdatesLocal, ifaces.ModUpdatesSteamCMD:
+		c.modupdates = out.Mods.UpdateCheck
+	default:
+		logger.LogError(c, sprintf("Unknown mod update check %q, using %q",
+			out.Mods.UpdateCheck, c.modupdates))
+	}
+
+	if out.Mods.SecondsUpdateCheck > 0 {
+		c.modupdatesecs = out.Mods.SecondsUpdateCheck
+	}
+
+	if out.Mods.SteamCMD != "" {
+		c.steamcmd = out.Mods.SteamCMD
+	}
+
+	c.workshopdir = out.Mods.WorkshopDir
+
 	c.enforceMods = out.Mods.Enforce
 	c.sentreact = out.Discord.SentReact
 	c.postUpCmd = out.Game.PostUpCommand
 	c.postDownCmd = out.Game.PostDownCommand
 
-	rconhost := fmt.Sprintf("[%s]\nhostname = %s\nport = %d\npassword = %s\n",
-		c.Galaxy(), c.RCONAddr(), c.RCONPort(), c.RCONPass())
-	ioutil.WriteFile(fmt.Sprintf("%s/rconhost.conf", c.DataPath()),
-		[]byte(rconhost), 0644)
+	c.password = out.Game.Password
+	c.motds = make([]string, 0, len(out.Game.MOTD))
+	for _, motd := range out.Game.MOTD {
+		if motd = strings.TrimSpace(motd); motd != "" {
+			c.motds = append(c.motds, motd)
+		}
+	}
+
+	c.autostart = defaultAutostart
+	if out.Game.Autostart != nil {
+		c.autostart = *out.Game.Autostart
+	}
+
+	c.loadGalaxies(out.Galaxies)
+	c.loadSchedule(out.Schedule)
 	return nil
 }
 
+// loadSchedule applies the configured ScheduledTasks. Tasks with an unknown
+// action are dropped, while the cron spec itself is validated by the server
+// that runs the task.
+func (c *Conf) loadSchedule(in map[string]yamlDataSchedule) {
+	names := make([]string, 0, len(in))
+	for name := range in {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	c.scheduled = make([]*ifaces.ScheduledTask, 0, len(names))
+	for _, name := range names {
+		sdef := in[name]
+
+		switch sdef.Action {
+		case ifaces.ScheduleActionSave, ifaces.ScheduleActionStop,
+			ifaces.ScheduleActionStart, ifaces.ScheduleActionRestart,
+			ifaces.ScheduleActionBackup:
+		default:
+			logger.LogError(c, sprintf("Invalid action for scheduled task %s: [%s]",
+				name, sdef.Action))
+			continue
+		}
+
+		warnings := make([]time.Duration, 0, len(sdef.Warnings))
+		for _, w := range sdef.Warnings {
+			if w > 0 {
+				warnings = append(warnings, time.Duration(w)*time.Second)
+			}
+		}
+
+		c.scheduled = append(c.scheduled, &ifaces.ScheduledTask{
+			Name:     name,
+			Galaxy:   sdef.Galaxy,
+			Action:   sdef.Action,
+			Spec:     sdef.Cron,
+			Empty:    sdef.Empty,
+			Warnings: warnings})
+	}
+}
+
+// scheduledTasks returns the ScheduledTasks that apply to a galaxy. Tasks that
+// do not name a galaxy apply to all of them.
+func (c *Conf) scheduledTasks(galaxy string) []*ifaces.ScheduledTask {
+	tasks := make([]*ifaces.ScheduledTask, 0)
+	for _, t := range c.scheduled {
+		if t.Galaxy == "" || strings.EqualFold(t.Galaxy, galaxy) {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks
+}
+
+// loadGalaxies applies the configuration for our additional galaxies. Galaxies
+// that were already loaded are updated in place, as their servers hold a
+// reference to them.
+func (c *Conf) loadGalaxies(in map[string]yamlDataGalaxy) {
+	names := make([]string, 0, len(in))
+	for name := range in {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	galaxies := make([]*GalaxyConf, 0, len(names))
+	for _, name := range names {
+		var g *GalaxyConf
+		for _, old := range c.galaxies {
+			if strings.EqualFold(old.galaxyname, name) {
+				g = old
+				break
+			}
+		}
+
+		if g == nil {
+			g = newGalaxyConf(c, name)
+			logger.LogInit(c, "Loaded configuration for galaxy: "+name)
+		}
+
+		g.load(in[name])
+		galaxies = append(galaxies, g)
+	}
+
+	for _, old := range c.galaxies {
+		found := false
+		for _, g := range galaxies {
+			found = found || g == old
+		}
+
+		if !found {
+			logger.LogWarning(c, sprintf("Galaxy %s was removed from the "+
+				"configuration, and will be dropped on the next restart",
+				old.galaxyname))
+		}
+	}
+
+	c.galaxies = galaxies
+}
+
 // SaveConfiguration saves our current configuration to a yaml file
 func (c *Conf) SaveConfiguration() error {
-	var events map[string][2]string
+	var events map[string]yamlDataEvent
 	events = nil
 
 	// Get our events and add them to our temporary map for serialization
 	if c.loggedevents != nil {
-		events = make(map[string][2]string, 0)
+		events = make(map[string]yamlDataEvent, 0)
 		for _, e := range c.loggedevents {
-			events[e.Name] = [2]string{
-				e.FString, e.Regex.String()}
+			events[e.Name] = yamlDataEvent{
+				FString:     e.FString,
+				Regex:       e.Regex.String(),
+				Cooldown:    int64(e.Cooldown / time.Second),
+				Channel:     e.Channel,
+				MentionRole: e.MentionRole,
+				RCON:        e.RCON,
+				MailTo:      e.MailTo,
+				MailSubject: e.MailSubject,
+				Mail:        e.Mail,
+				Webhook:     e.Webhook}
 		}
 	}
 
+	autostart := c.autostart
 	y := &yamlData{
 		Core: yamlDataCore{
 			LogTime:  c.logtime,
@@ -507,17 +862,41 @@ func (c *Conf) SaveConfiguration() error {
 			GalaxyName:           c.galaxyname,
 			InstallDir:           c.installdir,
 			DataDir:              c.datadir,
+			Autostart:            &autostart,
 			GamePort:             c.gameport,
 			PingPort:             c.pingport,
 			PostUpCommand:        c.postUpCmd,
 			PostDownCommand:      c.postDownCmd,
 			SecondsTillDBUpdate:  c.dbupdatetimeseconds,
-			SecondsTillHangCheck: c.hangtimeseconds},
+			SecondsTillHangCheck: c.hangtimeseconds,
+
+			SecondsSoftRestart:     c.softrestartseconds,
+
+			Password:    c.password,
+			MOTD:        c.motds,
+			SecondsMOTD: c.motdseconds,
+
+			SecondsHeartbeat:      c.heartbeatseconds,
+			SecondsHeartbeatGrace: c.heartbeatgrace,
+			SecondsStartupGrace:   c.startupgrace,
+			TickLagAlertMS:        c.ticklagalert,
+
+			SecondsResourceSample: c.resourceseconds,
+			ResourceHistory:       c.resourcehistory,
+			MemoryAlertsMB:        c.memoryalerts,
+
+			CrashReportLines:       c.crashreportlines,
+			CrashLimit:             c.crashlimit,
+			SecondsCrashWindow:     c.crashwindowseconds,
+			SecondsCrashBackoff:    c.crashbackoffseconds,
+			SecondsCrashBackoffMax: c.crashbackoffmax,
+
+			EventRetentionDays: c.eventretention},
 
 		RCON: yamlDataRCON{
-			Address: c.rconaddr,
-			Binary:  c.rconbin,
-			Port:    c.rconport},
+			Address:              c.rconaddr,
+			Port:                 c.rconport,
+			SecondsTillChatStale: c.chatstaleseconds},
 
 		Discord: yamlDataDiscord{
 			ClearStatusChannel: c.statuschannelclear,
@@ -531,6 +910,10 @@ func (c *Conf) SaveConfiguration() error {
 			Token:              c.token,
 			CommandAuthLevels:  c.cmndAuthLevels,
 			RoleAuthLevels:     c.roleAuthLevels,
+			InGameAdminLevel:   c.ingameadminlevel,
+			LinkedOnly:         c.linkedonly,
+			LinkedOnlyRole:     c.linkedonlyrole,
+			SecondsLinkGrace:   c.linkgraceseconds,
 			AliasedCommands:    c.aliasedCommands,
 			DisabledCommands:   c.disabledCommands},
 
@@ -539,10 +922,45 @@ func (c *Conf) SaveConfiguration() error {
 			Enforce:  c.enforceMods,
 			Enabled:  c.enabledMods,
 			Allowed:  c.allowedMods,
-			ModPaths: c.enabledModPaths},
+			ModPaths: c.enabledModPaths,
+
+			UpdateCheck:        c.modupdates,
+			SecondsUpdateCheck: c.modupdatesecs,
+			WorkshopDir:        c.workshopdir,
+			SteamCMD:           c.steamcmd},
+
+		Backups: yamlDataBackups{
+			Directory:  c.backupdir,
+			KeepHourly: c.backuphourly,
+			KeepDaily:  c.backupdaily,
+			AfterSave:  c.backupaftersave},
 
 		Events: events}
 
+	if len(c.galaxies) > 0 {
+		y.Galaxies = make(map[string]yamlDataGalaxy)
+		for _, g := range c.galaxies {
+			y.Galaxies[g.galaxyname] = g.yaml()
+		}
+	}
+
+	if len(c.scheduled) > 0 {
+		y.Schedule = make(map[string]yamlDataSchedule)
+		for _, t := range c.scheduled {
+			warnings := make([]int64, 0, len(t.Warnings))
+			for _, w := range t.Warnings {
+				warnings = append(warnings, int64(w/time.Second))
+			}
+
+			y.Schedule[t.Name] = yamlDataSchedule{
+				Galaxy:   t.Galaxy,
+				Action:   t.Action,
+				Cron:     t.Spec,
+				Empty:    t.Empty,
+				Warnings: warnings}
+		}
+	}
+
 	if strings.HasPrefix(y.Discord.Prefix, "<@!") {
 		y.Discord.Prefix = "mention"
 	}
@@ -653,12 +1071,6 @@ func (c *Conf) SetGalaxy(name string) {
 	c.galaxyname = name
 }
 
-// RCONBin returns the current RCON binary in use
-// TODO: This is temporary, until the rconlib is implemented
-func (c *Conf) RCONBin() string {
-	return c.rconbin
-}
-
 // RCONPort returns the current RCON port
 func (c *Conf) RCONPort() int {
 	return c.rconport
@@ -674,6 +1086,16 @@ func (c *Conf) RCONPass() string {
 	return c.rconpass
 }
 
+// GamePort returns the port that Avorion listens on
+func (c *Conf) GamePort() int {
+	return c.gameport
+}
+
+// PingPort returns the port that Avorion answers Steam queries on
+func (c *Conf) PingPort() int {
+	return c.pingport
+}
+
 // PostUpCommand returns the command configured to be run when starting the
 // server
 func (c *Conf) PostUpCommand() string {
@@ -686,12 +1108,159 @@ func (c *Conf) PostDownCommand() string {
 	return c.postDownCmd
 }
 
+// PortAvailable returns whether or not a port can be bound over both TCP and
+// UDP
+func (c *Conf) PortAvailable(port int) bool {
+	return isPortAvailable(port) && isUDPPortAvailable(port)
+}
+
+// Autostart returns whether or not the server is started along with the bot
+func (c *Conf) Autostart() bool {
+	return c.autostart
+}
+
+// ServerPassword returns the password that players need to join the server, or
+// an empty string if the server is not locked
+func (c *Conf) ServerPassword() string {
+	return c.password
+}
+
+// SetServerPassword sets the password that players need to join the server,
+// and writes it to server.ini while the server isn't running. An empty password
+// unlocks the server.
+func (c *Conf) SetServerPassword(p string) error {
+	if c.password != p {
+		c.password = p
+		c.SaveConfiguration()
+	}
+
+	return c.gameINI.writePassword(p)
+}
+
+// StageServerPassword sets the password that players need to join the server,
+// and stages it to be written to server.ini with the other staged changes. A
+// running server would overwrite a password that was written straight away.
+func (c *Conf) StageServerPassword(p string) error {
+	if c.password != p {
+		c.password = p
+		c.SaveConfiguration()
+	}
+
+	return c.gameINI.stagePassword(p)
+}
+
+// SyncServerPassword writes the configured password to server.ini. When none is
+// configured, a password that was set in server.ini by hand is imported.
+func (c *Conf) SyncServerPassword() error {
+	p, err := syncServerPassword(c.gameINI.file(), c.password)
+	if p != c.password {
+		c.password = p
+		c.SaveConfiguration()
+	}
+	return err
+}
+
+// MOTDList returns the messages of the day that are rotated through
+func (c *Conf) MOTDList() []string {
+	motds := make([]string, len(c.motds))
+	copy(motds, c.motds)
+	return motds
+}
+
+// SetMOTDList sets the messages of the day that are rotated through
+func (c *Conf) SetMOTDList(motds []string) {
+	c.motds = motds
+	c.SaveConfiguration()
+}
+
+// MOTDRotation returns the time between changes of the message of the day
+func (c *Conf) MOTDRotation() time.Duration {
+	return time.Duration(c.motdseconds) * time.Second
+}
+
 // HangTimeDuration returns a time.Duration based on the configured seconds until
 // between hang checks
 func (c *Conf) HangTimeDuration() time.Duration {
 	return time.Duration(c.hangtimeseconds) * time.Second
 }
 
+// ChatStaleDuration returns a time.Duration based on the configured seconds that
+// a chat message may wait in the RCON queue before it is dropped
+func (c *Conf) ChatStaleDuration() time.Duration {
+	return time.Duration(c.chatstaleseconds) * time.Second
+}
+
+// SoftRestartDeadline returns the longest time that a restart will wait for
+// the server to empty
+func (c *Conf) SoftRestartDeadline() time.Duration {
+	return time.Duration(c.softrestartseconds) * time.Second
+}
+
+// HeartbeatInterval returns the time between heartbeats sent by the
+// avocontrol-utilities mod
+func (c *Conf) HeartbeatInterval() time.Duration {
+	return time.Duration(c.heartbeatseconds) * time.Second
+}
+
+// HeartbeatGrace returns how long a heartbeat can be missing before the server
+// is considered hung, and how long to wait for the first heartbeat after the
+// server has started
+func (c *Conf) HeartbeatGrace() (time.Duration, time.Duration) {
+	return time.Duration(c.heartbeatgrace) * time.Second,
+		time.Duration(c.startupgrace) * time.Second
+}
+
+// TickLagAlert returns the average tick lag that triggers an alert, or zero if
+// tick lag alerts are disabled
+func (c *Conf) TickLagAlert() time.Duration {
+	return time.Duration(c.ticklagalert) * time.Millisecond
+}
+
+// ResourceSampleDuration returns the time between samples of the resource usage
+// of the Avorion process
+func (c *Conf) ResourceSampleDuration() time.Duration {
+	return time.Duration(c.resourceseconds) * time.Second
+}
+
+// ResourceHistorySize returns the number of resource samples that are kept
+func (c *Conf) ResourceHistorySize() int {
+	return c.resourcehistory
+}
+
+// MemoryAlertThresholds returns the memory usage thresholds in bytes that
+// trigger an alert, in ascending order
+func (c *Conf) MemoryAlertThresholds() []uint64 {
+	thresholds := make([]uint64, 0, len(c.memoryalerts))
+	for _, mb := range c.memoryalerts {
+		thresholds = append(thresholds, mb*1024*1024)
+	}
+	return thresholds
+}
+
+// CrashReportLines returns the number of lines of Avorion output that are kept
+// for crash reports
+func (c *Conf) CrashReportLines() int {
+	return c.crashreportlines
+}
+
+// CrashLimit returns the number of crashes that are allowed within the returned
+// window of time before the server is considered to be crash looping
+func (c *Conf) CrashLimit() (int, time.Duration) {
+	return c.crashlimit, time.Duration(c.crashwindowseconds) * time.Second
+}
+
+// CrashBackoff returns the initial and maximum delays before restarting a
+// server that is crash looping
+func (c *Conf) CrashBackoff() (time.Duration, time.Duration) {
+	return time.Duration(c.crashbackoffseconds) * time.Second,
+		time.Duration(c.crashbackoffmax) * time.Second
+}
+
+// EventRetention returns how long recorded events are kept in the tracking DB
+func (c *Conf) EventRetention() time.Duration {
+	return time.Duration(c.eventretention) * 24 * time.Hour
+}
+
 // DBUpdateTimeDuration returns a time.Duration based on the configured seconds until
 // between dbupdates
 func (c *Conf) DBUpdateTimeDuration() time.Duration {
@@ -706,23 +1275,8 @@ func (c *Conf) DBUpdateTimeDuration() time.Duration {
 //	@id string		Channel ID to set
 func (c *Conf) SetChatChannel(id string) chan ifaces.ChatData {
 	c.chatchannel = id
-
-	// Close the channel if its still listening.
-	if c.chatpipe != nil {
-		select {
-		case _, ok := <-c.chatpipe:
-			if ok {
-				close(c.chatpipe)
-			}
-		case <-time.After(100 * time.Nanosecond):
-			logger.LogDebug(c, "Closing old chatpipe")
-			close(c.chatpipe)
-		}
-	}
-
 	logger.LogInfo(c, sprintf("Setting chat channel to: %s", id))
-
-	c.chatpipe = make(chan ifaces.ChatData, 100)
+	c.chatpipe = replacePipe(c, c.chatpipe)
 	return c.chatpipe
 }
 
@@ -799,6 +1353,24 @@ func (c *Conf) RemoveCmndAuth(cmnd string) error {
 	return nil
 }
 
+// LinkedOnly returns whether or not players need a linked Discord account to
+// join, and the ID of the role that the account needs (if any)
+func (c *Conf) LinkedOnly() (bool, string) {
+	return c.linkedonly, c.linkedonlyrole
+}
+
+// LinkedOnlyGrace returns how long a player without a linked Discord account
+// has to link one before they are kicked
+func (c *Conf) LinkedOnlyGrace() time.Duration {
+	return time.Duration(c.linkgraceseconds) * time.Second
+}
+
+// InGameAdminLevel returns the authorization level that makes a linked player
+// an admin in game, or zero if the admin list isn't managed
+func (c *Conf) InGameAdminLevel() int {
+	return c.ingameadminlevel
+}
+
 /**************************************/
 /* IFace ifaces.IDatabaseConfigurator */
 /**************************************/
@@ -815,7 +1387,13 @@ func (c *Conf) DBName() string {
 
 // BuildModConfig generates a valid modconfig.lua file for Avorion
 func (c *Conf) BuildModConfig() error {
-	file := sprintf("%s/%s/modconfig.lua", c.datadir, c.galaxyname)
+	return c.buildModConfig(c.datadir, c.galaxyname)
+}
+
+// buildModConfig generates the modconfig.lua file for the galaxy in datadir.
+// Mods are shared between all of the configured galaxies.
+func (c *Conf) buildModConfig(datadir, galaxy string) error {
+	file := sprintf("%s/%s/modconfig.lua", datadir, galaxy)
 	logger.LogInfo(c, "Generating "+file)
 
 	modconfig := sprintf("-- Generated by AvorionControl\n"+
@@ -825,7 +1403,7 @@ func (c *Conf) BuildModConfig() error {
 		"modLocation   = \"\"\n"+
 		"forceEnabling = %t\n"+
 		"local prefix  = \"%s\"\n"+
-		"\nmods = {\n", c.enforceMods, c.datadir+"mods/")
+		"\nmods = {\n", c.enforceMods, datadir+"mods/")
 
 	modconfig += sprintf("  {workshopid = \"%s\"},\n", c.steamID)
 
@@ -848,6 +1426,88 @@ func (c *Conf) BuildModConfig() error {
 	return ioutil.WriteFile(file, []byte(modconfig), 0644)
 }
 
+// ModUpdateCheck returns how workshop mods are checked for updates, and how
+// often. The method is ModUpdatesOff when updates aren't checked for.
+func (c *Conf) ModUpdateCheck() (string, time.Duration) {
+	return c.modupdates, time.Duration(c.modupdatesecs) * time.Second
+}
+
+// WorkshopPath returns the directory that is checked for mod updates. When
+// steamcmd is used this is the directory it downloads into, and otherwise it
+// defaults to the workshop content directory of the primary galaxy.
+func (c *Conf) WorkshopPath() string {
+	dir := c.workshopdir
+	if dir == "" {
+		dir = strings.TrimSuffix(c.datadir, "/") + "/workshop/content/445220"
+		if c.modupdates == ifaces.ModUpdatesSteamCMD {
+			dir = strings.TrimSuffix(c.datadir, "/") + "/steamcmd"
+		}
+	}
+	return strings.TrimSuffix(dir, "/") + "/"
+}
+
+// SteamCMDPath returns the steamcmd executable used to check for mod updates
+func (c *Conf) SteamCMDPath() string {
+	return c.steamcmd
+}
+
+// ValidateModConfig returns the problems with the configured mods that would
+// stop Avorion from loading them, and the ones that only might
+func (c *Conf) ValidateModConfig() ([]error, []string) {
+	return c.validateModConfig(c.datadir)
+}
+
+// validateModConfig checks the configured mods against the mods available in
+// datadir. Conflicts and version mismatches are only warned about, as the
+// modinfo.lua files that they come from can't always be trusted.
+func (c *Conf) validateModConfig(datadir string) ([]error, []string) {
+	var (
+		errs     = make([]error, 0)
+		warnings = make([]string, 0)
+		seen     = make(map[int64]bool)
+	)
+
+	if _, err := strconv.ParseInt(c.steamID, 10, 64); err != nil {
+		errs = append(errs, fmt.Errorf("Invalid workshop ID for the "+
+			"avocontrol-utilities mod: %q", c.steamID))
+	}
+
+	for _, modid := range c.enabledMods {
+		if modid <= 0 {
+			errs = append(errs, fmt.Errorf("Invalid workshop ID: %d", modid))
+		} else if seen[modid] {
+			errs = append(errs, fmt.Errorf("Mod %d is enabled more than once", modid))
+		}
+		seen[modid] = true
+	}
+
+	for _, modpath := range c.enabledModPaths {
+		info := datadir + "mods/" + modpath + "/modinfo.lua"
+		if _, err := os.Stat(info); err != nil {
+			errs = append(errs, fmt.Errorf("Local mod %s has no modinfo.lua at %s",
+				modpath, info))
+		}
+	}
+
+	for _, allowedid := range c.allowedMods {
+		if allowedid <= 0 {
+			errs = append(errs, fmt.Errorf("Invalid allowed mod ID: %d", allowedid))
+		}
+	}
+
+	// Dependencies can only be checked for mods that have been downloaded
+	catalog, _ := c.modCatalog(datadir)
+	for _, p := range c.catalogProblems(catalog) {
+		if p.missing {
+			errs = append(errs, errors.New(p.msg))
+		} else {
+			warnings = append(warnings, p.msg)
+		}
+	}
+
+	return errs, warnings
+}
+
 // AddServerMod adds a server mod to the config file and saves said config
 func (c *Conf) AddServerMod(id int64) error {
 	for _, found := range c.enabledMods {
@@ -924,13 +1584,25 @@ func (c *Conf) ListClientMods() []int64 {
 
 // LoadGameConfig loads the server.ini file from the current game path
 func (c *Conf) LoadGameConfig() error {
-	var gcfg = &ifaces.ServerGameConfig{}
-	cfg, err := ini.Load(c.datadir + "/" + c.galaxyname + "/server.ini")
+	gcfg, err := loadGameConfig(c, c.datadir+"/"+c.galaxyname+"/server.ini")
 	if err != nil {
-		logger.LogError(c, "Failed to load game ini: "+err.Error())
 		return err
 	}
 
+	c.gameconfig = gcfg
+	return nil
+}
+
+// loadGameConfig parses the server.ini file at the given path
+func loadGameConfig(l logger.ILogger, file string) (*ifaces.ServerGameConfig,
+	error) {
+	var gcfg = &ifaces.ServerGameConfig{}
+	cfg, err := loadGameINI(file)
+	if err != nil {
+		logger.LogError(l, "Failed to load game ini: "+err.Error())
+		return nil, err
+	}
+
 	section := cfg.Section("Game")
 	gcfg.Name = cfg.Section("Administration").Key("name").MustString("Avorion Server")
 	gcfg.Steam = cfg.Section("Networking").Key("useSteam").MustBool()
@@ -947,9 +1619,8 @@ func (c *Conf) LoadGameConfig() error {
 	gcfg.MaxAllianceSlots = section.Key("AllianceInventorySlots").MustInt64()
 	gcfg.MaxAllianceShips = section.Key("MaximumAllianceShips").MustInt64()
 	gcfg.MaxAllianceStations = section.Key("MaximumAllianceStations").MustInt64()
-	c.gameconfig = gcfg
-	logger.LogInit(c, "Loaded server.ini")
-	return nil
+	logger.LogInit(l, "Loaded server.ini")
+	return gcfg, nil
 }
 
 // GameConfig returns the loaded server.ini object
@@ -968,23 +1639,8 @@ func (c *Conf) GameConfig() (*ifaces.ServerGameConfig, bool) {
 //	@id string		Channel ID to set
 func (c *Conf) SetLogChannel(id string) chan ifaces.ChatData {
 	c.logchannel = id
-
-	// Close the channel if its still listening.
-	if c.logpipe != nil {
-		select {
-		case _, ok := <-c.logpipe:
-			if ok {
-				close(c.logpipe)
-			}
-		case <-time.After(100 * time.Nanosecond):
-			logger.LogDebug(c, "Closing old logpipe")
-			close(c.logpipe)
-		}
-	}
-
 	logger.LogInfo(c, sprintf("Setting log channel to: %s", id))
-
-	c.logpipe = make(chan ifaces.ChatData, 100)
+	c.logpipe = replacePipe(c, c.logpipe)
 	return c.logpipe
 }
 
@@ -1003,6 +1659,105 @@ func (c *Conf) LogChannel() string {
 	return c.logchannel
 }
 
+// loadEventActions copies the actions of a custom event into e, logging and
+// leaving out the ones that can't work
+func (c *Conf) loadEventActions(name string, def yamlDataEvent,
+	e *ifaces.LoggedServerEvent) *ifaces.LoggedServerEvent {
+	if def.Cooldown < 0 {
+		logger.LogError(c, sprintf("Event %s has a negative cooldown", name))
+	} else {
+		e.Cooldown = time.Duration(def.Cooldown) * time.Second
+	}
+
+	for _, id := range [2]*string{&def.Channel, &def.MentionRole} {
+		if _, err := strconv.ParseUint(*id, 10, 64); *id != "" && err != nil {
+			logger.LogError(c, sprintf("Event %s has an invalid Discord ID: %s", name, *id))
+			*id = ""
+		}
+	}
+	e.Channel = def.Channel
+	e.MentionRole = def.MentionRole
+	e.RCON = def.RCON
+
+	if (def.MailTo == "") != (def.Mail == "") {
+		logger.LogError(c, sprintf("Event %s needs both mail_to and mail to send mail",
+			name))
+	} else {
+		e.MailTo = def.MailTo
+		e.MailSubject = def.MailSubject
+		e.Mail = def.Mail
+	}
+
+	if def.Webhook != "" {
+		u, err := url.Parse(def.Webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			logger.LogError(c, sprintf("Event %s has an invalid webhook URL", name))
+		} else {
+			e.Webhook = def.Webhook
+		}
+	}
+
+	return e
+}
+
+/**************************************/
+/* IFace ifaces.IScheduleConfigurator */
+/**************************************/
+
+// ScheduledTasks returns the ScheduledTasks for the primary galaxy
+func (c *Conf) ScheduledTasks() []*ifaces.ScheduledTask {
+	return c.scheduledTasks(c.galaxyname)
+}
+
+/************************************/
+/* IFace ifaces.IBackupConfigurator */
+/************************************/
+
+// BackupPath returns the directory that backups of the primary galaxy are
+// stored in
+func (c *Conf) BackupPath() string {
+	return c.backupPath(c.DataPath(), c.galaxyname)
+}
+
+// backupPath returns the backup directory for a galaxy, which defaults to a
+// backups directory alongside the galaxy
+func (c *Conf) backupPath(datapath, galaxy string) string {
+	dir := c.backupdir
+	if dir == "" {
+		dir = strings.TrimSuffix(datapath, "/") + "/backups"
+	}
+	return strings.TrimSuffix(dir, "/") + "/" + galaxy
+}
+
+// BackupRetention returns the number of hourly and daily backups to keep
+func (c *Conf) BackupRetention() (int, int) {
+	return c.backuphourly, c.backupdaily
+}
+
+// BackupAfterSave returns whether or not a backup is taken after every
+// scheduled save
+func (c *Conf) BackupAfterSave() bool {
+	return c.backupaftersave
+}
+
+// replacePipe closes a chat pipe if it is still listening, and returns a new
+// one to replace it with
+func replacePipe(l logger.ILogger, pipe chan ifaces.ChatData) chan ifaces.ChatData {
+	if pipe != nil {
+		select {
+		case _, ok := <-pipe:
+			if ok {
+				close(pipe)
+			}
+		case <-time.After(100 * time.Nanosecond):
+			logger.LogDebug(l, "Closing old pipe")
+			close(pipe)
+		}
+	}
+
+	return make(chan ifaces.ChatData, 100)
+}
+
 func touch(file string) error {
 	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
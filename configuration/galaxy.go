@@ -0,0 +1,343 @@
+package configuration
+
+import (
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"strings"
+)
+
+// GalaxyConf is the configuration for a galaxy that is managed in addition to
+// the primary one. Settings that are specific to a single Avorion instance are
+// stored here, while everything else is shared with (and saved by) the parent
+// Conf.
+type GalaxyConf struct {
+	*Conf
+	*gameINI
+
+	galaxyname string
+	datadir    string
+	dbname     string
+	autostart  *bool
+	password   string
+	motds      []string
+	gameconfig *ifaces.ServerGameConfig
+
+	rconpass string
+	rconport int
+	gameport int
+	pingport int
+
+	// Discord
+	statuschannel string
+	chatchannel   string
+	logchannel    string
+
+	// Chat
+	chatpipe chan ifaces.ChatData
+	logpipe  chan ifaces.ChatData
+}
+
+func newGalaxyConf(c *Conf, name string) *GalaxyConf {
+	g := &GalaxyConf{
+		Conf:       c,
+		galaxyname: name,
+		rconpass:   makePass()}
+
+	g.gameINI = newGameINI(g)
+	return g
+}
+
+// load applies the yaml configuration for the galaxy
+func (g *GalaxyConf) load(in yamlDataGalaxy) {
+	g.datadir = in.DataDir
+	g.dbname = in.DBName
+	g.autostart = in.Autostart
+	g.password = in.Password
+	g.motds = in.MOTD
+	g.gameport = in.GamePort
+	g.pingport = in.PingPort
+	g.rconport = in.RCONPort
+
+	if in.ChatChannel != "" && in.ChatChannel != g.chatchannel {
+		g.SetChatChannel(in.ChatChannel)
+	}
+
+	if in.LogChannel != "" && in.LogChannel != g.logchannel {
+		g.SetLogChannel(in.LogChannel)
+	}
+
+	if in.StatusChannel != "" {
+		g.SetStatusChannel(in.StatusChannel)
+	}
+}
+
+// yaml returns the galaxy configuration in its serializable form
+func (g *GalaxyConf) yaml() yamlDataGalaxy {
+	return yamlDataGalaxy{
+		DataDir:       g.datadir,
+		DBName:        g.dbname,
+		Autostart:     g.autostart,
+		Password:      g.password,
+		MOTD:          g.motds,
+		GamePort:      g.gameport,
+		PingPort:      g.pingport,
+		RCONPort:      g.rconport,
+		LogChannel:    g.logchannel,
+		ChatChannel:   g.chatchannel,
+		StatusChannel: g.statuschannel}
+}
+
+/************************/
+/* IFace: logger.Logger */
+/************************/
+
+// UUID - Return the objects name
+func (g *GalaxyConf) UUID() string {
+	return "Configuration:" + g.galaxyname
+}
+
+/*************************************/
+/* IFace ifaces.IDiscordConfigurator */
+/*************************************/
+
+// SetStatusChannel sets the current status channel
+func (g *GalaxyConf) SetStatusChannel(id string) {
+	logger.LogInfo(g, sprintf("Setting status channel to: %s", id))
+	g.statuschannel = id
+}
+
+// StatusChannel returns the current status channel
+func (g *GalaxyConf) StatusChannel() (string, bool) {
+	if g.statuschannel != "" {
+		return g.statuschannel, true
+	}
+	return "", false
+}
+
+/************************************/
+/* IFace ifaces.IGalaxyConfigurator */
+/************************************/
+
+// Galaxy returns the name of the galaxy
+func (g *GalaxyConf) Galaxy() string {
+	return g.galaxyname
+}
+
+// SetGalaxy sets the name of the galaxy
+func (g *GalaxyConf) SetGalaxy(name string) {
+	g.galaxyname = name
+}
+
+/**********************************/
+/* IFace ifaces.IGameConfigurator */
+/**********************************/
+
+// DataPath returns the datapath for the galaxy, which defaults to the one used
+// by the primary galaxy
+func (g *GalaxyConf) DataPath() string {
+	if g.datadir != "" {
+		return g.datadir
+	}
+	return g.Conf.DataPath()
+}
+
+// RCONPort returns the RCON port of the galaxy
+func (g *GalaxyConf) RCONPort() int {
+	return g.rconport
+}
+
+// RCONPass returns the RCON password of the galaxy
+func (g *GalaxyConf) RCONPass() string {
+	return g.rconpass
+}
+
+// GamePort returns the port that the galaxy listens on
+func (g *GalaxyConf) GamePort() int {
+	return g.gameport
+}
+
+// PingPort returns the port that the galaxy answers Steam queries on
+func (g *GalaxyConf) PingPort() int {
+	return g.pingport
+}
+
+// LoadGameConfig loads the server.ini file of the galaxy
+func (g *GalaxyConf) LoadGameConfig() error {
+	gcfg, err := loadGameConfig(g, g.DataPath()+"/"+g.galaxyname+"/server.ini")
+	if err != nil {
+		return err
+	}
+
+	g.gameconfig = gcfg
+	return nil
+}
+
+// GameConfig returns the loaded server.ini object
+func (g *GalaxyConf) GameConfig() (*ifaces.ServerGameConfig, bool) {
+	if g.gameconfig != nil {
+		return g.gameconfig, true
+	}
+	return nil, false
+}
+
+/**************************************/
+/* IFace ifaces.IDatabaseConfigurator */
+/**************************************/
+
+// DBName returns the filename of the galaxies tracking DB, which defaults to
+// the lowercased name of the galaxy
+func (g *GalaxyConf) DBName() string {
+	if g.dbname != "" {
+		return g.dbname
+	}
+	return strings.ToLower(g.galaxyname) + ".db"
+}
+
+// Autostart returns whether or not the galaxy is started along with the bot,
+// which defaults to the setting of the primary galaxy
+func (g *GalaxyConf) Autostart() bool {
+	if g.autostart != nil {
+		return *g.autostart
+	}
+	return g.Conf.Autostart()
+}
+
+// ServerPassword returns the password that players need to join the galaxy, or
+// an empty string if the galaxy is not locked
+func (g *GalaxyConf) ServerPassword() string {
+	return g.password
+}
+
+// SetServerPassword sets the password that players need to join the galaxy,
+// and writes it to server.ini while it isn't running. An empty password unlocks
+// the galaxy.
+func (g *GalaxyConf) SetServerPassword(p string) error {
+	if g.password != p {
+		g.password = p
+		g.SaveConfiguration()
+	}
+
+	return g.gameINI.writePassword(p)
+}
+
+// StageServerPassword sets the password that players need to join the galaxy,
+// and stages it to be written to server.ini with the other staged changes
+func (g *GalaxyConf) StageServerPassword(p string) error {
+	if g.password != p {
+		g.password = p
+		g.SaveConfiguration()
+	}
+
+	return g.gameINI.stagePassword(p)
+}
+
+// SyncServerPassword writes the configured password to server.ini. When none is
+// configured, a password that was set in server.ini by hand is imported.
+func (g *GalaxyConf) SyncServerPassword() error {
+	p, err := syncServerPassword(g.gameINI.file(), g.password)
+	if p != g.password {
+		g.password = p
+		g.SaveConfiguration()
+	}
+	return err
+}
+
+// MOTDList returns the messages of the day of the galaxy, which default to the
+// ones of the primary galaxy
+func (g *GalaxyConf) MOTDList() []string {
+	if g.motds == nil {
+		return g.Conf.MOTDList()
+	}
+
+	motds := make([]string, len(g.motds))
+	copy(motds, g.motds)
+	return motds
+}
+
+// SetMOTDList sets the messages of the day of the galaxy
+func (g *GalaxyConf) SetMOTDList(motds []string) {
+	g.motds = motds
+	g.SaveConfiguration()
+}
+
+/*********************************/
+/* IFace ifaces.IModConfigurator */
+/*********************************/
+
+// BuildModConfig generates a valid modconfig.lua file for the galaxy
+func (g *GalaxyConf) BuildModConfig() error {
+	return g.buildModConfig(g.DataPath(), g.galaxyname)
+}
+
+// ValidateModConfig returns the problems with the configured mods that would
+// stop the galaxy from loading them, and the ones that only might
+func (g *GalaxyConf) ValidateModConfig() ([]error, []string) {
+	return g.validateModConfig(g.DataPath())
+}
+
+// ModCatalog returns the mods that are available to the galaxy
+func (g *GalaxyConf) ModCatalog() ([]ifaces.ModInfo, []error) {
+	return g.modCatalog(g.DataPath())
+}
+
+/**********************************/
+/* IFace ifaces.IChatConfigurator */
+/**********************************/
+
+// SetChatChannel sets the channel that the galaxies chat is output to
+func (g *GalaxyConf) SetChatChannel(id string) chan ifaces.ChatData {
+	g.chatchannel = id
+	logger.LogInfo(g, sprintf("Setting chat channel to: %s", id))
+	g.chatpipe = replacePipe(g, g.chatpipe)
+	return g.chatpipe
+}
+
+// ChatChannel returns the current chat channel ID string
+func (g *GalaxyConf) ChatChannel() string {
+	return g.chatchannel
+}
+
+// ChatPipe returns a go channel for chat piping
+func (g *GalaxyConf) ChatPipe() chan ifaces.ChatData {
+	return g.chatpipe
+}
+
+/***********************************/
+/* IFace ifaces.IEventConfigurator */
+/***********************************/
+
+// SetLogChannel sets the channel that the galaxies events are logged to
+func (g *GalaxyConf) SetLogChannel(id string) chan ifaces.ChatData {
+	g.logchannel = id
+	logger.LogInfo(g, sprintf("Setting log channel to: %s", id))
+	g.logpipe = replacePipe(g, g.logpipe)
+	return g.logpipe
+}
+
+// LogPipe returns a go channel for log piping
+func (g *GalaxyConf) LogPipe() chan ifaces.ChatData {
+	return g.logpipe
+}
+
+// LogChannel returns the current log channel ID string
+func (g *GalaxyConf) LogChannel() string {
+	return g.logchannel
+}
+
+/**************************************/
+/* IFace ifaces.IScheduleConfigurator */
+/**************************************/
+
+// ScheduledTasks returns the ScheduledTasks for the galaxy
+func (g *GalaxyConf) ScheduledTasks() []*ifaces.ScheduledTask {
+	return g.scheduledTasks(g.galaxyname)
+}
+
+/************************************/
+/* IFace ifaces.IBackupConfigurator */
+/************************************/
+
+// BackupPath returns the directory that backups of the galaxy are stored in
+func (g *GalaxyConf) BackupPath() string {
+	return g.backupPath(g.DataPath(), g.galaxyname)
+}
@@ -0,0 +1,544 @@
+package configuration
+
+import (
+	"avorioncontrol/ifaces"
+	"bufio"
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-ini/ini"
+)
+
+const (
+	gameSettingBool = iota
+	gameSettingInt
+	gameSettingFloat
+	gameSettingString
+
+	errUnknownGameSetting  = "Unknown server.ini setting: %s"
+	errAmbiguousSetting    = "%s matches more than one setting, use Section.Key"
+	errReadOnlyGameSetting = "%s cannot be changed from Discord"
+	errNoStagedSettings    = "There are no staged changes"
+
+	// Shown in place of the value of a secret setting
+	hiddenGameSetting = "(hidden)"
+
+	serverPasswordSetting = "Administration.password"
+)
+
+// gameSetting describes a server.ini key that can be edited
+type gameSetting struct {
+	section  string
+	key      string
+	kind     int
+	min, max float64 // Ignored when equal
+	readonly bool
+	secret   bool // Staged by its own command, and never shown
+}
+
+func (gs gameSetting) name() string {
+	return gs.section + "." + gs.key
+}
+
+// gameSettings are the server.ini keys that are known to us. Anything else has
+// to be changed by hand.
+var gameSettings = []gameSetting{
+	{section: "Game", key: "Seed", kind: gameSettingString, readonly: true},
+	{section: "Game", key: "Version", kind: gameSettingString, readonly: true},
+	{section: "Game", key: "Difficulty", kind: gameSettingInt, min: -3, max: 3},
+	{section: "Game", key: "InfiniteResources", kind: gameSettingBool},
+	{section: "Game", key: "CollisionDamage", kind: gameSettingFloat, min: 0, max: 1},
+	{section: "Game", key: "PlayerToPlayerDamage", kind: gameSettingBool},
+	{section: "Game", key: "SameStartSector", kind: gameSettingBool},
+	{section: "Game", key: "MaximumPlayerShips", kind: gameSettingInt, min: 0, max: 1000},
+	{section: "Game", key: "MaximumPlayerStations", kind: gameSettingInt, min: 0, max: 1000},
+	{section: "Game", key: "MaximumAllianceShips", kind: gameSettingInt, min: 0, max: 1000},
+	{section: "Game", key: "MaximumAllianceStations", kind: gameSettingInt, min: 0, max: 1000},
+	{section: "Game", key: "MaximumBlocksPerCraft", kind: gameSettingInt, min: 0, max: 1e9},
+	{section: "Game", key: "MaximumVolumePerShip", kind: gameSettingInt, min: 0, max: 1e12},
+	{section: "Game", key: "PlayerInventorySlots", kind: gameSettingInt, min: 0, max: 10000},
+	{section: "Game", key: "AllianceInventorySlots", kind: gameSettingInt, min: 0, max: 10000},
+	{section: "Administration", key: "name", kind: gameSettingString},
+	{section: "Administration", key: "description", kind: gameSettingString},
+	{section: "Administration", key: "maxPlayers", kind: gameSettingInt, min: 1, max: 1000},
+	{section: "Administration", key: "public", kind: gameSettingBool},
+	{section: "Administration", key: "password", kind: gameSettingString,
+		readonly: true, secret: true},
+	{section: "Administration", key: "listed", kind: gameSettingBool},
+	{section: "Networking", key: "useSteam", kind: gameSettingBool, readonly: true}}
+
+// findGameSetting looks up a setting by its Section.Key name, or by its key
+// alone when that is unambiguous. Names are not case sensitive.
+func findGameSetting(name string) (gameSetting, error) {
+	var (
+		found = make([]gameSetting, 0)
+		lower = strings.ToLower(name)
+	)
+
+	for _, gs := range gameSettings {
+		if gs.secret {
+			continue
+		}
+		if strings.ToLower(gs.name()) == lower {
+			return gs, nil
+		}
+		if strings.ToLower(gs.key) == lower {
+			found = append(found, gs)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return gameSetting{}, errors.New(sprintf(errUnknownGameSetting, name))
+	case 1:
+		return found[0], nil
+	}
+
+	return gameSetting{}, errors.New(sprintf(errAmbiguousSetting, name))
+}
+
+// normalize validates a value for the setting, and returns it in the form that
+// is written to server.ini
+func (gs gameSetting) normalize(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	inRange := func(v float64) error {
+		if gs.min != gs.max && (v < gs.min || v > gs.max) {
+			return errors.New(sprintf("%s must be between %s and %s", gs.name(),
+				strconv.FormatFloat(gs.min, 'f', -1, 64),
+				strconv.FormatFloat(gs.max, 'f', -1, 64)))
+		}
+		return nil
+	}
+
+	switch gs.kind {
+	case gameSettingBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", errors.New(gs.name() + " must be true or false")
+		}
+		return strconv.FormatBool(b), nil
+
+	case gameSettingInt:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", errors.New(gs.name() + " must be a whole number")
+		}
+		return strconv.FormatInt(i, 10), inRange(float64(i))
+
+	case gameSettingFloat:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", errors.New(gs.name() + " must be a number")
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), inRange(f)
+	}
+
+	if strings.ContainsAny(value, "\r\n") {
+		return "", errors.New(gs.name() + " cannot span multiple lines")
+	}
+
+	return value, nil
+}
+
+// gameINIOwner is the configuration of the galaxy that a gameINI edits
+type gameINIOwner interface {
+	DataPath() string
+	Galaxy() string
+	LoadGameConfig() error
+}
+
+// gameINI stages and applies changes to the server.ini of a galaxy. It is
+// embedded in both Conf and GalaxyConf, and implements
+// ifaces.IGameINIConfigurator for them.
+type gameINI struct {
+	owner  gameINIOwner
+	mutex  *sync.Mutex
+	staged map[string]string
+}
+
+func newGameINI(owner gameINIOwner) *gameINI {
+	return &gameINI{
+		owner:  owner,
+		mutex:  new(sync.Mutex),
+		staged: make(map[string]string)}
+}
+
+// path returns the path of the server.ini file of a galaxy in the data
+// directory of the owner
+func (g *gameINI) path(galaxy string) string {
+	return g.owner.DataPath() + "/" + galaxy + "/server.ini"
+}
+
+// file returns the path of the server.ini file of the owner
+func (g *gameINI) file() string {
+	return g.path(g.owner.Galaxy())
+}
+
+// loadGameINI parses a server.ini file. Avorion has no inline comments, so a
+// ; or # is kept as part of the value.
+func loadGameINI(file string) (*ini.File, error) {
+	return ini.LoadSources(ini.LoadOptions{IgnoreInlineComment: true}, file)
+}
+
+// currentGameSetting returns the value of a setting in server.ini
+func currentGameSetting(cfg *ini.File, gs gameSetting) string {
+	return cfg.Section(gs.section).Key(gs.key).String()
+}
+
+// stagedGameSetting returns a setting that can be staged, including the secret
+// ones that findGameSetting leaves out
+func stagedGameSetting(name string) gameSetting {
+	for _, gs := range gameSettings {
+		if gs.name() == name {
+			return gs
+		}
+	}
+	return gameSetting{}
+}
+
+// hideSecrets replaces the values of secret settings in a list of changes
+func hideSecrets(changes []ifaces.GameSettingChange) []ifaces.GameSettingChange {
+	for i, change := range changes {
+		if !stagedGameSetting(change.Key).secret {
+			continue
+		}
+
+		for _, v := range []*string{&changes[i].Old, &changes[i].New} {
+			if *v != "" {
+				*v = hiddenGameSetting
+			}
+		}
+	}
+	return changes
+}
+
+// stagedChanges returns the staged changes against the current server.ini,
+// sorted by key, with the values of secret settings included
+func (g *gameINI) stagedChanges() ([]ifaces.GameSettingChange, error) {
+	cfg, err := loadGameINI(g.file())
+	if err != nil {
+		return nil, err
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	changes := make([]ifaces.GameSettingChange, 0, len(g.staged))
+	for name, value := range g.staged {
+		old := currentGameSetting(cfg, stagedGameSetting(name))
+		if old == value {
+			delete(g.staged, name)
+			continue
+		}
+		changes = append(changes, ifaces.GameSettingChange{
+			Key: name, Old: old, New: value})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+
+	return changes, nil
+}
+
+// stagePassword stages a new password for server.ini, which Avorion only reads
+// when it starts. Staging the current password unstages it.
+func (g *gameINI) stagePassword(password string) error {
+	cfg, err := loadGameINI(g.file())
+	if err != nil {
+		return err
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if currentGameSetting(cfg, stagedGameSetting(serverPasswordSetting)) ==
+		password {
+		delete(g.staged, serverPasswordSetting)
+	} else {
+		g.staged[serverPasswordSetting] = password
+	}
+
+	return nil
+}
+
+// writePassword writes a password to server.ini straight away, replacing any
+// password that was staged. The server must not be running.
+func (g *gameINI) writePassword(password string) error {
+	g.mutex.Lock()
+	delete(g.staged, serverPasswordSetting)
+	g.mutex.Unlock()
+
+	return writeServerPassword(g.file(), password)
+}
+
+/*************************************/
+/* IFace ifaces.IGameINIConfigurator */
+/*************************************/
+
+// GameSettings returns the known server.ini settings and their values
+func (g *gameINI) GameSettings() ([]ifaces.GameSetting, error) {
+	cfg, err := loadGameINI(g.file())
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]ifaces.GameSetting, 0, len(gameSettings))
+	for _, gs := range gameSettings {
+		if gs.secret {
+			continue
+		}
+		out = append(out, ifaces.GameSetting{
+			Key:      gs.name(),
+			Value:    currentGameSetting(cfg, gs),
+			ReadOnly: gs.readonly})
+	}
+
+	return out, nil
+}
+
+// GameSetting returns a single server.ini setting
+func (g *gameINI) GameSetting(name string) (ifaces.GameSetting, error) {
+	gs, err := findGameSetting(name)
+	if err != nil {
+		return ifaces.GameSetting{}, err
+	}
+
+	cfg, err := loadGameINI(g.file())
+	if err != nil {
+		return ifaces.GameSetting{}, err
+	}
+
+	return ifaces.GameSetting{
+		Key:      gs.name(),
+		Value:    currentGameSetting(cfg, gs),
+		ReadOnly: gs.readonly}, nil
+}
+
+// StageGameSetting validates a change to server.ini, and stages it to be
+// applied later. Staging the current value unstages it.
+func (g *gameINI) StageGameSetting(name, value string) (ifaces.GameSettingChange,
+	error) {
+	gs, err := findGameSetting(name)
+	if err != nil {
+		return ifaces.GameSettingChange{}, err
+	}
+
+	if gs.readonly {
+		return ifaces.GameSettingChange{}, errors.New(sprintf(
+			errReadOnlyGameSetting, gs.name()))
+	}
+
+	value, err = gs.normalize(value)
+	if err != nil {
+		return ifaces.GameSettingChange{}, err
+	}
+
+	cfg, err := loadGameINI(g.file())
+	if err != nil {
+		return ifaces.GameSettingChange{}, err
+	}
+
+	change := ifaces.GameSettingChange{
+		Key: gs.name(),
+		Old: currentGameSetting(cfg, gs),
+		New: value}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if change.Old == change.New {
+		delete(g.staged, change.Key)
+	} else {
+		g.staged[change.Key] = value
+	}
+
+	return change, nil
+}
+
+// StagedGameSettings returns the staged changes against the current
+// server.ini, sorted by key. Changes that have since been made by hand are
+// dropped, and the values of secret settings are hidden.
+func (g *gameINI) StagedGameSettings() ([]ifaces.GameSettingChange, error) {
+	changes, err := g.stagedChanges()
+	return hideSecrets(changes), err
+}
+
+// DiscardGameSettings drops the staged changes to server.ini, and returns how
+// many there were. Secret settings belong to their own commands, so they are
+// kept.
+func (g *gameINI) DiscardGameSettings() int {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	n := 0
+	for name := range g.staged {
+		if !stagedGameSetting(name).secret {
+			delete(g.staged, name)
+			n++
+		}
+	}
+	return n
+}
+
+// ApplyGameSettings writes the staged changes to server.ini. The previous file
+// is kept as server.ini.bak.
+func (g *gameINI) ApplyGameSettings() ([]ifaces.GameSettingChange, error) {
+	file := g.file()
+	changes, err := g.stagedChanges()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changes) == 0 {
+		return nil, errors.New(errNoStagedSettings)
+	}
+
+	cfg, err := loadGameINI(file)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, change := range changes {
+		gs := stagedGameSetting(change.Key)
+		cfg.Section(gs.section).Key(gs.key).SetValue(change.New)
+	}
+
+	original, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(file+".bak", original, 0644); err != nil {
+		return nil, err
+	}
+
+	if err := saveGameINI(cfg, file); err != nil {
+		return nil, err
+	}
+
+	g.mutex.Lock()
+	g.staged = make(map[string]string)
+	g.mutex.Unlock()
+
+	g.owner.LoadGameConfig()
+	return hideSecrets(changes), nil
+}
+
+// SeedGameConfig writes the server.ini of the galaxy with a new seed, using the
+// settings of the previous galaxy
+func (g *gameINI) SeedGameConfig(previous, seed string) error {
+	g.DiscardGameSettings()
+	if err := seedGameINI(g.path(previous), g.file(), seed); err != nil {
+		return err
+	}
+
+	g.owner.LoadGameConfig()
+	return nil
+}
+
+// saveGameINI writes server.ini the way that Avorion does, as Key=Value lines
+// without the alignment and quoting of go-ini. The file is replaced atomically.
+func saveGameINI(cfg *ini.File, file string) error {
+	out, err := os.Create(file + ".partial")
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(out)
+	for _, section := range cfg.Sections() {
+		if section.Name() == ini.DefaultSection && len(section.Keys()) == 0 {
+			continue
+		}
+
+		if section.Name() != ini.DefaultSection {
+			w.WriteString("[" + section.Name() + "]\n")
+		}
+
+		for _, key := range section.Keys() {
+			w.WriteString(key.Name() + "=" + key.Value() + "\n")
+		}
+		w.WriteString("\n")
+	}
+
+	if err := w.Flush(); err != nil {
+		out.Close()
+		return err
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file+".partial", file)
+}
+
+// seedGameINI writes a server.ini for a new galaxy that uses the given seed.
+// The settings of the server.ini at src are carried over when it exists, apart
+// from the ones that belong to the old galaxy.
+func seedGameINI(src, dst, seed string) error {
+	cfg, err := ini.LoadSources(ini.LoadOptions{Loose: true,
+		IgnoreInlineComment: true}, src)
+	if err != nil {
+		return err
+	}
+
+	cfg.Section("Game").DeleteKey("Version")
+	cfg.Section("Game").Key("Seed").SetValue(seed)
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+
+	return saveGameINI(cfg, dst)
+}
+
+// writeServerPassword sets the password in server.ini. A galaxy that hasn't
+// been created yet has no server.ini, and gets the password when it is first
+// started.
+func writeServerPassword(file, password string) error {
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return nil
+	}
+
+	cfg, err := loadGameINI(file)
+	if err != nil {
+		return err
+	}
+
+	key := cfg.Section("Administration").Key("password")
+	if key.String() == password {
+		return nil
+	}
+
+	key.SetValue(password)
+	return saveGameINI(cfg, file)
+}
+
+// syncServerPassword writes the configured password to server.ini, and returns
+// it. When no password is configured, the one in server.ini is returned
+// instead, so that a password that was set by hand is imported rather than
+// cleared.
+func syncServerPassword(file, password string) (string, error) {
+	if password != "" {
+		return password, writeServerPassword(file, password)
+	}
+
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return "", nil
+	}
+
+	cfg, err := loadGameINI(file)
+	if err != nil {
+		return "", err
+	}
+
+	return cfg.Section("Administration").Key("password").String(), nil
+}
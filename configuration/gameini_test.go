@@ -0,0 +1,132 @@
+package configuration
+
+import (
+	"avorioncontrol/ifaces"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testINIOwner is a galaxy in a temporary data directory
+type testINIOwner struct {
+	datapath string
+}
+
+func (o *testINIOwner) DataPath() string      { return o.datapath }
+func (o *testINIOwner) Galaxy() string        { return "Galaxy" }
+func (o *testINIOwner) LoadGameConfig() error { return nil }
+
+// testGameINI returns a gameINI for a server.ini with the given contents
+func testGameINI(t *testing.T, contents string) *gameINI {
+	g := newGameINI(&testINIOwner{datapath: t.TempDir()})
+	if err := os.MkdirAll(filepath.Dir(g.file()), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(g.file(), []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// iniPassword returns the password in the server.ini of g
+func iniPassword(t *testing.T, g *gameINI) string {
+	cfg, err := loadGameINI(g.file())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg.Section("Administration").Key("password").String()
+}
+
+func TestStagePassword(t *testing.T) {
+	g := testGameINI(t, "[Administration]\nname=Test\npassword=old\n")
+
+	if err := g.stagePassword("new"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := iniPassword(t, g); got != "old" {
+		t.Fatalf("staging wrote %q to server.ini", got)
+	}
+
+	changes, err := g.StagedGameSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ifaces.GameSettingChange{{Key: serverPasswordSetting,
+		Old: hiddenGameSetting, New: hiddenGameSetting}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("got staged changes %+v, expected %+v", changes, want)
+	}
+
+	// The password is only changed by its own commands
+	if _, err := g.StageGameSetting("password", "other"); err == nil {
+		t.Fatal("the password was staged as a regular setting")
+	}
+
+	settings, err := g.GameSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, gs := range settings {
+		if gs.Key == serverPasswordSetting {
+			t.Fatal("the password was listed with the other settings")
+		}
+	}
+
+	if n := g.DiscardGameSettings(); n != 0 {
+		t.Fatalf("discarded %d changes, expected the password to be kept", n)
+	}
+
+	if _, err := g.ApplyGameSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := iniPassword(t, g); got != "new" {
+		t.Fatalf("server.ini has password %q after applying, expected new", got)
+	}
+
+	// Unlocking shows that the password was cleared
+	if err := g.stagePassword(""); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err = g.ApplyGameSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = []ifaces.GameSettingChange{{Key: serverPasswordSetting,
+		Old: hiddenGameSetting}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("got applied changes %+v, expected %+v", changes, want)
+	}
+
+	if got := iniPassword(t, g); got != "" {
+		t.Fatalf("server.ini has password %q after unlocking", got)
+	}
+}
+
+func TestWritePasswordUnstages(t *testing.T) {
+	g := testGameINI(t, "[Administration]\npassword=old\n")
+
+	if err := g.stagePassword("staged"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.writePassword("written"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := iniPassword(t, g); got != "written" {
+		t.Fatalf("server.ini has password %q, expected written", got)
+	}
+
+	if changes, _ := g.StagedGameSettings(); len(changes) != 0 {
+		t.Fatalf("the staged password was kept: %+v", changes)
+	}
+}
@@ -0,0 +1,643 @@
+package configuration
+
+import (
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// The game itself is listed as a dependency by most mods
+	modGameID = "Avorion"
+
+	errModInfoNoMeta = "modinfo.lua does not define meta"
+)
+
+// luaTable is a Lua table literal, split into its keyed and positional fields
+type luaTable struct {
+	fields map[string]interface{}
+	items  []interface{}
+}
+
+func (t *luaTable) str(k string) string {
+	switch v := t.fields[k].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+func (t *luaTable) boolean(k string) bool {
+	b, _ := t.fields[k].(bool)
+	return b
+}
+
+// luaParser reads the subset of Lua that is used by modinfo.lua files, which
+// is a list of assignments of literals. Long strings and expressions such as
+// concatenation are not supported.
+type luaParser struct {
+	in  []rune
+	pos int
+
+	// An error found while skipping comments, which takes precedence over the
+	// errors that it causes later on
+	err error
+}
+
+func (p *luaParser) errorf(f string, a ...interface{}) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	if p.pos > len(p.in) {
+		p.pos = len(p.in)
+	}
+
+	line := strings.Count(string(p.in[:p.pos]), "\n") + 1
+	return fmt.Errorf("line %d: %s", line, sprintf(f, a...))
+}
+
+// at returns whether the input continues with s
+func (p *luaParser) at(s string) bool {
+	i := p.pos
+	for _, r := range s {
+		if i >= len(p.in) || p.in[i] != r {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// skip moves past whitespace and comments
+func (p *luaParser) skip() {
+	for p.pos < len(p.in) {
+		switch {
+		case unicode.IsSpace(p.in[p.pos]):
+			p.pos++
+
+		case p.at("--[["):
+			start := p.pos
+			p.pos += 4
+			for p.pos < len(p.in) && !p.at("]]") {
+				p.pos++
+			}
+
+			if p.pos >= len(p.in) {
+				p.pos = start
+				p.err = p.errorf("unfinished long comment")
+				p.pos = len(p.in)
+				return
+			}
+			p.pos += 2
+
+		case p.at("--"):
+			for p.pos < len(p.in) && p.in[p.pos] != '\n' {
+				p.pos++
+			}
+
+		default:
+			return
+		}
+	}
+}
+
+func (p *luaParser) peek() rune {
+	p.skip()
+	if p.pos >= len(p.in) {
+		return 0
+	}
+	return p.in[p.pos]
+}
+
+func (p *luaParser) expect(r rune) error {
+	if p.peek() != r {
+		return p.errorf("expected %q", r)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *luaParser) name() string {
+	p.skip()
+	start := p.pos
+	for p.pos < len(p.in) && (p.in[p.pos] == '_' || unicode.IsLetter(p.in[p.pos]) ||
+		(p.pos > start && unicode.IsDigit(p.in[p.pos]))) {
+		p.pos++
+	}
+	return string(p.in[start:p.pos])
+}
+
+func (p *luaParser) str() (string, error) {
+	quote := p.in[p.pos]
+	p.pos++
+
+	var b strings.Builder
+	for p.pos < len(p.in) {
+		r := p.in[p.pos]
+		p.pos++
+
+		switch r {
+		case quote:
+			return b.String(), nil
+		case '\n':
+			return "", p.errorf("unfinished string")
+		case '\\':
+			if p.pos >= len(p.in) {
+				return "", p.errorf("unfinished string")
+			}
+			r = p.in[p.pos]
+			p.pos++
+			switch r {
+			case 'n':
+				r = '\n'
+			case 't':
+				r = '\t'
+			}
+		}
+		b.WriteRune(r)
+	}
+
+	return "", p.errorf("unfinished string")
+}
+
+func (p *luaParser) value() (interface{}, error) {
+	switch r := p.peek(); {
+	case r == '{':
+		return p.table()
+
+	case r == '"' || r == '\'':
+		return p.str()
+
+	case r == '-' || r == '.' || unicode.IsDigit(r):
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.in) && strings.ContainsRune("0123456789.xXeEabcdefABCDEF",
+			p.in[p.pos]) {
+			p.pos++
+		}
+		n, err := strconv.ParseFloat(string(p.in[start:p.pos]), 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", string(p.in[start:p.pos]))
+		}
+		return n, nil
+	}
+
+	switch n := p.name(); n {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "nil":
+		return nil, nil
+	case "":
+		return nil, p.errorf("unexpected %q", p.peek())
+	default:
+		return nil, p.errorf("unsupported expression %q", n)
+	}
+}
+
+func (p *luaParser) table() (*luaTable, error) {
+	t := &luaTable{fields: make(map[string]interface{})}
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+
+	for p.peek() != '}' {
+		if p.peek() == 0 {
+			return nil, p.errorf("unfinished table")
+		}
+
+		key := ""
+		start := p.pos
+		switch r := p.peek(); {
+		case r == '[':
+			p.pos++
+			k, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(']'); err != nil {
+				return nil, err
+			}
+			key = fmt.Sprint(k)
+
+		case r == '_' || unicode.IsLetter(r):
+			key = p.name()
+		}
+
+		// Bare names that aren't followed by = are values like true or false
+		if key != "" && p.peek() == '=' {
+			p.pos++
+		} else {
+			key = ""
+			p.pos = start
+		}
+
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+
+		if key != "" {
+			t.fields[key] = v
+		} else {
+			t.items = append(t.items, v)
+		}
+
+		if r := p.peek(); r == ',' || r == ';' {
+			p.pos++
+		} else if r != '}' {
+			return nil, p.errorf("expected } or , in table")
+		}
+	}
+
+	p.pos++
+	return t, nil
+}
+
+// parseLua returns the global assignments made in a modinfo.lua
+func parseLua(data []byte) (map[string]interface{}, error) {
+	var (
+		p    = &luaParser{in: []rune(string(data))}
+		vars = make(map[string]interface{})
+	)
+
+	for p.peek() != 0 {
+		name := p.name()
+		if name == "local" {
+			name = p.name()
+		}
+
+		if name == "" {
+			return nil, p.errorf("unexpected %q", p.peek())
+		}
+
+		if err := p.expect('='); err != nil {
+			return nil, err
+		}
+
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		vars[name] = v
+
+		if p.peek() == ';' {
+			p.pos++
+		}
+	}
+
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	return vars, nil
+}
+
+// parseModInfo reads the metadata of a mod from the contents of its modinfo.lua
+func parseModInfo(data []byte) (ifaces.ModInfo, error) {
+	var info ifaces.ModInfo
+
+	vars, err := parseLua(data)
+	if err != nil {
+		return info, err
+	}
+
+	meta, ok := vars["meta"].(*luaTable)
+	if !ok {
+		return info, errors.New(errModInfoNoMeta)
+	}
+
+	info.ID = meta.str("id")
+	info.Name = meta.str("name")
+	info.Title = meta.str("title")
+	info.Version = meta.str("version")
+	info.ServerSideOnly = meta.boolean("serverSideOnly")
+	info.ClientSideOnly = meta.boolean("clientSideOnly")
+
+	if deps, ok := meta.fields["dependencies"].(*luaTable); ok {
+		for _, item := range deps.items {
+			d, ok := item.(*luaTable)
+			if !ok || d.str("id") == "" {
+				continue
+			}
+
+			dep := ifaces.ModDependency{
+				ID:           d.str("id"),
+				Min:          d.str("min"),
+				Max:          d.str("max"),
+				Optional:     d.boolean("optional"),
+				Incompatible: d.boolean("incompatible")}
+
+			if exact := d.str("exact"); exact != "" {
+				dep.Min, dep.Max = exact, exact
+			}
+
+			info.Dependencies = append(info.Dependencies, dep)
+		}
+	}
+
+	return info, nil
+}
+
+// readModInfo reads the modinfo.lua in the directory of a mod
+func readModInfo(dir string) (ifaces.ModInfo, error) {
+	data, err := ioutil.ReadFile(strings.TrimSuffix(dir, "/") + "/modinfo.lua")
+	if err != nil {
+		return ifaces.ModInfo{}, err
+	}
+
+	info, err := parseModInfo(data)
+	if err != nil {
+		return info, fmt.Errorf("%s/modinfo.lua: %s", dir, err.Error())
+	}
+
+	info.Path = dir
+	return info, nil
+}
+
+// compareModVersions compares two versions in the major.minor.patch format
+func compareModVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionMatches returns whether a version satisfies a dependency
+func versionMatches(d ifaces.ModDependency, version string) bool {
+	if version == "" {
+		return true
+	}
+	if d.Min != "" && compareModVersions(version, d.Min) < 0 {
+		return false
+	}
+	if d.Max != "" && compareModVersions(version, d.Max) > 0 {
+		return false
+	}
+	return true
+}
+
+// versionRange describes the versions of a mod that satisfy a dependency
+func versionRange(d ifaces.ModDependency) string {
+	switch {
+	case d.Min != "" && d.Min == d.Max:
+		return "version " + d.Min
+	case d.Min != "" && d.Max != "":
+		return sprintf("a version between %s and %s", d.Min, d.Max)
+	case d.Min != "":
+		return sprintf("version %s or newer", d.Min)
+	default:
+		return sprintf("version %s or older", d.Max)
+	}
+}
+
+// modCatalog reads the modinfo.lua of the local mods of the galaxy in datadir,
+// and of every downloaded workshop mod. Mods that can't be read are returned as
+// errors.
+func (c *Conf) modCatalog(datadir string) ([]ifaces.ModInfo, []error) {
+	var (
+		mods = make([]ifaces.ModInfo, 0)
+		errs = make([]error, 0)
+	)
+
+	for _, modpath := range c.enabledModPaths {
+		info, err := readModInfo(datadir + "mods/" + modpath)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		info.Local = true
+		mods = append(mods, info)
+	}
+
+	dir := c.WorkshopContentPath()
+	entries, _ := ioutil.ReadDir(dir)
+	for _, e := range entries {
+		if _, err := strconv.ParseInt(e.Name(), 10, 64); err != nil || !e.IsDir() {
+			continue
+		}
+
+		// Mods that are still being downloaded have no modinfo.lua yet
+		info, err := readModInfo(dir + e.Name())
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			logger.LogWarning(c, "Failed to read workshop mod: "+err.Error())
+			continue
+		}
+
+		// Mods that were developed locally may still have their local ID
+		info.ID = e.Name()
+		mods = append(mods, info)
+	}
+
+	sort.SliceStable(mods, func(i, j int) bool { return mods[i].Local && !mods[j].Local })
+	return mods, errs
+}
+
+// modIndex maps the IDs of the mods in a catalog to their info. The catalog
+// lists local mods first, and the first mod with an ID wins.
+func modIndex(catalog []ifaces.ModInfo) map[string]ifaces.ModInfo {
+	index := make(map[string]ifaces.ModInfo, len(catalog))
+	for _, m := range catalog {
+		if _, ok := index[m.ID]; !ok {
+			index[m.ID] = m
+		}
+	}
+	return index
+}
+
+// enabledModIDs returns the IDs of the enabled mods, including local ones
+func (c *Conf) enabledModIDs(catalog []ifaces.ModInfo) map[string]bool {
+	enabled := map[string]bool{c.steamID: true}
+	for _, id := range c.enabledMods {
+		enabled[strconv.FormatInt(id, 10)] = true
+	}
+	for _, m := range catalog {
+		if m.Local {
+			enabled[m.ID] = true
+		}
+	}
+	return enabled
+}
+
+// modProblem is an unmet dependency or conflict of an enabled mod. Only a
+// missing dependency stops a mod from loading.
+type modProblem struct {
+	mod     string
+	dep     string
+	msg     string
+	missing bool
+}
+
+// modProblems returns the unmet dependencies and conflicts of an enabled mod
+func modProblems(m ifaces.ModInfo, index map[string]ifaces.ModInfo,
+	enabled map[string]bool) []modProblem {
+	problems := make([]modProblem, 0)
+	label := m.ID
+	if m.Name != "" {
+		label = sprintf("%s (%s)", m.Name, m.ID)
+	}
+
+	for _, d := range m.Dependencies {
+		if d.ID == modGameID {
+			continue
+		}
+
+		var (
+			dep, known = index[d.ID]
+			msg        string
+			missing    bool
+		)
+
+		switch {
+		case d.Incompatible && enabled[d.ID] && (!known || versionMatches(d, dep.Version)):
+			msg = sprintf("%s conflicts with %s", label, d.ID)
+
+		case d.Incompatible || d.Optional:
+
+		case !enabled[d.ID]:
+			msg = sprintf("%s requires %s, which is not enabled", label, d.ID)
+			missing = true
+
+		case known && !versionMatches(d, dep.Version):
+			msg = sprintf("%s requires %s of %s, but %s is installed", label,
+				versionRange(d), d.ID, dep.Version)
+		}
+
+		if msg != "" {
+			problems = append(problems, modProblem{mod: m.ID, dep: d.ID, msg: msg,
+				missing: missing})
+		}
+	}
+
+	return problems
+}
+
+// catalogProblems returns the unmet dependencies and conflicts of every enabled
+// mod in a catalog
+func (c *Conf) catalogProblems(catalog []ifaces.ModInfo) []modProblem {
+	var (
+		index    = modIndex(catalog)
+		enabled  = c.enabledModIDs(catalog)
+		problems = make([]modProblem, 0)
+	)
+
+	for id := range enabled {
+		if m, ok := index[id]; ok {
+			problems = append(problems, modProblems(m, index, enabled)...)
+		}
+	}
+
+	sort.Slice(problems, func(i, j int) bool { return problems[i].msg < problems[j].msg })
+	return problems
+}
+
+/*********************************/
+/* IFace ifaces.IModConfigurator */
+/*********************************/
+
+// ModCatalog returns the mods that are available to the primary galaxy
+func (c *Conf) ModCatalog() ([]ifaces.ModInfo, []error) {
+	return c.modCatalog(c.datadir)
+}
+
+// WorkshopContentPath returns the directory that contains the downloaded
+// workshop mods, with one directory per workshop ID
+func (c *Conf) WorkshopContentPath() string {
+	if c.modupdates == ifaces.ModUpdatesSteamCMD {
+		return c.WorkshopPath() + "steamapps/workshop/content/445220/"
+	}
+	return c.WorkshopPath()
+}
+
+// AddServerModWithDependencies adds a server mod along with the workshop mods
+// that it depends on. Dependencies are only known for mods that have been
+// downloaded, and problems that can't be fixed are returned as warnings.
+func (c *Conf) AddServerModWithDependencies(id int64) ([]int64, []string, error) {
+	var (
+		catalog, _ = c.ModCatalog()
+		index      = modIndex(catalog)
+		added      = make([]int64, 0)
+		warnings   = make([]string, 0)
+		queue      = []int64{id}
+	)
+
+	if m, ok := index[strconv.FormatInt(id, 10)]; ok && m.ClientSideOnly {
+		return nil, nil, fmt.Errorf("%s only runs on clients, allow it with "+
+			"`mod allow` instead", m.Name)
+	}
+
+	if err := c.AddServerMod(id); err != nil {
+		return nil, nil, err
+	}
+
+	for len(queue) > 0 {
+		next := strconv.FormatInt(queue[0], 10)
+		queue = queue[1:]
+
+		m, ok := index[next]
+		if !ok {
+			warnings = append(warnings, sprintf("%s has not been downloaded yet, so "+
+				"its dependencies can't be checked", next))
+			continue
+		}
+
+		for _, d := range m.Dependencies {
+			if d.ID == modGameID || d.Optional || d.Incompatible {
+				continue
+			}
+
+			depid, err := strconv.ParseInt(d.ID, 10, 64)
+			if err != nil {
+				continue
+			}
+
+			if c.AddServerMod(depid) == nil {
+				added = append(added, depid)
+				queue = append(queue, depid)
+			}
+		}
+	}
+
+	c.SaveConfiguration()
+
+	// Only warn about problems that involve the mods that were just added
+	changed := map[string]bool{strconv.FormatInt(id, 10): true}
+	for _, dep := range added {
+		changed[strconv.FormatInt(dep, 10)] = true
+	}
+
+	for _, p := range c.catalogProblems(catalog) {
+		if changed[p.mod] || changed[p.dep] {
+			warnings = append(warnings, p.msg)
+		}
+	}
+
+	return added, warnings, nil
+}
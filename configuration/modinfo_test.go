@@ -0,0 +1,115 @@
+package configuration
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLua(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   string
+		want map[string]interface{}
+		err  string
+	}{
+		{
+			name: "literals",
+			in:   `a = 1; local b = "two" c = 'three' d = true e = false f = nil g = -0.5`,
+			want: map[string]interface{}{"a": 1.0, "b": "two", "c": "three",
+				"d": true, "e": false, "f": nil, "g": -0.5}},
+		{
+			name: "escapes",
+			in:   `a = "tab\tnew\nline \"quoted\" \\ \'single\'"`,
+			want: map[string]interface{}{"a": "tab\tnew\nline \"quoted\" \\ 'single'"}},
+		{
+			name: "comments",
+			in:   "-- line\na = 1 --[[ long\ncomment ]] b = 2 --[[]] c = 3",
+			want: map[string]interface{}{"a": 1.0, "b": 2.0, "c": 3.0}},
+		{
+			name: "nested tables",
+			in:   `t = {x = 1, [2] = "two"; {y = {z = true}}, "item",}`,
+			want: map[string]interface{}{"t": &luaTable{
+				fields: map[string]interface{}{"x": 1.0, "2": "two"},
+				items: []interface{}{
+					&luaTable{
+						fields: map[string]interface{}{"y": &luaTable{
+							fields: map[string]interface{}{"z": true}}}},
+					"item"}}}},
+		{name: "empty", in: "  \n-- nothing\n", want: map[string]interface{}{}},
+		{name: "unfinished long comment", in: "a = 1\n--[[ never closed",
+			err: "line 2: unfinished long comment"},
+		{name: "unfinished long comment in table", in: "t = {1, --[[ ]",
+			err: "unfinished long comment"},
+		{name: "truncated assignment", in: "a =", err: "unexpected"},
+		{name: "truncated table", in: "t = {a = 1,", err: "unfinished table"},
+		{name: "truncated string", in: `a = "abc`, err: "unfinished string"},
+		{name: "truncated escape", in: `a = "abc\`, err: "unfinished string"},
+		{name: "string across lines", in: "a = \"abc\ndef\"", err: "unfinished string"},
+		{name: "missing comma", in: "t = {1 2}", err: "expected } or ,"},
+		{name: "long string", in: "a = [[long]]", err: "unexpected"},
+		{name: "concatenation", in: `a = "x" .. "y"`, err: "unexpected"},
+		{name: "concatenation in table", in: `t = {"x" .. "y"}`, err: "expected } or ,"},
+		{name: "expression", in: "a = b", err: "unsupported expression"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseLua([]byte(tc.in))
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got error %v, expected %q", err, tc.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %#v, expected %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseModInfo(t *testing.T) {
+	info, err := parseModInfo([]byte(`
+meta = {
+    id = "1234",
+    name = "example",
+    title = "Example Mod",
+    version = "1.2.0",
+    serverSideOnly = true,
+
+    dependencies = {
+        {id = "Avorion", min = "2.0"},
+        {id = "5678", exact = "1.0"},
+        {id = "9999", optional = true},
+        {id = "4321", incompatible = true},
+    },
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.ID != "1234" || info.Name != "example" || info.Title != "Example Mod" ||
+		info.Version != "1.2.0" || !info.ServerSideOnly || info.ClientSideOnly {
+		t.Fatalf("got %+v", info)
+	}
+
+	if len(info.Dependencies) != 4 {
+		t.Fatalf("got %d dependencies, expected 4", len(info.Dependencies))
+	}
+
+	if d := info.Dependencies[1]; d.Min != "1.0" || d.Max != "1.0" {
+		t.Fatalf("exact version was read as %+v", d)
+	}
+
+	if !info.Dependencies[2].Optional || !info.Dependencies[3].Incompatible {
+		t.Fatalf("got %+v", info.Dependencies)
+	}
+
+	if _, err := parseModInfo([]byte(`name = "no meta"`)); err == nil {
+		t.Fatal("a modinfo.lua without meta was accepted")
+	}
+}
@@ -17,6 +17,15 @@ func isPortAvailable(p int) bool {
 	return true
 }
 
+func isUDPPortAvailable(p int) bool {
+	l, err := net.ListenPacket("udp", ":"+strconv.Itoa(p))
+	if err != nil {
+		return false
+	}
+	_ = l.Close()
+	return true
+}
+
 //https://stackoverflow.com/questions/22892120/how-to-generate-a-random-string-of-a-fixed-length-in-go/22892986#22892986
 func makePass() string {
 	letters := []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890")
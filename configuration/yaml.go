@@ -12,6 +12,7 @@ type yamlDataGame struct {
 	GalaxyName string `yaml:"galaxy_name"`
 	InstallDir string `yaml:"install_dir"`
 	DataDir    string `yaml:"data_dir"`
+	Autostart  *bool  `yaml:"autostart"`
 	PingPort   int    `yaml:"ping_port"`
 	GamePort   int    `yaml:"port"`
 
@@ -19,6 +20,29 @@ type yamlDataGame struct {
 	PostDownCommand      string `yaml:"post_down_command"`
 	SecondsTillDBUpdate  int64  `yaml:"seconds_until_dbupdate"`
 	SecondsTillHangCheck int64  `yaml:"seconds_until_hangcheck"`
+
+	SecondsSoftRestart int64 `yaml:"seconds_soft_restart_deadline"`
+
+	Password    string   `yaml:"password"`
+	MOTD        []string `yaml:"motd"`
+	SecondsMOTD int64    `yaml:"seconds_between_motd"`
+
+	SecondsHeartbeat      int64 `yaml:"seconds_between_heartbeats"`
+	SecondsHeartbeatGrace int64 `yaml:"seconds_heartbeat_grace"`
+	SecondsStartupGrace   int64 `yaml:"seconds_heartbeat_startup_grace"`
+	TickLagAlertMS        int64 `yaml:"tick_lag_alert_ms"`
+
+	SecondsResourceSample int64    `yaml:"seconds_between_resource_samples"`
+	ResourceHistory       int      `yaml:"resource_history_samples"`
+	MemoryAlertsMB        []uint64 `yaml:"memory_alert_thresholds_mb,flow"`
+
+	CrashReportLines       int   `yaml:"crash_report_lines"`
+	CrashLimit             int   `yaml:"crash_limit"`
+	SecondsCrashWindow     int64 `yaml:"seconds_crash_window"`
+	SecondsCrashBackoff    int64 `yaml:"seconds_crash_backoff"`
+	SecondsCrashBackoffMax int64 `yaml:"seconds_crash_backoff_max"`
+
+	EventRetentionDays int64 `yaml:"event_retention_days"`
 }
 
 type yamlDataDiscord struct {
@@ -36,14 +60,20 @@ type yamlDataDiscord struct {
 	AliasedCommands   map[string][]string `yaml:"aliased_commands"`
 	RoleAuthLevels    map[string]int      `yaml:"role_auth_levels"`
 	CommandAuthLevels map[string]int      `yaml:"command_auth_levels"`
+	InGameAdminLevel  int                 `yaml:"ingame_admin_level"`
+
+	LinkedOnly       bool   `yaml:"linked_only"`
+	LinkedOnlyRole   string `yaml:"linked_only_role"`
+	SecondsLinkGrace int64  `yaml:"seconds_linked_only_grace"`
 
 	ClearStatusChannel bool `yaml:"status_channel_clear"`
 }
 
 type yamlDataRCON struct {
 	Address string `yaml:"address"`
-	Binary  string `yaml:"binary"`
 	Port    int    `yaml:"port"`
+
+	SecondsTillChatStale int64 `yaml:"seconds_until_chat_stale"`
 }
 
 type yamlDataMods struct {
@@ -52,13 +82,90 @@ type yamlDataMods struct {
 	Allowed  []int64  `yaml:"allowed"`
 	Enabled  []int64  `yaml:"enabled"`
 	ModPaths []string `yaml:"modpaths"`
+
+	UpdateCheck        string `yaml:"update_check"`
+	SecondsUpdateCheck int64  `yaml:"seconds_between_update_checks"`
+	WorkshopDir        string `yaml:"workshop_dir"`
+	SteamCMD           string `yaml:"steamcmd"`
+}
+
+type yamlDataBackups struct {
+	Directory  string `yaml:"directory"`
+	KeepHourly int    `yaml:"keep_hourly"`
+	KeepDaily  int    `yaml:"keep_daily"`
+	AfterSave  bool   `yaml:"after_save"`
+}
+
+type yamlDataGalaxy struct {
+	DataDir       string   `yaml:"data_dir,omitempty"`
+	DBName        string   `yaml:"db_filename,omitempty"`
+	Autostart     *bool    `yaml:"autostart,omitempty"`
+	Password      string   `yaml:"password,omitempty"`
+	MOTD          []string `yaml:"motd,omitempty"`
+	GamePort      int      `yaml:"port"`
+	PingPort      int      `yaml:"ping_port"`
+	RCONPort      int      `yaml:"rcon_port"`
+	LogChannel    string   `yaml:"log_channel"`
+	ChatChannel   string   `yaml:"chat_channel"`
+	StatusChannel string   `yaml:"status_channel"`
+}
+
+type yamlDataSchedule struct {
+	Galaxy   string  `yaml:"galaxy,omitempty"`
+	Action   string  `yaml:"action"`
+	Cron     string  `yaml:"cron"`
+	Empty    bool    `yaml:"when_empty,omitempty"`
+	Warnings []int64 `yaml:"warning_seconds,flow"`
+}
+
+// yamlDataEvent is a custom event. Events without any actions can also be
+// written as a [fstring, regex] pair, which is how they are saved.
+type yamlDataEvent struct {
+	FString string `yaml:"message"`
+	Regex   string `yaml:"regex"`
+
+	Cooldown    int64  `yaml:"cooldown_seconds,omitempty"`
+	Channel     string `yaml:"channel,omitempty"`
+	MentionRole string `yaml:"mention_role,omitempty"`
+	RCON        string `yaml:"rcon,omitempty"`
+	MailTo      string `yaml:"mail_to,omitempty"`
+	MailSubject string `yaml:"mail_subject,omitempty"`
+	Mail        string `yaml:"mail,omitempty"`
+	Webhook     string `yaml:"webhook,omitempty"`
+}
+
+// yamlDataEventFields keeps yaml from calling the methods of yamlDataEvent
+// recursively
+type yamlDataEventFields yamlDataEvent
+
+// UnmarshalYAML reads an event in either of its forms
+func (e *yamlDataEvent) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var pair [2]string
+	if err := unmarshal(&pair); err == nil {
+		*e = yamlDataEvent{FString: pair[0], Regex: pair[1]}
+		return nil
+	}
+
+	return unmarshal((*yamlDataEventFields)(e))
+}
+
+// MarshalYAML writes events without actions as a [fstring, regex] pair
+func (e yamlDataEvent) MarshalYAML() (interface{}, error) {
+	if (e == yamlDataEvent{FString: e.FString, Regex: e.Regex}) {
+		return [2]string{e.FString, e.Regex}, nil
+	}
+
+	return yamlDataEventFields(e), nil
 }
 
 type yamlData struct {
-	Core    yamlDataCore         `yaml:"Core"`
-	Game    yamlDataGame         `yaml:"Game"`
-	RCON    yamlDataRCON         `yaml:"RCON"`
-	Discord yamlDataDiscord      `yaml:"Discord"`
-	Mods    yamlDataMods         `yaml:"Mods"`
-	Events  map[string][2]string `yaml:"Events"`
+	Core     yamlDataCore                `yaml:"Core"`
+	Game     yamlDataGame                `yaml:"Game"`
+	RCON     yamlDataRCON                `yaml:"RCON"`
+	Discord  yamlDataDiscord             `yaml:"Discord"`
+	Mods     yamlDataMods                `yaml:"Mods"`
+	Backups  yamlDataBackups             `yaml:"Backups"`
+	Events   map[string]yamlDataEvent    `yaml:"Events"`
+	Galaxies map[string]yamlDataGalaxy   `yaml:"Galaxies,omitempty"`
+	Schedule map[string]yamlDataSchedule `yaml:"Schedule,omitempty"`
 }
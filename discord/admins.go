@@ -0,0 +1,118 @@
+package discord
+
+import (
+	"avorioncontrol/discord/commands"
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// adminSyncInterval is how often the in-game admins are checked against Discord
+// to catch changes that don't raise an event, such as new integrations
+const adminSyncInterval = 5 * time.Minute
+
+// adminSyncDebounce is how long role changes are gathered before the admins are
+// synced, so that a burst of member updates only causes a single sync
+const adminSyncDebounce = 10 * time.Second
+
+// sameRoles returns whether or not two lists hold the same roles
+func sameRoles(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	roles := make(map[string]bool, len(a))
+	for _, r := range a {
+		roles[r] = true
+	}
+
+	for _, r := range b {
+		if !roles[r] {
+			return false
+		}
+	}
+	return true
+}
+
+// syncAdmins updates the in-game admins of every running server
+func (b *Bot) syncAdmins(s *discordgo.Session, servers []ifaces.IGameServer) {
+	if b.config.InGameAdminLevel() <= 0 {
+		return
+	}
+
+	isAdmin := commands.InGameAdminResolver(s, b.config)
+	for _, gs := range servers {
+		if !gs.IsUp() {
+			continue
+		}
+
+		if _, err := gs.SyncAdmins(isAdmin); err != nil {
+			logger.LogWarning(b, "Failed to sync admins of "+gs.Config().Galaxy()+
+				": "+err.Error())
+		}
+	}
+}
+
+// superviseAdmins keeps the in-game admins of the servers in line with the
+// Discord roles of the players that have linked their accounts
+func (b *Bot) superviseAdmins(s *discordgo.Session, servers []ifaces.IGameServer) {
+	// Role changes are applied shortly after they happen. Updates that don't
+	// change any roles, such as new nicknames, are ignored when the previous
+	// state of the member is known.
+	updates := make(chan struct{}, 1)
+	s.AddHandler(func(s *discordgo.Session, u *discordgo.GuildMemberUpdate) {
+		if u.Member == nil || (u.User != nil && u.User.Bot) {
+			return
+		}
+
+		if u.BeforeUpdate != nil && sameRoles(u.BeforeUpdate.Roles, u.Roles) {
+			return
+		}
+
+		select {
+		case updates <- struct{}{}:
+		default:
+		}
+	})
+
+	// Servers that come online get their admins as soon as they are ready
+	for _, gs := range servers {
+		go func(gs ifaces.IGameServer) {
+			states, unsubscribe := gs.SubscribeState()
+			defer unsubscribe()
+
+			for {
+				select {
+				case t := <-states:
+					if t.To == ifaces.ServerOnline {
+						b.syncAdmins(s, []ifaces.IGameServer{gs})
+					}
+				case <-b.exit:
+					return
+				}
+			}
+		}(gs)
+	}
+
+	ticker := time.NewTicker(adminSyncInterval)
+	defer ticker.Stop()
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-updates:
+			if debounce == nil {
+				debounce = time.After(adminSyncDebounce)
+			}
+		case <-debounce:
+			debounce = nil
+			b.syncAdmins(s, servers)
+		case <-ticker.C:
+			b.syncAdmins(s, servers)
+		case <-b.exit:
+			return
+		}
+	}
+}
@@ -9,6 +9,8 @@ package discord
 
 import (
 	"avorioncontrol/ifaces"
+	"bytes"
+	"context"
 	"fmt"
 	"log"
 	"regexp"
@@ -87,7 +89,7 @@ func New(c ifaces.IConfigurator, wg *sync.WaitGroup, exit chan struct{}) *Bot {
 /****************************/
 
 // Start initializes the discordgo backend
-func (b *Bot) Start(gs ifaces.IGameServer) {
+func (b *Bot) Start(servers []ifaces.IGameServer) {
 	logger.LogInit(b, "Initialized Discord bot")
 	dg, err := discordgo.New("Bot " + b.config.Token())
 	if err != nil {
@@ -129,17 +131,24 @@ func (b *Bot) Start(gs ifaces.IGameServer) {
 		colorcache: make(map[string]map[string]CachedColor, 0)}
 
 	for _, g := range dg.State.Guilds {
-		onGuildJoin(g.ID, dg, b, gs, cache)
+		onGuildJoin(g.ID, dg, b, servers, cache)
 	}
 
-	cache.UpdateCache(dg, gs)
+	cache.UpdateCache(dg, servers)
+
+	linked := commands.LinkedRoleResolver(dg, b.config)
+	for _, srv := range servers {
+		srv.SetAccessResolver(linked)
+	}
+
+	go b.superviseAdmins(dg, servers)
 
 	go func() {
 		for {
 			select {
 			case <-time.After(5 * time.Minute):
 				logger.LogDebug(b, "Starting cache update")
-				cache.UpdateCache(dg, gs)
+				cache.UpdateCache(dg, servers)
 			case <-b.exit:
 				return
 			}
@@ -150,9 +159,12 @@ func (b *Bot) Start(gs ifaces.IGameServer) {
 		v := regexp.MustCompile("^[0-9]+:[0-9]{10}$")
 		in := strings.TrimSpace(m.Content)
 		if v.MatchString(in) {
-			if gs.ValidateIntegrationPin(in, m.Author.ID) {
-				s.MessageReactionAdd(m.ChannelID, m.ID, "✅")
-				s.ChannelMessageSend(m.ID, "Thanks for validating!")
+			for _, gs := range servers {
+				if gs.ValidateIntegrationPin(in, m.Author.ID) {
+					s.MessageReactionAdd(m.ChannelID, m.ID, "✅")
+					s.ChannelMessageSend(m.ID, "Thanks for validating!")
+					return
+				}
 			}
 		}
 	}
@@ -173,7 +185,7 @@ func (b *Bot) Start(gs ifaces.IGameServer) {
 		}
 
 		if reg, err = commands.Registrar(m.GuildID); err != nil {
-			onGuildJoin(m.GuildID, dg, b, gs, cache)
+			onGuildJoin(m.GuildID, dg, b, servers, cache)
 			if reg, err = commands.Registrar(m.GuildID); err != nil {
 				log.Fatal(err)
 			}
@@ -245,7 +257,11 @@ func (b *Bot) Start(gs ifaces.IGameServer) {
 		}
 
 		// Send messages from Discord to the ifaces as the user if its available
-		if gs.IsUp() && b.config.ChatChannel() == m.ChannelID {
+		for _, gs := range servers {
+			if !gs.IsUp() || gs.Config().ChatChannel() != m.ChannelID {
+				continue
+			}
+
 			colorInt, _, colorShort := cache.GetColor(s, m.GuildID, m.Author.ID)
 			if colorInt == 0 {
 				colorShort = "default"
@@ -261,8 +277,9 @@ func (b *Bot) Start(gs ifaces.IGameServer) {
 			content := strings.ReplaceAll(m.Content, `"`, `“`)
 			logger.LogDebug(reg, "Processing: "+content)
 
-			_, err = gs.RunCommand(fmt.Sprintf(`discordsay "%s" "%s" "%s"`,
-				colorShort, author, content))
+			_, err = gs.RunCommandContext(context.Background(),
+				ifaces.CommandPriorityChat, fmt.Sprintf(`discordsay "%s" "%s" "%s"`,
+					colorShort, author, content))
 			if err != nil {
 				s.MessageReactionAdd(m.ChannelID, m.ID, "🚫")
 			} else {
@@ -277,8 +294,11 @@ func (b *Bot) Start(gs ifaces.IGameServer) {
 	go func() {
 		for {
 			time.Sleep(30 * time.Minute)
-			gs.RunCommand(fmt.Sprintf("setdiscorddata \"%s\" \"%s\"",
-				dg.State.User.String(), b.config.DiscordLink()))
+			for _, gs := range servers {
+				gs.RunCommandContext(context.Background(), ifaces.CommandPriorityBulk,
+					fmt.Sprintf("setdiscorddata \"%s\" \"%s\"",
+						dg.State.User.String(), b.config.DiscordLink()))
+			}
 		}
 	}()
 
@@ -330,7 +350,7 @@ func (b *Bot) updateServerStatus(guild string, s *discordgo.Session,
 	}
 
 	setupchan := func(stat ifaces.ServerStatus, clear bool) {
-		cid, ok = b.config.StatusChannel()
+		cid, ok = gs.Config().StatusChannel()
 		if ok {
 			logger.LogInit(b, "Setting up server status on channel: "+cid)
 
@@ -371,11 +391,9 @@ func (b *Bot) updateServerStatus(guild string, s *discordgo.Session,
 		}
 	}
 
-	// TODO: Once autostart configuration is in place, we should modify
-	//	this so that it *doesnt* change the status to initializing
-	logger.LogInit(b, "Starting server updater for "+guild)
+	logger.LogInit(b, fmt.Sprintf("Starting %s server updater for %s",
+		gs.Config().Galaxy(), guild))
 	laststatus = gs.Status()
-	laststatus.Status = ifaces.ServerStarting
 	setupchan(laststatus, b.config.StatusChannelClear())
 
 	// Make sure that we change the embed to state that the server is
@@ -386,6 +404,10 @@ func (b *Bot) updateServerStatus(guild string, s *discordgo.Session,
 		updatechan(laststatus)
 	}()
 
+	// Update right away when the server changes state
+	states, unsubscribe := gs.SubscribeState()
+	defer unsubscribe()
+
 	for {
 		select {
 		case <-b.exit:
@@ -396,23 +418,25 @@ func (b *Bot) updateServerStatus(guild string, s *discordgo.Session,
 			}
 			return
 
+		case <-states:
 		case <-time.After(time.Second * 5):
-			// No point in continuing if the server status hasn't changed
-			if stat := gs.Status(); gs.CompareStatus(stat, laststatus) {
-				continue
-			} else {
-				logger.LogInfo(b, "Server status updated")
-				laststatus = stat
-			}
+		}
 
-			if cid, ok := b.config.StatusChannel(); ok {
-				if lastcid != cid {
-					setupchan(gs.Status(), b.config.StatusChannelClear())
-					continue
-				}
+		// No point in continuing if the server status hasn't changed
+		if stat := gs.Status(); gs.CompareStatus(stat, laststatus) {
+			continue
+		} else {
+			logger.LogInfo(b, "Server status updated")
+			laststatus = stat
+		}
 
-				updatechan(laststatus)
+		if cid, ok := gs.Config().StatusChannel(); ok {
+			if lastcid != cid {
+				setupchan(gs.Status(), b.config.StatusChannelClear())
+				continue
 			}
+
+			updatechan(laststatus)
 		}
 	}
 }
@@ -422,107 +446,141 @@ func (b *Bot) updateServerStatus(guild string, s *discordgo.Session,
 /*********/
 
 // onGuildJoin handler
-func onGuildJoin(gid string, s *discordgo.Session, b *Bot, gs ifaces.IGameServer,
-	cache *DataCache) {
-	reg := commands.NewRegistrar(gid, gs)
+func onGuildJoin(gid string, s *discordgo.Session, b *Bot,
+	servers []ifaces.IGameServer, cache *DataCache) {
+	reg := commands.NewRegistrar(gid, servers)
 	reg.SetLoglevel(b.Loglevel())
 	commands.InitializeCommandRegistry(reg)
 	cache.AddGuild(gid)
 
-	go func() {
-		logger.LogInit(b, "Started bot chat supervisor")
-		b.wg.Add(1)
+	for _, gs := range servers {
+		go b.superviseChat(reg, s, gs.Config())
+		go b.updateServerStatus(gid, s, gs)
+	}
 
-		defer func() {
-			b.wg.Done()
-			logger.LogInfo(b, "Stopped bot chat supervisor")
-		}()
+	logger.LogDebug(reg, "Initialized new command registrar")
+}
 
-		for {
-			select {
-			case lm := <-b.config.LogPipe():
-				logger.LogDebug(b, "Processing chat data from server for logging")
-				if b.config.ChatChannel() != "" {
-					// Don't bother with empty messages
-					if len(lm.Msg) == 0 {
-						continue
-					}
+// superviseChat relays the chat and logged events of a single galaxy to their
+// configured Discord channels
+func (b *Bot) superviseChat(reg *commands.CommandRegistrar, s *discordgo.Session,
+	cfg ifaces.IConfigurator) {
+	logger.LogInit(b, "Started bot chat supervisor for "+cfg.Galaxy())
+	b.wg.Add(1)
 
-					// Default to Avorion
-					if lm.Name == "" {
-						lm.Name = "Avorion"
-					}
+	defer func() {
+		b.wg.Done()
+		logger.LogInfo(b, "Stopped bot chat supervisor for "+cfg.Galaxy())
+	}()
 
-					// Truncate messages larger than 1900 to make sure we have enough room
-					//	for the rest of the message
-					msg := string(lm.Msg)
-					if len(msg) > 1900 {
-						msg = msg[0:1900]
-						msg += "...(truncated)"
-					}
+	for {
+		select {
+		case lm := <-cfg.LogPipe():
+			logger.LogDebug(b, "Processing chat data from server for logging")
+			channel := cfg.LogChannel()
+			if lm.Channel != "" {
+				channel = lm.Channel
+			}
 
-					// Prevent mentions from in-game
-					msg = strings.ReplaceAll(msg, "@everyone", "everyone")
-					msg = strings.ReplaceAll(msg, "@here", "here")
+			if channel != "" {
+				// Don't bother with empty messages
+				if len(lm.Msg) == 0 {
+					continue
+				}
 
-					embed := &discordgo.MessageEmbed{
-						Title:       "Game Event Logged",
-						Description: msg}
+				// Default to Avorion
+				if lm.Name == "" {
+					lm.Name = "Avorion"
+				}
 
-					s.ChannelMessageSendEmbed(b.config.LogChannel(), embed)
+				// Truncate messages larger than 1900 to make sure we have enough room
+				//	for the rest of the message
+				msg := string(lm.Msg)
+				if len(msg) > 1900 {
+					msg = msg[0:1900]
+					msg += "...(truncated)"
 				}
 
-			case cm := <-b.config.ChatPipe():
-				logger.LogDebug(b, "Processing chat data from server")
-				if b.config.ChatChannel() != "" {
-					// Don't bother with empty messages
-					if len(cm.Msg) == 0 {
-						continue
-					}
+				// Prevent mentions from in-game
+				msg = strings.ReplaceAll(msg, "@everyone", "everyone")
+				msg = strings.ReplaceAll(msg, "@here", "here")
 
-					// Default to Avorion
-					if cm.Name == "" {
-						cm.Name = "Avorion"
-					}
+				embed := &discordgo.MessageEmbed{
+					Title:       "Game Event Logged",
+					Description: msg}
 
-					// Truncate messages larger than 1900 to make sure we have enough room
-					//	for the rest of the message
-					msg := string(cm.Msg)
-					if len(msg) > 1900 {
-						msg = msg[0:1900]
-						msg += "...(truncated)"
-					}
+				if len(lm.Files) == 0 && lm.MentionRole == "" {
+					s.ChannelMessageSendEmbed(channel, embed)
+					continue
+				}
 
-					// Prevent mentions from in-game
-					msg = strings.ReplaceAll(msg, "@everyone", "everyone")
-					msg = strings.ReplaceAll(msg, "@here", "here")
-
-					if reCatchMention.MatchString(msg) {
-						logger.LogDebug(reg, "Found mention in chat string")
-						m := reCatchMention.FindStringSubmatch(msg)
-						for _, caught := range m {
-							logger.LogWarning(reg, "Player attempted to mention: "+caught)
-							msg = strings.ReplaceAll(msg, caught, "`(mention blocked)`")
-						}
-					}
+				send := &discordgo.MessageSend{Embed: embed}
+				for _, f := range lm.Files {
+					send.Files = append(send.Files, &discordgo.File{
+						Name:        f.Name,
+						ContentType: "text/plain",
+						Reader:      bytes.NewReader(f.Content)})
+				}
 
-					if cm.UID != "" {
-						msg = fmt.Sprintf("<@%s>: %s", cm.UID, msg)
-					} else {
-						msg = fmt.Sprintf("▫️ **%s**: %s", cm.Name, msg)
-					}
+				// Mentions in embeds don't notify anyone, so the role goes in the
+				// content, and is the only mention that is allowed
+				if lm.MentionRole != "" {
+					send.Content = "<@&" + lm.MentionRole + ">"
+					send.AllowedMentions = &discordgo.MessageAllowedMentions{
+						Roles: []string{lm.MentionRole}}
+				}
 
-					s.ChannelMessageSend(b.config.ChatChannel(), msg)
+				if _, err := s.ChannelMessageSendComplex(channel, send); err != nil {
+					logger.LogError(b, "Failed to send logged event: "+err.Error())
 				}
-			case <-b.exit:
-				return
-			default:
-				time.Sleep(time.Second)
 			}
-		}
-	}()
 
-	go b.updateServerStatus(gid, s, gs)
+		case cm := <-cfg.ChatPipe():
+			logger.LogDebug(b, "Processing chat data from server")
+			if cfg.ChatChannel() != "" {
+				// Don't bother with empty messages
+				if len(cm.Msg) == 0 {
+					continue
+				}
 
-	logger.LogDebug(reg, "Initialized new command registrar")
+				// Default to Avorion
+				if cm.Name == "" {
+					cm.Name = "Avorion"
+				}
+
+				// Truncate messages larger than 1900 to make sure we have enough room
+				//	for the rest of the message
+				msg := string(cm.Msg)
+				if len(msg) > 1900 {
+					msg = msg[0:1900]
+					msg += "...(truncated)"
+				}
+
+				// Prevent mentions from in-game
+				msg = strings.ReplaceAll(msg, "@everyone", "everyone")
+				msg = strings.ReplaceAll(msg, "@here", "here")
+
+				if reCatchMention.MatchString(msg) {
+					logger.LogDebug(reg, "Found mention in chat string")
+					m := reCatchMention.FindStringSubmatch(msg)
+					for _, caught := range m {
+						logger.LogWarning(reg, "Player attempted to mention: "+caught)
+						msg = strings.ReplaceAll(msg, caught, "`(mention blocked)`")
+					}
+				}
+
+				if cm.UID != "" {
+					msg = fmt.Sprintf("<@%s>: %s", cm.UID, msg)
+				} else {
+					msg = fmt.Sprintf("▫️ **%s**: %s", cm.Name, msg)
+				}
+
+				s.ChannelMessageSend(cfg.ChatChannel(), msg)
+			}
+		case <-b.exit:
+			return
+		default:
+			time.Sleep(time.Second)
+		}
+	}
 }
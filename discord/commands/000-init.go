@@ -65,44 +65,50 @@ func InitializeCommandRegistry(r *CommandRegistrar) {
 	// ifaces.Server (Avorion)
 	r.Register("rcon",
 		"Run a command in Avorion and return its result",
-		"rcon <command> ...",
+		"rcon (galaxy) <command> ...",
 		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
 			arg("command", "Name of the command to run"),
 			arg("...", "The commands arguments")},
 		rconCmnd)
 
 	r.Register("status",
 		"Get the current server status",
-		"status",
-		make([]CommandArgument, 0),
+		"status (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
 		statusCmnd)
 
 	r.Register("getjumps",
 		"Get the last n jumps for a player or alliance",
-		"getjumps <number> <name>",
+		"getjumps (galaxy) <number> <name>",
 		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
 			arg("number", "Number of jumps to list (250 max)"),
 			arg("name", "Player or Alliance name")},
 		getJumpsCmnd)
 
 	r.Register("getcoordhistory",
 		"Get all of the logged jumps made to a sector",
-		"getcoordhistory <x:y> <x:y> ...",
+		"getcoordhistory (galaxy) <x:y> <x:y> ...",
 		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
 			arg("x", "x coordinate for a Sector"),
 			arg("y", "y coordinate for a sector")},
 		getCoordHistoryCmnd)
 
 	r.Register("getplayers",
 		"List the tracked players",
-		"getplayers",
-		make([]CommandArgument, 0),
+		"getplayers (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
 		getPlayersCmnd)
 
 	r.Register("getalliances",
 		"List the tracked alliances",
-		"getalliances",
-		make([]CommandArgument, 0),
+		"getalliances (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
 		getAlliancesCmnd)
 
 	r.Register("reload",
@@ -113,22 +119,25 @@ func InitializeCommandRegistry(r *CommandRegistrar) {
 
 	r.Register("setchatchannel",
 		"Sets the channel to output server chat into",
-		"setchatchannel channelid",
+		"setchatchannel (galaxy) channelid",
 		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
 			arg("channelid", "UID of the channel to send server chat messages to")},
 		setChatChannelCmnd)
 
 	r.Register("setlogchannel",
 		"Sets the channel to output logged server events to",
-		"setlogchannel channelid",
+		"setlogchannel (galaxy) channelid",
 		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
 			arg("channelid", "UID of the channel to send logged events to ")},
 		setLogChannelCmnd)
 
 	r.Register("setstatuschannel",
 		"Sets the channel in which the server will update it's status embed",
-		"setstatuschannel channelid",
+		"setstatuschannel (galaxy) channelid",
 		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
 			arg("channelid", "UID of the channel to send server chat messages to")},
 		setStatusChannelCmnd)
 
@@ -146,19 +155,274 @@ func InitializeCommandRegistry(r *CommandRegistrar) {
 		proxySubCmnd)
 	r.Register("stop",
 		"Stop the Avorion server (if its up)",
-		"stop",
-		make([]CommandArgument, 0),
+		"stop (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
 		stopServerCmnd, "server")
 	r.Register("start",
 		"Start the Avorion server (if its down)",
-		"start",
-		make([]CommandArgument, 0),
+		"start (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
 		startServerCmnd, "server")
 	r.Register("restart",
 		"Restart the Avorion server",
-		"restart",
-		make([]CommandArgument, 0),
+		"restart (galaxy) (--when-empty (minutes)|--cancel)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
+			arg("--when-empty", "Wait until the server is empty, or the given number of minutes have passed"),
+			arg("--cancel", "Cancel a pending restart")},
 		restartServerCmnd, "server")
+	r.Register("recover",
+		"Clear a crash loop lockout and start the server",
+		"recover (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
+		recoverServerCmnd, "server")
+	r.Register("queue",
+		"Show the state of the RCON command queue",
+		"queue (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
+		queueServerCmnd, "server")
+	r.Register("preflight",
+		"Check that everything the server needs to start is in place",
+		"preflight (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
+		preflightServerCmnd, "server")
+	r.Register("history",
+		"Show the recent starts, stops and crashes of the server",
+		"history (galaxy) (count)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
+			arg("count", "Number of state changes to show (default: 15)")},
+		historyServerCmnd, "server")
+	r.Register("heartbeat",
+		"Show the heartbeats of the game loop, and how its tick lag is trending",
+		"heartbeat (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
+		heartbeatServerCmnd, "server")
+	r.Register("resources",
+		"Show the CPU, memory, thread and file usage of the Avorion process",
+		"resources (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
+		resourcesServerCmnd, "server")
+	r.Register("lock",
+		"Require a password to join the server",
+		"lock (galaxy) <password> (--restart)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
+			arg("password", "Password that players need to join"),
+			arg("--restart", "Restart the server once it is empty to apply the password")},
+		lockServerCmnd, "server")
+	r.Register("unlock",
+		"Remove the password that is needed to join the server",
+		"unlock (galaxy) (--restart)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
+			arg("--restart", "Restart the server once it is empty to remove the password")},
+		unlockServerCmnd, "server")
+	r.Register("schedule",
+		"List, skip or postpone the scheduled restarts, stops and saves",
+		"schedule (galaxy) [list|skip|postpone] (task) (minutes)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
+			arg("list", "List the scheduled tasks and their next run (default)"),
+			arg("skip task", "Skip the next run of a task"),
+			arg("postpone task minutes", "Delay the next run of a task")},
+		scheduleServerCmnd, "server")
+	r.Register("list",
+		"List the galaxies that are being managed, and their state",
+		"list",
+		make([]CommandArgument, 0),
+		listServersCmnd, "server")
+
+	r.Register("backup",
+		"Manage galaxy backups",
+		"backup <subcommand>",
+		make([]CommandArgument, 0),
+		proxySubCmnd)
+	r.Register("list",
+		"List the backups of a galaxy, newest first",
+		"list (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
+		listBackupCmnd, "backup")
+	r.Register("create",
+		"Save the galaxy and create a new backup of it",
+		"create (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
+		createBackupCmnd, "backup")
+	r.Register("restore",
+		"Stop the server, replace the galaxy with a backup and start it again",
+		"restore (galaxy) <backup>",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
+			arg("backup", "Name of the backup to restore, as shown by `backup list`")},
+		restoreBackupCmnd, "backup")
+
+	r.Register("galaxy",
+		"Manage the galaxy of a server",
+		"galaxy <subcommand>",
+		make([]CommandArgument, 0),
+		proxySubCmnd)
+	r.Register("new",
+		"Archive the galaxy and its tracking DB, and replace it with a new one",
+		"new (galaxy) <name> (seed)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
+			arg("name", "Name of the new galaxy"),
+			arg("seed", "Seed of the new galaxy (defaults to a random seed)")},
+		newGalaxyCmnd, "galaxy")
+
+	r.Register("motd",
+		"Manage the rotating messages of the day shown to players on login",
+		"motd <subcommand>",
+		make([]CommandArgument, 0),
+		proxySubCmnd)
+	r.Register("list",
+		"List the messages of the day",
+		"list (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
+		listMOTDCmnd, "motd")
+	r.Register("add",
+		"Add a message of the day to the rotation",
+		"add (galaxy) <message>",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
+			arg("message", "Message to show to players when they log in")},
+		addMOTDCmnd, "motd")
+	r.Register("remove",
+		"Remove a message of the day from the rotation",
+		"remove (galaxy) <number>",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
+			arg("number", "Number of the message, as shown by `motd list`")},
+		removeMOTDCmnd, "motd")
+	r.Register("next",
+		"Switch to the next message of the day now",
+		"next (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
+		nextMOTDCmnd, "motd")
+
+	r.Register("access",
+		"Manage which players are allowed to join regardless of linked-only mode",
+		"access <subcommand>",
+		make([]CommandArgument, 0),
+		proxySubCmnd)
+	r.Register("list",
+		"List the players that are allowed or denied",
+		"list (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
+		listAccessCmnd, "access")
+	r.Register("allow",
+		"Allow a player to join without a linked Discord account",
+		"allow (galaxy) <player>",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
+			arg("player", "Index, name or Steam64 ID of the player")},
+		allowAccessCmnd, "access")
+	r.Register("deny",
+		"Keep a player from joining, kicking them if they are online",
+		"deny (galaxy) <player>",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
+			arg("player", "Index, name or Steam64 ID of the player")},
+		denyAccessCmnd, "access")
+	r.Register("remove",
+		"Remove a player from the access lists",
+		"remove (galaxy) <player>",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
+			arg("player", "Index, name or Steam64 ID of the player")},
+		removeAccessCmnd, "access")
+
+	r.Register("events",
+		"Inspect the events that are parsed from the output of Avorion",
+		"events <subcommand>",
+		make([]CommandArgument, 0),
+		proxySubCmnd)
+	r.Register("stats",
+		"Show how often each event matched, and how its subscribers are keeping up",
+		"stats (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
+		eventStatsCmnd, "events")
+	r.Register("search",
+		"Search the events that were recorded in the tracking DB",
+		"search (galaxy) [type:<types>] [player:<player>] [since:<time>] "+
+			"[until:<time>] [limit:<count>]",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
+			arg("type", "Comma separated event types, such as PlayerJoin,PlayerLeft,"+
+				"PlayerKick,PlayerBan,Chat or the name of a configured event"),
+			arg("player", "Name, index or Discord mention of the player involved"),
+			arg("since", "Only show events after a duration ago (90m, 12h, 3d) or "+
+				"a time (2006-01-02 15:04)"),
+			arg("until", "Only show events before a duration ago or a time"),
+			arg("limit", sprintf("Number of events to show (default %d, at most %d)",
+				defaultEventSearchLimit, maxEventSearchLimit))},
+		eventSearchCmnd, "events")
+	r.Register("list",
+		"List the registered events in the order that lines are matched against them",
+		"list (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
+		eventListCmnd, "events")
+	r.Register("test",
+		"Show which event a line of output would match, and what it would capture",
+		"test (galaxy) <line>",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
+			arg("line", "A line of Avorion output, optionally wrapped in backticks")},
+		eventTestCmnd, "events")
+
+	r.Register("gameconfig",
+		"View and change the server.ini of a galaxy",
+		"gameconfig <subcommand>",
+		make([]CommandArgument, 0),
+		proxySubCmnd)
+	r.Register("get",
+		"Show the known server.ini settings, or a single one",
+		"get (galaxy) (setting)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
+			arg("setting", "Setting to show, as `Section.Key` or just `Key`")},
+		getGameConfigCmnd, "gameconfig")
+	r.Register("set",
+		"Stage a change to a server.ini setting",
+		"set (galaxy) <setting> <value>",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
+			arg("setting", "Setting to change, as `Section.Key` or just `Key`"),
+			arg("value", "New value of the setting")},
+		setGameConfigCmnd, "gameconfig")
+	r.Register("diff",
+		"Show the staged server.ini changes",
+		"diff (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
+		diffGameConfigCmnd, "gameconfig")
+	r.Register("discard",
+		"Drop the staged server.ini changes",
+		"discard (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
+		discardGameConfigCmnd, "gameconfig")
+	r.Register("apply",
+		"Write the staged changes to server.ini, or queue them for the next start",
+		"apply (galaxy) (--restart)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
+			arg("--restart", "Restart the server once it is empty to load the changes")},
+		applyGameConfigCmnd, "gameconfig")
 
 	r.Register("admin",
 		"Configure admin level privileges",
@@ -175,6 +439,12 @@ func InitializeCommandRegistry(r *CommandRegistrar) {
 		"commands",
 		make([]CommandArgument, 0),
 		showAdminCmndsSubCmnd, "admin")
+	r.Register("sync",
+		"Update the in-game admins from the roles of linked Discord accounts",
+		"sync (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
+		syncAdminsSubCmnd, "admin")
 	r.Register("addrole",
 		"Add a set level of authorization to a role",
 		"addrole <role> <level>",
@@ -209,7 +479,7 @@ func InitializeCommandRegistry(r *CommandRegistrar) {
 
 	r.Register("mod",
 		"Configure mods installed on the Avorion server",
-		"mod <add|remove|list>",
+		"mod <add|remove|list|updates>",
 		make([]CommandArgument, 0),
 		proxySubCmnd)
 	r.Register("add",
@@ -241,6 +511,12 @@ func InitializeCommandRegistry(r *CommandRegistrar) {
 		"list",
 		make([]CommandArgument, 0),
 		listModsSubCmnd, "mod")
+	r.Register("updates",
+		"Check the installed mods for updates, restarting the server to load them",
+		"updates (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
+		modUpdatesSubCmnd, "mod")
 
 	r.Register("modlist",
 		"List the workshop mods that are currently configured to be installed",
@@ -249,13 +525,14 @@ func InitializeCommandRegistry(r *CommandRegistrar) {
 		listModsSubCmnd)
 	r.Register("showuserintegrations",
 		"Show the users that have integrated Discord and their in-game player",
-		"showuserintegrations",
-		make([]CommandArgument, 0),
+		"showuserintegrations (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
 		getIntegratedCmnd)
 
 	r.Register("broadcast",
 		"Send all players an email, with an attachment used as the message body",
-		"broadcast <email subject header>",
+		"broadcast (galaxy) <email subject header>",
 		make([]CommandArgument, 0),
 		sendBroadcastCmnd)
 
@@ -266,26 +543,30 @@ func InitializeCommandRegistry(r *CommandRegistrar) {
 		proxySubCmnd)
 	r.Register("kick",
 		"Kick the given player",
-		"kick <player index>",
+		"kick (galaxy) <player index>",
 		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
 			arg("player index", "Valid player index")},
 		playerKickCmnd, "player")
 	r.Register("ban",
 		"Ban the given player",
-		"ban <player index>",
+		"ban (galaxy) <player index>",
 		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)"),
 			arg("player index", "Valid player index")},
 		playerBanCmnd, "player")
 
 	r.Register("showonline",
 		"Show the players that are currently online",
-		"showonline",
-		make([]CommandArgument, 0),
+		"showonline (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
 		showOnlinePlayersCmnd)
 
 	r.Register("checkhang",
 		"Checks whether or not the server is online and restarts it if it is hanging",
-		"checkhang",
-		make([]CommandArgument, 0),
+		"checkhang (galaxy)",
+		[]CommandArgument{
+			arg("galaxy", "Name of the galaxy to target (defaults to the primary galaxy)")},
 		checkHangCmnd)
 }
@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func listAccessCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Access Lists")
+		srv, _ = cmd.Registrar().Server(a, 2)
+	)
+
+	entries, err := srv.AccessList()
+	if err != nil {
+		return nil, &ErrCommandError{message: err.Error(), cmd: cmd}
+	}
+
+	out.Description = srv.Config().Galaxy()
+	if linked, role := c.LinkedOnly(); linked {
+		if role != "" {
+			out.Description += sprintf(" (linked-only, requires <@&%s>)", role)
+		} else {
+			out.Description += " (linked-only)"
+		}
+	}
+	out.Quoted = true
+
+	if len(entries) == 0 {
+		out.AddLine("There are no players on the access lists")
+	}
+
+	for _, e := range entries {
+		state := "denied"
+		if e.Allowed {
+			state = "allowed"
+		}
+		out.AddLine(sprintf("**%s** (%d): %s", e.Name, e.SteamID, state))
+	}
+
+	out.Construct()
+	return out, nil
+}
+
+// setAccess allows or denies the player named by the command arguments
+func setAccess(m *discordgo.MessageCreate, a BotArgs, cmd *CommandRegistrant,
+	allowed bool) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Access Lists")
+		srv, b = cmd.Registrar().Server(a, 2)
+	)
+
+	if !HasNumArgs(b[1:], 1, 1) {
+		return nil, &ErrInvalidArgument{
+			message: sprintf("`%s` was passed the wrong number of arguments", cmd.Name()),
+			cmd:     cmd}
+	}
+
+	sid, name, err := srv.ResolveSteamID(b[2])
+	if err != nil {
+		return nil, &ErrInvalidArgument{message: err.Error(), cmd: cmd}
+	}
+
+	e := ifaces.AccessEntry{SteamID: sid, Name: name, Allowed: allowed}
+	if err := srv.SetAccess(e); err != nil {
+		return nil, &ErrCommandError{message: err.Error(), cmd: cmd}
+	}
+
+	state := "denied"
+	if allowed {
+		state = "allowed"
+	}
+	logger.LogInfo(cmd, sprintf("%s %s %s (%d) on %s", m.Author.String(), state,
+		name, sid, srv.Config().Galaxy()))
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+	out.AddLine(sprintf("**%s** (%d) is now %s", name, sid, state))
+	out.Construct()
+	return out, nil
+}
+
+func allowAccessCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	return setAccess(m, a, cmd, true)
+}
+
+func denyAccessCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	return setAccess(m, a, cmd, false)
+}
+
+func removeAccessCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Access Lists")
+		srv, b = cmd.Registrar().Server(a, 2)
+	)
+
+	if !HasNumArgs(b[1:], 1, 1) {
+		return nil, &ErrInvalidArgument{
+			message: sprintf("`%s` was passed the wrong number of arguments", cmd.Name()),
+			cmd:     cmd}
+	}
+
+	// Players that were removed from the game can still be on the lists, so a
+	// raw Steam ID is accepted as is
+	sid, err := strconv.ParseInt(b[2], 10, 64)
+	if err != nil || len(b[2]) < 15 {
+		if sid, _, err = srv.ResolveSteamID(b[2]); err != nil {
+			return nil, &ErrInvalidArgument{message: err.Error(), cmd: cmd}
+		}
+	}
+
+	ok, err := srv.ClearAccess(sid)
+	if err != nil {
+		return nil, &ErrCommandError{message: err.Error(), cmd: cmd}
+	}
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+	if ok {
+		out.AddLine(sprintf("Removed %d from the access lists", sid))
+	} else {
+		out.AddLine(sprintf("%d is not on the access lists", sid))
+	}
+
+	out.Construct()
+	return out, nil
+}
@@ -243,3 +243,42 @@ func showSelfCmndSubCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a Bot
 	out.Construct()
 	return out, nil
 }
+
+func syncAdminsSubCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "In-Game Admins")
+		srv, _ = cmd.Registrar().Server(a, 2)
+	)
+
+	if c.InGameAdminLevel() <= 0 {
+		return nil, &ErrCommandError{
+			message: "In-game admins are not managed (set `ingame_admin_level`)",
+			cmd:     cmd}
+	}
+
+	changes, err := srv.SyncAdmins(InGameAdminResolver(s, c))
+	if err != nil {
+		return nil, &ErrCommandError{
+			message: "Failed to update admins: " + err.Error(),
+			cmd:     cmd}
+	}
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+
+	if len(changes) == 0 {
+		out.AddLine("The admin list is already up to date")
+	}
+
+	for _, change := range changes {
+		verb := "Removed"
+		if change.Added {
+			verb = "Added"
+		}
+		out.AddLine(sprintf("%s **%s**", verb, change.Name))
+	}
+
+	out.Construct()
+	return out, nil
+}
@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+)
+
+func listBackupCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Galaxy Backups")
+		srv, _ = cmd.Registrar().Server(a, 2)
+	)
+
+	backups, err := srv.Backups()
+	if err != nil {
+		return nil, &ErrCommandError{
+			message: "Failed to list backups: " + err.Error(),
+			cmd:     cmd}
+	}
+
+	tz, err := time.LoadLocation(c.TimeZone())
+	if err != nil {
+		tz = time.Local
+	}
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+	if len(backups) == 0 {
+		out.AddLine("There are no backups")
+	}
+
+	for _, b := range backups {
+		sum := "_no checksum_"
+		if len(b.Checksum) >= 12 {
+			sum = "`" + b.Checksum[:12] + "`"
+		}
+
+		out.AddLine(sprintf("**%s** (%s, %s) %s", b.Name,
+			b.Time.In(tz).Format(scheduleTimeFormat), humanize.Bytes(uint64(b.Size)),
+			sum))
+	}
+
+	out.Construct()
+	return out, nil
+}
+
+func createBackupCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Galaxy Backups")
+		srv, _ = cmd.Registrar().Server(a, 2)
+	)
+
+	b, err := srv.CreateBackup()
+	if err != nil {
+		logger.LogError(cmd, "Backup: "+err.Error())
+		return nil, &ErrCommandError{
+			message: "Failed to create backup: " + err.Error(),
+			cmd:     cmd}
+	}
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+	out.AddLine(sprintf("Created backup **%s** (%s)", b.Name,
+		humanize.Bytes(uint64(b.Size))))
+	out.AddLine(sprintf("SHA256: `%s`", b.Checksum))
+	out.Construct()
+	return out, nil
+}
+
+func restoreBackupCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Galaxy Backups")
+		srv, b = cmd.Registrar().Server(a, 2)
+	)
+
+	// Account for the fact that this is a subcommand by passing the HasNumArgs
+	//	function a slice of the args removing the first argument
+	if !HasNumArgs(b[1:], 1, 1) {
+		return nil, &ErrInvalidArgument{
+			message: sprintf("`%s` was passed the wrong number of arguments", cmd.Name()),
+			cmd:     cmd}
+	}
+
+	s.ChannelMessageSend(m.ChannelID, sprintf("Restoring `%s`, the server will "+
+		"be restarted if it is running", b[2]))
+
+	if err := srv.RestoreBackup(b[2]); err != nil {
+		logger.LogError(cmd, "Backup: "+err.Error())
+		return nil, &ErrCommandError{
+			message: "Failed to restore backup: " + err.Error(),
+			cmd:     cmd}
+	}
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+	out.AddLine(sprintf("Restored galaxy from **%s**", b[2]))
+	out.Construct()
+	return out, nil
+}
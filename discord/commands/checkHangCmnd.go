@@ -2,6 +2,7 @@ package commands
 
 import (
 	"avorioncontrol/ifaces"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -11,9 +12,9 @@ var checkingState = false
 func checkHangCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
 	var (
-		reg = cmd.Registrar()
-		srv = reg.server
-		out = newCommandOutput(cmd, "Server Hang Check")
+		reg    = cmd.Registrar()
+		srv, _ = reg.Server(a, 1)
+		out    = newCommandOutput(cmd, "Server Hang Check")
 
 		state = srv.Status().Status
 	)
@@ -52,16 +53,22 @@ func checkHangCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 		s.ChannelMessageSend(m.ChannelID, "Checking server state "+
 			"(if its hanging this will take some time)")
 
-		// players should always return output, so we use that as a secondary check
-		// TODO: Might not be a bad idea to add a (very) simple syn/ack command to
-		// the game for this purpose
-		_, err := srv.RunCommand(`echo Server status check`)
+		// The game loop sends heartbeats, so a missing one means it is hanging
+		err := srv.CheckHang()
 		if err != nil && err.Error() != "Server is not online" {
 			go func() { srv.Restart(); checkingState = false }()
 			srv.Crashed()
+			out.AddLine(err.Error())
 			out.AddLine("Server is hanging or is down, starting restart process")
 		} else {
+			checkingState = false
 			out.AddLine("Server is online")
+			if beats := srv.Heartbeats(); len(beats) > 0 {
+				last := beats[len(beats)-1]
+				out.AddLine(sprintf("Last heartbeat: %s ago (tick lag: %s)",
+					time.Since(last.Time).Round(time.Second),
+					last.Lag().Round(time.Millisecond)))
+			}
 		}
 	} else {
 		out.AddLine("Server is currently offline")
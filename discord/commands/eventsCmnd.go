@@ -0,0 +1,298 @@
+package commands
+
+import (
+	"avorioncontrol/ifaces"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	defaultEventSearchLimit = 25
+	maxEventSearchLimit     = 250
+)
+
+// eventTimeLayouts are the formats that absolute times can be given in when
+// searching for events
+var eventTimeLayouts = []string{
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02"}
+
+func eventStatsCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out          = newCommandOutput(cmd, "Event Statistics")
+		srv, b       = cmd.Registrar().Server(a, 2)
+		stats, subs  = srv.EventStats()
+		tried, found uint64
+		total        time.Duration
+	)
+
+	if !HasNumArgs(b[1:], 0, 0) {
+		return nil, &ErrInvalidArgument{
+			message: sprintf("`%s` was passed the wrong number of arguments", cmd.Name()),
+			cmd:     cmd}
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].MatchTime > stats[j].MatchTime
+	})
+
+	out.Description = srv.Config().Galaxy()
+	out.AddLine("**Events** _(shared by every galaxy)_")
+	for _, st := range stats {
+		tried += st.Candidates
+		found += st.Matches
+		total += st.MatchTime
+		if st.Candidates == 0 {
+			continue
+		}
+
+		out.AddLine(sprintf("> `%s`: %d matches in %d tries (%s)", st.Name,
+			st.Matches, st.Candidates, st.MatchTime.Round(time.Microsecond)))
+	}
+	out.AddLine(sprintf("> _%d matches in %d tries, %s spent matching_", found,
+		tried, total.Round(time.Microsecond)))
+
+	out.AddLine("**Subscribers**")
+	for _, sub := range subs {
+		out.AddLine(sprintf("> `%s`: %d delivered, %d queued, %d dropped",
+			sub.Name, sub.Delivered, sub.Queued, sub.Dropped))
+	}
+
+	out.Construct()
+	return out, nil
+}
+
+// parseEventTime parses a time that is either a duration before now, such as
+// 90m, 12h or 3d, or an absolute time in the configured timezone
+func parseEventTime(v string, loc *time.Location) (time.Time, bool) {
+	if strings.HasSuffix(v, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(v, "d")); err == nil &&
+			days >= 0 {
+			return time.Now().AddDate(0, 0, -days), true
+		}
+	}
+
+	if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+		return time.Now().Add(-d), true
+	}
+
+	for _, layout := range eventTimeLayouts {
+		if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// eventSearchFilters splits the arguments of a search into their filters.
+// Arguments that don't start with a filter continue the value of the previous
+// one, so that names and times can contain spaces.
+func eventSearchFilters(args []string) (map[string]string, string) {
+	var (
+		key     string
+		filters = make(map[string]string)
+	)
+
+	for _, v := range args {
+		if i := strings.Index(v, ":"); i > 0 {
+			switch k := strings.ToLower(v[:i]); k {
+			case "type", "player", "since", "until", "limit":
+				key = k
+				filters[key] = v[i+1:]
+				continue
+			}
+		}
+
+		if key == "" {
+			return nil, v
+		}
+		filters[key] = strings.TrimSpace(filters[key] + " " + v)
+	}
+
+	return filters, ""
+}
+
+func eventSearchCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Event Search")
+		srv, b = cmd.Registrar().Server(a, 2)
+		q      = ifaces.GameEventQuery{Limit: defaultEventSearchLimit}
+	)
+
+	loc, err := time.LoadLocation(c.TimeZone())
+	if err != nil {
+		return nil, &ErrInvalidTimezone{
+			tz:  c.TimeZone(),
+			cmd: cmd}
+	}
+
+	filters, bad := eventSearchFilters(b[2:])
+	if bad != "" {
+		return nil, &ErrInvalidArgument{
+			message: sprintf("`%s` is not a valid filter", bad),
+			cmd:     cmd}
+	}
+
+	for key, v := range filters {
+		switch key {
+		case "type":
+			q.Types = strings.Split(v, ",")
+
+		case "player":
+			q.Name = v
+			p := srv.PlayerFromName(v)
+			if p == nil {
+				p = srv.PlayerFromDiscord(strings.Trim(v, "<@!>"))
+			}
+			if p == nil {
+				p = srv.Player(v)
+			}
+			if p != nil {
+				q.Faction, _ = strconv.ParseInt(p.Index(), 10, 64)
+				q.Name = p.Name()
+			}
+
+		case "since", "until":
+			t, ok := parseEventTime(v, loc)
+			if !ok {
+				return nil, &ErrInvalidArgument{
+					message: sprintf("`%s` is not a valid time or duration", v),
+					cmd:     cmd}
+			}
+
+			if key == "since" {
+				q.Since = t
+			} else {
+				q.Until = t
+			}
+
+		case "limit":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return nil, &ErrInvalidArgument{
+					message: sprintf("`%s` is not a valid limit", v),
+					cmd:     cmd}
+			}
+
+			q.Limit = n
+			if q.Limit > maxEventSearchLimit {
+				q.Limit = maxEventSearchLimit
+			}
+		}
+	}
+
+	found, err := srv.SearchEvents(q)
+	if err != nil {
+		return nil, &ErrCommandError{message: err.Error(), cmd: cmd}
+	}
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+
+	if len(found) == 0 {
+		out.AddLine("No recorded events match the search")
+	}
+
+	// Show the events in the order that they happened
+	for i := len(found) - 1; i >= 0; i-- {
+		e := found[i]
+		t := e.Time.In(loc)
+		line := sprintf("**%d/%02d/%02d %02d:%02d:%02d | %s**", t.Year(), t.Month(),
+			t.Day(), t.Hour(), t.Minute(), t.Second(), e.Type)
+
+		if e.Name != "" {
+			line += sprintf(" `%s`", e.Name)
+		}
+		out.AddLine(line + " " + e.Message)
+	}
+
+	out.Construct()
+	return out, nil
+}
+
+func eventListCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Registered Events")
+		srv, b = cmd.Registrar().Server(a, 2)
+	)
+
+	if !HasNumArgs(b[1:], 0, 0) {
+		return nil, &ErrInvalidArgument{
+			message: sprintf("`%s` was passed the wrong number of arguments", cmd.Name()),
+			cmd:     cmd}
+	}
+
+	out.Description = "Lines are matched against events in this order " +
+		"_(shared by every galaxy)_"
+
+	for i, e := range srv.Events() {
+		line := sprintf("**%d. %s**", i+1, e.Name)
+		if e.Kind != "" {
+			line += " (" + e.Kind + ")"
+		}
+		out.AddLine(line)
+		out.AddLine(sprintf("> `%s`", strings.ReplaceAll(e.Regex, "`", "'")))
+	}
+
+	out.Construct()
+	return out, nil
+}
+
+func eventTestCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Event Test")
+		srv, b = cmd.Registrar().Server(a, 2)
+	)
+
+	if !HasNumArgs(b[1:], 1, -1) {
+		return nil, &ErrInvalidArgument{
+			message: sprintf("`%s` was passed the wrong number of arguments", cmd.Name()),
+			cmd:     cmd}
+	}
+
+	// Lines can be pasted as code so that Discord leaves them alone
+	line := strings.Trim(strings.Join(b[2:], " "), "`")
+	out.Quoted = true
+
+	match, ok := srv.TestEvent(line)
+	if !ok {
+		out.AddLine("The line doesn't match any event")
+		out.Construct()
+		return out, nil
+	}
+
+	out.Description = sprintf("Matched by **%s**", match.Event)
+	if match.Kind != "" {
+		out.Description += sprintf(" (%s)", match.Kind)
+	}
+
+	for i, g := range match.Groups {
+		name := sprintf("$%d", i+1)
+		if g.Name != "" {
+			name += sprintf(" (%s)", g.Name)
+		}
+		out.AddLine(sprintf("**%s:** `%s`", name, strings.ReplaceAll(g.Value, "`", "'")))
+	}
+
+	if len(match.Groups) == 0 {
+		out.AddLine("Nothing was captured")
+	}
+
+	if match.Payload != "" {
+		out.AddLine(sprintf("**Payload:** `%s`",
+			strings.ReplaceAll(match.Payload, "`", "'")))
+	}
+
+	out.Construct()
+	return out, nil
+}
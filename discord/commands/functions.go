@@ -2,8 +2,12 @@ package commands
 
 import (
 	"avorioncontrol/ifaces"
+	"errors"
 	"log"
+	"net/http"
 	"time"
+
+	"github.com/bwmarrin/discordgo"
 )
 
 func init() {
@@ -38,6 +42,91 @@ func HasNumArgs(a BotArgs, min, max int) bool {
 	return true
 }
 
+// InGameAdminResolver returns a function that reports whether the roles of a
+// Discord user grant them admin in game. Users that are in none of the guilds
+// of the bot are not admins, but a failure to look them up is an error.
+func InGameAdminResolver(s *discordgo.Session,
+	c ifaces.IConfigurator) func(string) (bool, error) {
+	return func(uid string) (bool, error) {
+		level := 0
+		for _, g := range s.State.Guilds {
+			member, err := s.State.Member(g.ID, uid)
+			if err != nil {
+				if member, err = s.GuildMember(g.ID, uid); err != nil {
+					if isUnknownMember(err) {
+						continue
+					}
+					return false, err
+				}
+			}
+
+			for _, r := range member.Roles {
+				if l := c.GetRoleAuth(r); l > level {
+					level = l
+				}
+			}
+		}
+
+		return c.InGameAdminLevel() > 0 && level >= c.InGameAdminLevel(), nil
+	}
+}
+
+// isUnknownMember returns whether err is the answer of Discord to a lookup of
+// a user that is not a member of the guild
+func isUnknownMember(err error) bool {
+	var rerr *discordgo.RESTError
+	return errors.As(err, &rerr) && rerr.Response != nil &&
+		rerr.Response.StatusCode == http.StatusNotFound
+}
+
+// LinkedRoleResolver returns a function that reports whether a Discord user may
+// join servers in linked-only mode. Without a configured role, being a member of
+// one of the bots guilds is enough.
+func LinkedRoleResolver(s *discordgo.Session, c ifaces.IConfigurator) func(string) bool {
+	return func(uid string) bool {
+		_, role := c.LinkedOnly()
+		for _, g := range s.State.Guilds {
+			member, err := s.State.Member(g.ID, uid)
+			if err != nil {
+				if member, err = s.GuildMember(g.ID, uid); err != nil {
+					continue
+				}
+			}
+
+			if role == "" {
+				return true
+			}
+
+			for _, r := range member.Roles {
+				if r == role {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+}
+
+// restartForChanges notes when changes to a galaxy take effect, and schedules a
+// restart to load them when one was asked for
+func restartForChanges(out *CommandOutput, srv ifaces.IGameServer, restart bool) {
+	switch {
+	case !srv.IsUp():
+		out.AddLine("_The changes will take effect when the server is started_")
+
+	case !restart:
+		out.AddLine("_The changes will take effect when the server is restarted_")
+
+	default:
+		if err := srv.SoftRestart(srv.Config().SoftRestartDeadline()); err != nil {
+			out.AddLine(sprintf("_Could not schedule a restart: %s_", err.Error()))
+		} else {
+			out.AddLine("_The server will restart once it is empty_")
+		}
+	}
+}
+
 // reverseSlice reverse an arbtrary slice
 func reverseJumps(j []*ifaces.JumpInfo) []*ifaces.JumpInfo {
 	var jumps []*ifaces.JumpInfo
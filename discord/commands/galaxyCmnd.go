@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func newGalaxyCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out      = newCommandOutput(cmd, "New Galaxy")
+		srv, b   = cmd.Registrar().Server(a, 2)
+		seed     = ""
+		previous = srv.Config().Galaxy()
+	)
+
+	if !HasNumArgs(b[1:], 1, 2) {
+		return nil, &ErrInvalidArgument{
+			message: sprintf("`%s` was passed the wrong number of arguments", cmd.Name()),
+			cmd:     cmd}
+	}
+
+	if len(b) > 3 {
+		seed = b[3]
+	}
+
+	s.ChannelMessageSend(m.ChannelID, sprintf("Replacing **%s** with **%s**. "+
+		"Progress will be posted to the log channel", previous, b[2]))
+
+	// Players are given time to log off first, so this runs in the background and
+	// reports back once it is done
+	go func(name, seed string) {
+		seed, err := srv.NewGalaxy(name, seed)
+		if err != nil {
+			logger.LogError(cmd, "NewGalaxy: "+err.Error())
+			s.ChannelMessageSend(m.ChannelID, sprintf("Failed to create **%s**: %s",
+				name, err.Error()))
+			return
+		}
+
+		out.Description = srv.Config().Galaxy()
+		out.Quoted = true
+		out.AddLine(sprintf("Archived **%s**", previous))
+		out.AddLine(sprintf("Created **%s** with the seed `%s`", name, seed))
+		if !srv.IsUp() {
+			out.AddLine("_Use `server start` to bring the new galaxy online_")
+		}
+		out.Construct()
+
+		embed, _, _ := GenerateOutputEmbed(out, out.ThisPage())
+		if _, err := s.ChannelMessageSendEmbed(m.ChannelID, embed); err != nil {
+			logger.LogError(cmd, "discordgo: "+err.Error())
+		}
+	}(b[2], seed)
+
+	return nil, nil
+}
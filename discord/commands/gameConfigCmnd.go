@@ -0,0 +1,233 @@
+package commands
+
+import (
+	"avorioncontrol/ifaces"
+	"avorioncontrol/logger"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// gameConfigDiff formats server.ini changes as a diff
+func gameConfigDiff(changes []ifaces.GameSettingChange) string {
+	lines := make([]string, 0, len(changes)*2)
+	for _, c := range changes {
+		lines = append(lines,
+			sprintf("- %s = %s", c.Key, c.Old),
+			sprintf("+ %s = %s", c.Key, c.New))
+	}
+
+	return "```diff\n" + strings.ReplaceAll(strings.Join(lines, "\n"), "```",
+		"'''") + "\n```"
+}
+
+func getGameConfigCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Game Configuration")
+		srv, b = cmd.Registrar().Server(a, 2)
+	)
+
+	if !HasNumArgs(b[1:], 0, 1) {
+		return nil, &ErrInvalidArgument{
+			message: sprintf("`%s` was passed the wrong number of arguments", cmd.Name()),
+			cmd:     cmd}
+	}
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+
+	settings := make([]ifaces.GameSetting, 0)
+	if len(b) > 2 {
+		setting, err := srv.Config().GameSetting(b[2])
+		if err != nil {
+			return nil, &ErrCommandError{message: err.Error(), cmd: cmd}
+		}
+		settings = append(settings, setting)
+	} else {
+		all, err := srv.Config().GameSettings()
+		if err != nil {
+			return nil, &ErrCommandError{
+				message: "Failed to read server.ini: " + err.Error(),
+				cmd:     cmd}
+		}
+		settings = all
+	}
+
+	for _, setting := range settings {
+		line := sprintf("**%s**: `%s`", setting.Key, setting.Value)
+		if setting.ReadOnly {
+			line += " _(read only)_"
+		}
+		out.AddLine(line)
+	}
+
+	out.Construct()
+	return out, nil
+}
+
+func setGameConfigCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Game Configuration")
+		srv, b = cmd.Registrar().Server(a, 2)
+	)
+
+	// Values can contain spaces, so there is no upper limit
+	if !HasNumArgs(b[1:], 2, -1) {
+		return nil, &ErrInvalidArgument{
+			message: sprintf("`%s` was passed the wrong number of arguments", cmd.Name()),
+			cmd:     cmd}
+	}
+
+	change, err := srv.Config().StageGameSetting(b[2], strings.Join(b[3:], " "))
+	if err != nil {
+		return nil, &ErrInvalidArgument{message: err.Error(), cmd: cmd}
+	}
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+
+	if change.Old == change.New {
+		out.AddLine(sprintf("**%s** is already `%s`, nothing was staged",
+			change.Key, change.New))
+	} else {
+		out.AddLine(sprintf("Staged **%s**: `%s` → `%s`", change.Key, change.Old,
+			change.New))
+		out.AddLine("Use `gameconfig diff` to review, and `gameconfig apply` to " +
+			"write the staged changes")
+	}
+
+	out.Construct()
+	return out, nil
+}
+
+func diffGameConfigCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Game Configuration")
+		srv, _ = cmd.Registrar().Server(a, 2)
+	)
+
+	changes, err := srv.Config().StagedGameSettings()
+	if err != nil {
+		return nil, &ErrCommandError{
+			message: "Failed to read server.ini: " + err.Error(),
+			cmd:     cmd}
+	}
+
+	out.Description = srv.Config().Galaxy()
+	if len(changes) == 0 {
+		out.Quoted = true
+		out.AddLine("There are no staged changes")
+	} else {
+		out.AddLine(gameConfigDiff(changes))
+	}
+
+	out.Construct()
+	return out, nil
+}
+
+func discardGameConfigCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Game Configuration")
+		srv, _ = cmd.Registrar().Server(a, 2)
+	)
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+	out.AddLine(sprintf("Discarded %d staged changes",
+		srv.Config().DiscardGameSettings()))
+	out.Construct()
+	return out, nil
+}
+
+func applyGameConfigCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out     = newCommandOutput(cmd, "Game Configuration")
+		srv, b  = cmd.Registrar().Server(a, 2)
+		restart = false
+	)
+
+	if !HasNumArgs(b[1:], 0, 1) {
+		return nil, &ErrInvalidArgument{
+			message: sprintf("`%s` was passed the wrong number of arguments", cmd.Name()),
+			cmd:     cmd}
+	}
+
+	if len(b) > 2 {
+		if b[2] != "--restart" {
+			return nil, &ErrInvalidArgument{
+				message: sprintf("Unknown option `%s`", b[2]),
+				cmd:     cmd}
+		}
+		restart = true
+	}
+
+	// Avorion writes server.ini back when it shuts down, so changes made while
+	// it runs would be lost. They are kept staged, and written by the next start.
+	if srv.IsUp() {
+		return queueGameConfig(m, srv, cmd, restart)
+	}
+
+	changes, err := srv.Config().ApplyGameSettings()
+	if err != nil {
+		return nil, &ErrCommandError{
+			message: "Failed to apply changes: " + err.Error(),
+			cmd:     cmd}
+	}
+
+	diff := gameConfigDiff(changes)
+	logger.LogInfo(cmd, sprintf("%s applied %d server.ini changes to %s",
+		m.Author.String(), len(changes), srv.Config().Galaxy()))
+	srv.SendLog(ifaces.ChatData{Msg: sprintf("**server.ini** of %s was changed "+
+		"by %s:\n%s", srv.Config().Galaxy(), m.Author.Mention(), diff)})
+
+	out.Description = srv.Config().Galaxy()
+	out.AddLine(diff)
+
+	restartForChanges(out, srv, restart)
+	out.Construct()
+	return out, nil
+}
+
+// queueGameConfig leaves the staged changes of a running server to be written
+// when it is next started, and restarts it if asked to
+func queueGameConfig(m *discordgo.MessageCreate, srv ifaces.IGameServer,
+	cmd *CommandRegistrant, restart bool) (*CommandOutput, ICommandError) {
+	out := newCommandOutput(cmd, "Game Configuration")
+
+	changes, err := srv.Config().StagedGameSettings()
+	if err != nil {
+		return nil, &ErrCommandError{
+			message: "Failed to read staged changes: " + err.Error(),
+			cmd:     cmd}
+	}
+
+	if len(changes) == 0 {
+		return nil, &ErrCommandError{
+			message: "There are no staged changes",
+			cmd:     cmd}
+	}
+
+	logger.LogInfo(cmd, sprintf("%s queued %d server.ini changes for %s",
+		m.Author.String(), len(changes), srv.Config().Galaxy()))
+
+	out.Description = srv.Config().Galaxy()
+	out.AddLine(gameConfigDiff(changes))
+	out.AddLine("_The server is running, so the changes will be written to " +
+		"server.ini when it is next started_")
+
+	if restart {
+		if err := srv.SoftRestart(srv.Config().SoftRestartDeadline()); err != nil {
+			out.AddLine(sprintf("_Could not schedule a restart: %s_", err.Error()))
+		} else {
+			out.AddLine("_The server will restart once it is empty_")
+		}
+	}
+
+	out.Construct()
+	return out, nil
+}
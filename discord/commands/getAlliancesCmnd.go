@@ -9,17 +9,18 @@ import (
 func getAlliancesCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
 	var (
-		reg = cmd.Registrar()
-		out = newCommandOutput(cmd, "Alliances")
+		reg    = cmd.Registrar()
+		srv, _ = reg.Server(a, 1)
+		out    = newCommandOutput(cmd, "Alliances")
 	)
 
-	if reg.server == nil || !reg.server.IsUp() {
+	if srv == nil || !srv.IsUp() {
 		return nil, &ErrCommandError{
 			message: "Server has not finished initializing",
 			cmd:     cmd}
 	}
 
-	alliances := reg.server.Alliances()
+	alliances := srv.Alliances()
 	if len(alliances) == 0 {
 		out.AddLine("No tracked alliances available")
 		out.Construct()
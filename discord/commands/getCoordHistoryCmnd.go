@@ -18,6 +18,8 @@ func getCoordHistoryCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a Bot
 		match []string
 	)
 
+	srv, a := reg.Server(a, 1)
+
 	// Require at least one set of coords
 	if !HasNumArgs(a, 1, -1) {
 		return nil, &ErrInvalidArgument{
@@ -62,7 +64,7 @@ func getCoordHistoryCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a Bot
 	// Migrate this to a method call on sectors or a utility function
 	for _, c := range coords {
 		logger.LogDebug(cmd, sprintf("Checking for jumps to sector: (%d:%d)", c[0], c[1]))
-		sector := reg.server.Sector(c[0], c[1])
+		sector := srv.Sector(c[0], c[1])
 		if len(sector.Jumphistory) > 0 {
 			orderedjumps := reverseJumps(sector.Jumphistory)
 			for _, j := range orderedjumps {
@@ -90,7 +92,7 @@ func getCoordHistoryCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a Bot
 
 		switch j.Kind {
 		case "player":
-			if obj = reg.server.Player(fid); obj == nil {
+			if obj = srv.Player(fid); obj == nil {
 				logger.LogError(cmd, "(player) Got an invalid ifaces.IHaveShips object")
 				return nil, &ErrCommandError{
 					message: "Error, bad data type encountered. Please review the logs.",
@@ -98,7 +100,7 @@ func getCoordHistoryCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a Bot
 			}
 
 		case "alliance":
-			if obj = reg.server.Alliance(fid); obj == nil {
+			if obj = srv.Alliance(fid); obj == nil {
 				logger.LogError(cmd, "(alliance) Got an invalid ifaces.IHaveShips object")
 				return nil, &ErrCommandError{
 					message: "Error, bad data type encountered. Please review the logs.",
@@ -11,10 +11,10 @@ func getIntegratedCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotAr
 	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
 
 	var (
-		reg = cmd.Registrar()
-		srv = reg.server
-		out = newCommandOutput(cmd, "Players with Discord Integration")
-		cnt = 0
+		reg    = cmd.Registrar()
+		srv, _ = reg.Server(a, 1)
+		out    = newCommandOutput(cmd, "Players with Discord Integration")
+		cnt    = 0
 	)
 
 	out.Header = "Players Found"
@@ -19,6 +19,8 @@ func getJumpsCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 		cnt int
 	)
 
+	srv, a := reg.Server(a, 1)
+
 	// Make sure we have the args we need
 	if !HasNumArgs(a, 2, -1) {
 		return nil, &ErrInvalidArgument{
@@ -36,15 +38,15 @@ func getJumpsCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 			cmd:     cmd}
 	}
 
-	if p := reg.server.PlayerFromName(ref); p != nil {
+	if p := srv.PlayerFromName(ref); p != nil {
 		obj = p
-	} else if p := reg.server.PlayerFromDiscord(ref); p != nil {
+	} else if p := srv.PlayerFromDiscord(ref); p != nil {
 		obj = p
-	} else if p := reg.server.Player(ref); p != nil {
+	} else if p := srv.Player(ref); p != nil {
 		obj = p
-	} else if a := reg.server.Alliance(ref); a != nil {
+	} else if a := srv.Alliance(ref); a != nil {
 		obj = a
-	} else if a := reg.server.AllianceFromName(ref); a != nil {
+	} else if a := srv.AllianceFromName(ref); a != nil {
 		obj = a
 	}
 
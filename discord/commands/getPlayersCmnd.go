@@ -9,17 +9,18 @@ import (
 func getPlayersCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
 	var (
-		reg = cmd.Registrar()
-		out = newCommandOutput(cmd, "Players")
+		reg    = cmd.Registrar()
+		srv, _ = reg.Server(a, 1)
+		out    = newCommandOutput(cmd, "Players")
 	)
 
-	if reg.server == nil || !reg.server.IsUp() {
+	if srv == nil || !srv.IsUp() {
 		return nil, &ErrCommandError{
 			message: "Server has not finished initializing",
 			cmd:     cmd}
 	}
 
-	players := reg.server.Players()
+	players := srv.Players()
 	if len(players) == 0 {
 		out.AddLine("No tracked players available")
 		out.Construct()
@@ -23,6 +23,9 @@ func proxySubCmnd(s *discordgo.Session, m *discordgo.MessageCreate,
 	_, cmdlets := cmd.Subcommands()
 	for _, cmdlet := range cmdlets {
 		if a[1] == cmdlet.Name() {
+			if err := cmdlet.checkGalaxy(a, 2); err != nil {
+				return nil, err
+			}
 			return cmdlet.exec(s, m, a, c, cmdlet)
 		}
 	}
@@ -15,10 +15,12 @@ func modAddSubCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
 
 	var (
-		out     = newCommandOutput(cmd, "Add Server Mods")
-		failed  = make([]string, 0)
-		reason  = make([]string, 0)
-		success = make([]int64, 0)
+		out      = newCommandOutput(cmd, "Add Server Mods")
+		failed   = make([]string, 0)
+		reason   = make([]string, 0)
+		success  = make([]int64, 0)
+		deps     = make([]int64, 0)
+		warnings = make([]string, 0)
 	)
 
 	if !HasNumArgs(a[1:], 1, -1) {
@@ -37,13 +39,16 @@ func modAddSubCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 
 	for _, mod := range a[2:] {
 		if id, err := strconv.ParseInt(mod, 10, 64); err == nil {
-			if err := c.AddServerMod(id); err != nil {
+			added, warns, err := c.AddServerModWithDependencies(id)
+			if err != nil {
 				failed = append(failed, mod)
 				reason = append(reason, err.Error())
 			} else {
 				logger.LogInfo(cmd, sprintf("%s added %d to the mod configuration",
 					m.Author.String(), id))
 				success = append(success, id)
+				deps = append(deps, added...)
+				warnings = append(warnings, warns...)
 			}
 		} else {
 			failed = append(failed, mod)
@@ -51,10 +56,26 @@ func modAddSubCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 		}
 	}
 
+	catalog := modCatalogIndex(c)
 	out.Header = "Mods Added"
 	if len(success) > 0 {
 		for i, id := range success {
-			out.AddLine(sprintf("> %d. %s%d", i+1, modURLBase, id))
+			out.AddLine(sprintf("> %d. %s", i+1, modLine(catalog, id)))
+		}
+	}
+
+	if len(deps) > 0 {
+		out.AddLine("**Dependencies Added**")
+		for i, id := range deps {
+			out.AddLine(sprintf("> %d. %s", i+1, modLine(catalog, id)))
+		}
+	}
+
+	if len(warnings) > 0 {
+		out.Status = ifaces.CommandWarning
+		out.AddLine("**Warnings**")
+		for _, w := range warnings {
+			out.AddLine("> " + w)
 		}
 	}
 
@@ -291,25 +312,114 @@ func listModsSubCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs
 		out        = newCommandOutput(cmd, "Mod List")
 		servermods = c.ListServerMods()
 		clientmods = c.ListClientMods()
+		catalog    = modCatalogIndex(c)
+		localmods  = make([]ifaces.ModInfo, 0)
 	)
 
+	for _, mod := range catalog {
+		if mod.Local {
+			localmods = append(localmods, mod)
+		}
+	}
+
 	out.Header = "Current Mod Configuration"
 	out.AddLine("**Mods Installed**")
-	if len(servermods) < 1 {
+	if len(servermods) < 1 && len(localmods) < 1 {
 		out.AddLine("No mods currently configured")
 	} else {
 		for i, id := range servermods {
-			out.AddLine(sprintf("> %d. %s%d", i+1, modURLBase, id))
+			out.AddLine(sprintf("> %d. %s", i+1, modLine(catalog, id)))
+		}
+		for i, mod := range localmods {
+			out.AddLine(sprintf("> %d. %s _(local)_", len(servermods)+i+1,
+				modName(mod)))
 		}
 	}
 
 	if len(clientmods) > 0 {
 		out.AddLine("**Mods Allowed**")
 		for i, id := range clientmods {
-			out.AddLine(sprintf("> %d. %s%d", i+1, modURLBase, id))
+			out.AddLine(sprintf("> %d. %s", i+1, modLine(catalog, id)))
 		}
 	}
 
 	out.Construct()
 	return out, nil
 }
+
+// modCatalogIndex returns the known mods indexed by their ID. The catalog lists
+// local mods first, and the first mod with an ID wins, as it does in Avorion.
+func modCatalogIndex(c ifaces.IConfigurator) map[string]ifaces.ModInfo {
+	catalog, _ := c.ModCatalog()
+	index := make(map[string]ifaces.ModInfo, len(catalog))
+	for _, mod := range catalog {
+		if _, ok := index[mod.ID]; !ok {
+			index[mod.ID] = mod
+		}
+	}
+	return index
+}
+
+// modName formats the name and version of a mod
+func modName(mod ifaces.ModInfo) string {
+	name := mod.Title
+	if name == "" {
+		name = mod.Name
+	}
+	if name == "" {
+		name = mod.ID
+	}
+
+	if mod.Version != "" {
+		return sprintf("**%s** v%s", name, mod.Version)
+	}
+	return sprintf("**%s**", name)
+}
+
+// modLine formats a workshop mod, including its name and version when it has
+// been downloaded
+func modLine(catalog map[string]ifaces.ModInfo, id int64) string {
+	if mod, ok := catalog[strconv.FormatInt(id, 10)]; ok {
+		return sprintf("%s <%s%d>", modName(mod), modURLBase, id)
+	}
+	return sprintf("%s%d", modURLBase, id)
+}
+
+func modUpdatesSubCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Mod Updates")
+		srv, b = cmd.Registrar().Server(a, 2)
+	)
+
+	if !HasNumArgs(b[1:], 0, 0) {
+		return nil, &ErrInvalidArgument{
+			message: sprintf("`%s` was passed the wrong number of arguments", cmd.Name()),
+			cmd:     cmd}
+	}
+
+	if !srv.IsUp() {
+		return nil, &ErrCommandError{
+			message: "The server has to be online to check for mod updates",
+			cmd:     cmd}
+	}
+
+	updated, err := srv.CheckModUpdates()
+	if err != nil {
+		return nil, &ErrCommandError{message: err.Error(), cmd: cmd}
+	}
+
+	out.Description = srv.Config().Galaxy()
+	if len(updated) == 0 {
+		out.Quoted = true
+		out.AddLine("No mod updates were found")
+	}
+
+	catalog := modCatalogIndex(srv.Config())
+	for i, id := range updated {
+		out.AddLine(sprintf("> %d. %s", i+1, modLine(catalog, id)))
+	}
+
+	out.Construct()
+	return out, nil
+}
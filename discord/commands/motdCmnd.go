@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"avorioncontrol/ifaces"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func listMOTDCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Message of the Day")
+		srv, _ = cmd.Registrar().Server(a, 2)
+		motds  = srv.Config().MOTDList()
+	)
+
+	out.Description = sprintf("%s (rotates every %s)", srv.Config().Galaxy(),
+		srv.Config().MOTDRotation())
+	out.Quoted = true
+
+	if srv.MOTD() != "" {
+		out.AddLine(sprintf("**Current:** %s", srv.MOTD()))
+	}
+
+	if len(motds) == 0 {
+		out.AddLine("There are no messages of the day configured")
+	}
+
+	for i, motd := range motds {
+		out.AddLine(sprintf("**%d:** %s", i+1, motd))
+	}
+
+	out.Construct()
+	return out, nil
+}
+
+func addMOTDCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Message of the Day")
+		srv, b = cmd.Registrar().Server(a, 2)
+	)
+
+	if !HasNumArgs(b[1:], 1, -1) {
+		return nil, &ErrInvalidArgument{
+			message: sprintf("`%s` was passed the wrong number of arguments", cmd.Name()),
+			cmd:     cmd}
+	}
+
+	motd := strings.Join(b[2:], " ")
+	motds := append(srv.Config().MOTDList(), motd)
+	srv.Config().SetMOTDList(motds)
+
+	// Show the first message straight away instead of waiting for the rotation
+	if len(motds) == 1 {
+		srv.SetMOTD(motd)
+	}
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+	out.AddLine(sprintf("Added message **%d:** %s", len(motds), motd))
+	out.Construct()
+	return out, nil
+}
+
+func removeMOTDCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Message of the Day")
+		srv, b = cmd.Registrar().Server(a, 2)
+		motds  = srv.Config().MOTDList()
+	)
+
+	if !HasNumArgs(b[1:], 1, 1) {
+		return nil, &ErrInvalidArgument{
+			message: sprintf("`%s` was passed the wrong number of arguments", cmd.Name()),
+			cmd:     cmd}
+	}
+
+	n, err := strconv.Atoi(b[2])
+	if err != nil || n < 1 || n > len(motds) {
+		return nil, &ErrInvalidArgument{
+			message: sprintf("There is no message of the day number `%s`", b[2]),
+			cmd:     cmd}
+	}
+
+	removed := motds[n-1]
+	motds = append(motds[:n-1], motds[n:]...)
+	srv.Config().SetMOTDList(motds)
+
+	if len(motds) == 0 {
+		srv.SetMOTD("")
+	}
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+	out.AddLine(sprintf("Removed message **%d:** %s", n, removed))
+	out.Construct()
+	return out, nil
+}
+
+func nextMOTDCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Message of the Day")
+		srv, _ = cmd.Registrar().Server(a, 2)
+	)
+
+	if len(srv.Config().MOTDList()) == 0 {
+		return nil, &ErrCommandError{
+			message: "There are no messages of the day configured",
+			cmd:     cmd}
+	}
+
+	srv.RotateMOTD()
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+	out.AddLine(sprintf("**Current:** %s", srv.MOTD()))
+	out.Construct()
+	return out, nil
+}
@@ -14,12 +14,12 @@ func playerKickCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 	var (
 		reason = `Kicked by an Admin`
 		reg    = cmd.Registrar()
-		srv    = reg.server
 		out    = newCommandOutput(cmd, "Kick Player")
 
 		obj ifaces.IPlayer
 	)
 
+	srv, a := reg.Server(a, 2)
 	out.Quoted = true
 
 	if !HasNumArgs(a[1:], 1, -1) {
@@ -57,12 +57,12 @@ func playerBanCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 	var (
 		reason = `Banned by an Admin`
 		reg    = cmd.Registrar()
-		srv    = reg.server
 		out    = newCommandOutput(cmd, "Kick Player")
 
 		obj ifaces.IPlayer
 	)
 
+	srv, a := reg.Server(a, 2)
 	out.Quoted = true
 
 	if !HasNumArgs(a, 1, -1) {
@@ -98,10 +98,10 @@ func playerBanCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 func showOnlinePlayersCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
 	var (
-		reg = cmd.Registrar()
-		srv = reg.server
-		out = newCommandOutput(cmd, "Players Online")
-		cnt = 0
+		reg    = cmd.Registrar()
+		srv, _ = reg.Server(a, 1)
+		out    = newCommandOutput(cmd, "Players Online")
+		cnt    = 0
 	)
 
 	out.Quoted = true
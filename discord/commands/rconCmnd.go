@@ -12,7 +12,6 @@ func rconCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
 	var (
 		reg = cmd.Registrar()
-		srv = reg.server
 		out = newCommandOutput(cmd, "RCON")
 
 		rconout string
@@ -20,6 +19,8 @@ func rconCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 		err     error
 	)
 
+	srv, a := reg.Server(a, 1)
+
 	if !HasNumArgs(a, 1, -1) {
 		return nil, &ErrInvalidArgument{
 			message: sprintf(`%s was passed the wrong number of arguments`, cmd.Name()),
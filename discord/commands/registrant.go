@@ -2,6 +2,7 @@ package commands
 
 import (
 	"avorioncontrol/logger"
+	"strings"
 )
 
 // CommandRegistrant - Command definition
@@ -83,6 +84,43 @@ func (c *CommandRegistrant) Subcommands() (int, []*CommandRegistrant) {
 	return 0, nil
 }
 
+// checkGalaxy - Return an error when more than one galaxy is managed, and the
+// argument in the galaxy selector position is neither the name of a galaxy nor
+// anything else that the command accepts there. This keeps a typo in a galaxy
+// name from running a command against the default galaxy.
+//  @a BotArgs    Arguments that were passed to the command
+//  @i int        Index of the argument that may contain a galaxy name
+func (c *CommandRegistrant) checkGalaxy(a BotArgs, i int) ICommandError {
+	if c.registrar == nil || len(c.registrar.servers) < 2 || len(a) <= i {
+		return nil
+	}
+
+	if len(c.args) == 0 || c.args[0][0] != "galaxy" {
+		return nil
+	}
+
+	names := make([]string, 0, len(c.registrar.servers))
+	for _, gs := range c.registrar.servers {
+		if strings.EqualFold(gs.Config().Galaxy(), a[i]) {
+			return nil
+		}
+		names = append(names, "`"+gs.Config().Galaxy()+"`")
+	}
+
+	// Anything might be a valid value for an argument that isn't a flag, while
+	// flags have to be given by their name
+	for _, arg := range c.args[1:] {
+		if !strings.HasPrefix(arg[0], "--") || arg[0] == a[i] {
+			return nil
+		}
+	}
+
+	return &ErrInvalidArgument{
+		message: sprintf("`%s` is not a galaxy. Use one of: %s", a[i],
+			strings.Join(names, ", ")),
+		cmd: c}
+}
+
 // Registrar - Return a commands master CommandRegistrar
 func (c *CommandRegistrant) Registrar() *CommandRegistrar {
 	return c.registrar
@@ -26,7 +26,7 @@ type CommandRegistrar struct {
 	commands     map[string]*CommandRegistrant
 	commandnames []string
 	loglevel     int
-	server       ifaces.IGameServer
+	servers      []ifaces.IGameServer
 	embeds       []chan struct{}
 }
 
@@ -42,18 +42,49 @@ func (reg *CommandRegistrar) UUID() string {
 }
 
 // NewRegistrar - Create and return a new instance of CommandRegistrar
-//  @gid string    ID string of the guild the CommandRegistrar belongs to
-func NewRegistrar(gid string, gs ifaces.IGameServer) *CommandRegistrar {
+//  @gid string                      ID string of the guild the CommandRegistrar belongs to
+//  @servers []ifaces.IGameServer    Servers that commands can target. The first is the default
+func NewRegistrar(gid string, servers []ifaces.IGameServer) *CommandRegistrar {
 	registrars[gid] = &CommandRegistrar{
 		GuildID:  gid,
 		commands: make(map[string]*CommandRegistrant, 10),
-		server:   gs,
+		servers:  servers,
 		loglevel: 1,
 		embeds:   make([]chan struct{}, 0)}
 
 	return registrars[gid]
 }
 
+// Servers - Return all of the servers that the CommandRegistrar can target
+func (reg *CommandRegistrar) Servers() []ifaces.IGameServer {
+	return reg.servers
+}
+
+// Server - Return the server targeted by a command, and its arguments without
+// the galaxy selector. Commands accept an optional galaxy name directly after
+// the (sub)command name, and otherwise target the default galaxy. Names that
+// can't be anything but a mistyped galaxy are rejected by checkGalaxy before
+// the command runs.
+//  @a BotArgs    Arguments that were passed to the command
+//  @i int        Index of the argument that may contain a galaxy name
+func (reg *CommandRegistrar) Server(a BotArgs, i int) (ifaces.IGameServer, BotArgs) {
+	if len(reg.servers) == 0 {
+		return nil, a
+	}
+
+	if i < len(a) {
+		for _, gs := range reg.servers {
+			if strings.EqualFold(gs.Config().Galaxy(), a[i]) {
+				args := make(BotArgs, 0, len(a)-1)
+				args = append(args, a[:i]...)
+				return gs, append(args, a[i+1:]...)
+			}
+		}
+	}
+
+	return reg.servers[0], a
+}
+
 // Registrar - Return the Registrar that is associated with a specific guild
 func Registrar(gid string) (r *CommandRegistrar, err error) {
 	if r = registrars[gid]; r == nil {
@@ -241,7 +272,7 @@ func (reg *CommandRegistrar) ProcessCommand(s *discordgo.Session,
 
 	if len(args) > 1 && args[1] == "help" && cmd.Name() != "rcon" {
 		out = cmd.Help()
-	} else {
+	} else if cmderr = cmd.checkGalaxy(args, 1); cmderr == nil {
 		out, cmderr = cmd.exec(s, m, args, c, cmd)
 	}
 
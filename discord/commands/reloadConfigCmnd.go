@@ -19,7 +19,17 @@ func reloadConfigCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArg
 
 	out.AddLine("Reloaded bot configuration")
 
-	cmd.Registrar().server.InitializeEvents()
+	// The events and schedules of every galaxy are swapped in while the servers
+	// keep running
+	servers := cmd.Registrar().Servers()
+	for _, srv := range servers {
+		srv.InitializeEvents()
+		srv.ReloadSchedule()
+	}
+
+	if len(servers) > 0 {
+		out.AddLine(sprintf("Reloaded %d event definitions", len(servers[0].Events())))
+	}
 	out.Construct()
 	return out, nil
 }
@@ -17,6 +17,7 @@ import (
 
 func sendBroadcastCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	srv, a := cmd.Registrar().Server(a, 1)
 	if len(m.Attachments) < 1 {
 		return nil, &ErrCommandError{
 			message: "Please attach a text file to process", cmd: cmd}
@@ -29,13 +30,13 @@ func sendBroadcastCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotAr
 	}
 
 	var (
-		dir  = c.DataPath() + c.Galaxy() + "/messages/"
+		cfg  = srv.Config()
+		dir  = cfg.DataPath() + cfg.Galaxy() + "/messages/"
 		sub  = strings.Join(a[1:], " ")
 		name = m.Attachments[0].Filename
 		size = m.Attachments[0].Size
 		url  = m.Attachments[0].URL
 		out  = newCommandOutput(cmd, "Mass In-Game Email")
-		srv  = cmd.Registrar().server
 	)
 
 	if utf8.RuneCountInString(sub) > 48 {
@@ -55,9 +56,9 @@ func sendBroadcastCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotAr
 		cmd:     cmd}
 
 	logger.LogDebug(cmd, "Ensuring that messages directory exists")
-	_, err := os.Stat(c.DataPath() + c.Galaxy() + "/messages")
+	_, err := os.Stat(cfg.DataPath() + cfg.Galaxy() + "/messages")
 	if os.IsNotExist(err) {
-		if os.Mkdir(c.DataPath()+c.Galaxy()+"/messages", 0700) != nil {
+		if os.Mkdir(cfg.DataPath()+cfg.Galaxy()+"/messages", 0700) != nil {
 			logger.LogError(cmd, "Failed to create messages directory: "+err.Error())
 			return nil, errout
 		}
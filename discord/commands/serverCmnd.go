@@ -3,14 +3,24 @@ package commands
 import (
 	"avorioncontrol/ifaces"
 	"avorioncontrol/logger"
+	"strconv"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
 )
 
+const scheduleTimeFormat = "Mon Jan 2 15:04 MST"
+
 func restartServerCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
-	reg := cmd.Registrar()
-	if err := reg.server.Restart(); err != nil {
+	srv, b := cmd.Registrar().Server(a, 2)
+
+	if len(b) > 2 {
+		return softRestartServerCmnd(s, m, b, c, cmd, srv)
+	}
+
+	if err := srv.Restart(); err != nil {
 		logger.LogError(cmd, "Avorion: "+err.Error())
 		return nil, &ErrCommandError{
 			message: "Error restarting Avorion: " + err.Error(),
@@ -20,11 +30,71 @@ func restartServerCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotAr
 	return nil, nil
 }
 
+// softRestartServerCmnd handles the flags for restarting once the server is
+// empty, and cancelling that restart
+func softRestartServerCmnd(s *discordgo.Session, m *discordgo.MessageCreate,
+	a BotArgs, c ifaces.IConfigurator, cmd *CommandRegistrant,
+	srv ifaces.IGameServer) (*CommandOutput, ICommandError) {
+	var (
+		out      = newCommandOutput(cmd, "Server Restart")
+		deadline = c.SoftRestartDeadline()
+	)
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+
+	switch a[2] {
+	case "--cancel":
+		if !srv.CancelSoftRestart() {
+			return nil, &ErrCommandError{
+				message: "There is no pending restart to cancel",
+				cmd:     cmd}
+		}
+		out.AddLine("Cancelled the pending restart")
+
+	case "--when-empty":
+		if !HasNumArgs(a[1:], 1, 2) {
+			return nil, &ErrInvalidArgument{
+				message: sprintf("`%s` was passed the wrong number of arguments", cmd.Name()),
+				cmd:     cmd}
+		}
+
+		if len(a) > 3 {
+			mins, err := strconv.Atoi(a[3])
+			if err != nil || mins < 1 {
+				return nil, &ErrInvalidArgument{
+					message: sprintf("`%s` is not a valid number of minutes", a[3]),
+					cmd:     cmd}
+			}
+			deadline = time.Duration(mins) * time.Minute
+		}
+
+		if err := srv.SoftRestart(deadline); err != nil {
+			return nil, &ErrCommandError{
+				message: "Error restarting Avorion: " + err.Error(),
+				cmd:     cmd}
+		}
+
+		out.AddLine(sprintf("The server will restart once it is empty, or in %s "+
+			"at the latest", deadline.String()))
+		out.AddLine(sprintf("Use `server restart %s --cancel` to cancel it",
+			srv.Config().Galaxy()))
+
+	default:
+		return nil, &ErrInvalidArgument{
+			message: sprintf("`%s` is not a valid option", a[2]),
+			cmd:     cmd}
+	}
+
+	out.Construct()
+	return out, nil
+}
+
 func stopServerCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
-	reg := cmd.Registrar()
-	if reg.server.IsUp() {
-		if err := reg.server.Stop(true); err != nil {
+	srv, _ := cmd.Registrar().Server(a, 2)
+	if srv.IsUp() {
+		if err := srv.Stop(true); err != nil {
 			logger.LogError(cmd, "Avorion: "+err.Error())
 			return nil, &ErrCommandError{
 				message: "Error stopping Avorion: " + err.Error(),
@@ -37,9 +107,9 @@ func stopServerCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 
 func startServerCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
-	reg := cmd.Registrar()
-	if !reg.server.IsUp() {
-		if err := reg.server.Start(true); err != nil {
+	srv, _ := cmd.Registrar().Server(a, 2)
+	if !srv.IsUp() {
+		if err := srv.Start(true); err != nil {
 			s.ChannelMessageSend(m.ChannelID, sprintf(
 				"Encountered an error starting the server:\n```%s\n```\n", err.Error()))
 			logger.LogError(cmd, "Avorion: "+err.Error())
@@ -51,3 +121,515 @@ func startServerCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs
 
 	return nil, nil
 }
+
+func recoverServerCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Crash Recovery")
+		srv, _ = cmd.Registrar().Server(a, 2)
+	)
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+
+	if srv.ClearCrashLoop() {
+		out.AddLine("Cleared the crash loop lockout")
+	} else {
+		out.AddLine("The server was not in a crash loop, cleared its crash history")
+	}
+
+	if !srv.IsUp() {
+		if err := srv.Start(true); err != nil {
+			logger.LogError(cmd, "Avorion: "+err.Error())
+			return nil, &ErrCommandError{
+				message: "Error starting Avorion: " + err.Error(),
+				cmd:     cmd}
+		}
+		out.AddLine("Started the server")
+	}
+
+	srv.Recovered()
+	out.Construct()
+	return out, nil
+}
+
+func preflightServerCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Pre-flight Checks")
+		srv, _ = cmd.Registrar().Server(a, 2)
+		report = srv.Preflight()
+	)
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+
+	for _, check := range report.Checks {
+		mark := "✅"
+		if !check.Passed {
+			mark = "❌"
+		}
+		out.AddLine(sprintf("%s **%s:** %s", mark, check.Name, check.Message))
+	}
+
+	out.AddLine("")
+	if report.Passed() {
+		out.AddLine("_All checks passed_")
+	} else {
+		out.AddLine(sprintf("_%d checks failed, the server will not start_",
+			len(report.Failed())))
+	}
+
+	out.Construct()
+	return out, nil
+}
+
+func historyServerCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out     = newCommandOutput(cmd, "Server History")
+		srv, b  = cmd.Registrar().Server(a, 2)
+		history = srv.StateHistory()
+		count   = 15
+	)
+
+	if !HasNumArgs(b[1:], 0, 1) {
+		return nil, &ErrInvalidArgument{
+			message: sprintf(`"%s" does not accept more than one argument`, cmd.Name()),
+			cmd:     cmd}
+	}
+
+	if len(b) > 2 {
+		n, err := strconv.Atoi(b[2])
+		if err != nil || n < 1 {
+			return nil, &ErrInvalidArgument{
+				message: sprintf(`"%s" is not a valid count`, b[2]),
+				cmd:     cmd}
+		}
+		count = n
+	}
+
+	tz, err := time.LoadLocation(c.TimeZone())
+	if err != nil {
+		tz = time.Local
+	}
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+
+	if len(history) == 0 {
+		out.AddLine("No state changes have been recorded")
+		out.Construct()
+		return out, nil
+	}
+
+	start := 0
+	if len(history) > count {
+		start = len(history) - count
+	}
+
+	// Each state lasts until the next transition, or until now for the last one
+	for i := len(history) - 1; i >= start; i-- {
+		var (
+			t       = history[i]
+			to, _   = ifaces.State(t.To)
+			lasted  time.Duration
+			ongoing = i == len(history)-1
+		)
+
+		if ongoing {
+			lasted = time.Since(t.Time)
+		} else {
+			lasted = history[i+1].Time.Sub(t.Time)
+		}
+
+		line := sprintf("`%s` **%s** for _%s_", t.Time.In(tz).Format(scheduleTimeFormat),
+			to, lasted.Round(time.Second))
+		if ongoing {
+			line += " (current)"
+		}
+		if t.Reason != "" {
+			line += " - " + t.Reason
+		}
+
+		out.AddLine(line)
+	}
+
+	out.Construct()
+	return out, nil
+}
+
+// meanLag returns the average tick lag of a set of heartbeats
+func meanLag(beats []ifaces.Heartbeat) time.Duration {
+	if len(beats) == 0 {
+		return 0
+	}
+
+	var total time.Duration
+	for _, b := range beats {
+		total += b.Lag()
+	}
+	return total / time.Duration(len(beats))
+}
+
+func heartbeatServerCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	const window = 30
+
+	var (
+		out    = newCommandOutput(cmd, "Game Loop Heartbeat")
+		srv, _ = cmd.Registrar().Server(a, 2)
+		beats  = srv.Heartbeats()
+	)
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+
+	if len(beats) == 0 {
+		out.AddLine("No heartbeats have been received since the server started")
+		out.AddLine("_Is the avocontrol-utilities mod installed?_")
+		out.Construct()
+		return out, nil
+	}
+
+	var (
+		last     = beats[len(beats)-1]
+		recent   = beats
+		previous []ifaces.Heartbeat
+		maxLag   time.Duration
+	)
+
+	if len(beats) > window {
+		recent = beats[len(beats)-window:]
+		previous = beats[:len(beats)-window]
+		if len(previous) > window {
+			previous = previous[len(previous)-window:]
+		}
+	}
+
+	for _, b := range beats {
+		if b.Lag() > maxLag {
+			maxLag = b.Lag()
+		}
+	}
+
+	out.AddLine(sprintf("Last heartbeat: _%s ago_",
+		time.Since(last.Time).Round(time.Second)))
+	out.AddLine(sprintf("Current tick lag: _%s_", last.Lag().Round(time.Millisecond)))
+	out.AddLine(sprintf("Average tick lag (last %d): _%s_", len(recent),
+		meanLag(recent).Round(time.Millisecond)))
+	out.AddLine(sprintf("Highest tick lag (last %d): _%s_", len(beats),
+		maxLag.Round(time.Millisecond)))
+
+	if len(previous) > 0 {
+		var (
+			cur   = meanLag(recent)
+			prev  = meanLag(previous)
+			trend = "steady"
+		)
+
+		// Ignore jitter of less than 10% or 10ms
+		switch diff := cur - prev; {
+		case diff > prev/10 && diff > 10*time.Millisecond:
+			trend = "rising"
+		case -diff > prev/10 && -diff > 10*time.Millisecond:
+			trend = "falling"
+		}
+
+		out.AddLine(sprintf("Trend: _%s_ (from %s)", trend,
+			prev.Round(time.Millisecond)))
+	}
+
+	out.Construct()
+	return out, nil
+}
+
+func resourcesServerCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out     = newCommandOutput(cmd, "Resource Usage")
+		srv, _  = cmd.Registrar().Server(a, 2)
+		history = srv.ProcessHistory()
+	)
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+
+	if !srv.IsUp() || len(history) == 0 {
+		out.AddLine("No resource usage has been sampled yet")
+		out.Construct()
+		return out, nil
+	}
+
+	// Only summarize the samples of the current process
+	cur := history[len(history)-1]
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].PID != cur.PID {
+			history = history[i+1:]
+			break
+		}
+	}
+
+	var (
+		first  = history[0]
+		minRSS = cur.RSS
+		maxRSS = cur.RSS
+		cpu    = float64(0)
+	)
+
+	for _, p := range history {
+		if p.RSS < minRSS {
+			minRSS = p.RSS
+		}
+		if p.RSS > maxRSS {
+			maxRSS = p.RSS
+		}
+		cpu += p.CPU
+	}
+
+	out.AddLine("**Current:**")
+	out.AddLine(sprintf("CPU: _%.1f%%_", cur.CPU))
+	out.AddLine(sprintf("Memory: _%s_", humanize.IBytes(cur.RSS)))
+	out.AddLine(sprintf("Threads: _%d_", cur.Threads))
+	out.AddLine(sprintf("Open files: _%d_", cur.FDs))
+	out.AddLine("")
+
+	span := cur.Time.Sub(first.Time)
+	out.AddLine(sprintf("**Last %s (%d samples):**", span.Round(time.Minute),
+		len(history)))
+	out.AddLine(sprintf("Average CPU: _%.1f%%_", cpu/float64(len(history))))
+	out.AddLine(sprintf("Memory: _%s_ - _%s_", humanize.IBytes(minRSS),
+		humanize.IBytes(maxRSS)))
+
+	if span >= time.Hour {
+		growth := (float64(cur.RSS) - float64(first.RSS)) / span.Hours()
+		sign := ""
+		if growth < 0 {
+			sign, growth = "-", -growth
+		}
+		out.AddLine(sprintf("Memory growth: _%s%s/hour_", sign,
+			humanize.IBytes(uint64(growth))))
+	}
+
+	out.Construct()
+	return out, nil
+}
+
+func queueServerCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "RCON Command Queue")
+		srv, _ = cmd.Registrar().Server(a, 2)
+		stats  = srv.CommandQueueStats()
+		names  = [ifaces.CommandPriorityCount]string{
+			"Health", "Moderation", "Chat", "Bulk"}
+	)
+
+	out.Description = srv.Config().Galaxy()
+	out.Monospace = true
+	for i, name := range names {
+		out.AddLine(sprintf("%-11s %d queued", name+":", stats.Depth[i]))
+	}
+
+	out.AddLine("")
+	out.AddLine(sprintf("Processed:  %d", stats.Processed))
+	out.AddLine(sprintf("Failed:     %d", stats.Failed))
+	out.AddLine(sprintf("Cancelled:  %d", stats.Cancelled))
+	out.AddLine(sprintf("Stale:      %d", stats.Stale))
+
+	out.Construct()
+	return out, nil
+}
+
+func scheduleServerCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Scheduled Tasks")
+		srv, b = cmd.Registrar().Server(a, 2)
+		op     = "list"
+	)
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+
+	if len(b) > 2 {
+		op = b[2]
+	}
+
+	switch op {
+	case "list":
+		if !HasNumArgs(b[1:], 0, 1) {
+			break
+		}
+
+		tz, err := time.LoadLocation(c.TimeZone())
+		if err != nil {
+			tz = time.Local
+		}
+
+		tasks := srv.Schedule()
+		if len(tasks) == 0 {
+			out.AddLine("There are no scheduled tasks")
+		}
+
+		for _, t := range tasks {
+			line := sprintf("**%s** (%s) `%s`: ", t.Name, t.Action, t.Spec)
+			switch {
+			case t.Next.IsZero():
+				line += "_never_"
+			case t.Skipped:
+				line += sprintf("~~%s~~ _(skipped)_", t.Next.In(tz).Format(scheduleTimeFormat))
+			default:
+				line += t.Next.In(tz).Format(scheduleTimeFormat)
+			}
+
+			if t.Postponed > 0 {
+				line += sprintf(" _(postponed %s)_", t.Postponed.String())
+			}
+			out.AddLine(line)
+		}
+
+		out.Construct()
+		return out, nil
+
+	case "skip":
+		if !HasNumArgs(b[1:], 2, 2) {
+			break
+		}
+
+		if err := srv.SkipScheduled(b[3]); err != nil {
+			return nil, &ErrCommandError{
+				message: err.Error(),
+				cmd:     cmd}
+		}
+
+		out.AddLine(sprintf("Skipping the next run of `%s`", b[3]))
+		out.Construct()
+		return out, nil
+
+	case "postpone":
+		if !HasNumArgs(b[1:], 3, 3) {
+			break
+		}
+
+		mins, err := strconv.Atoi(b[4])
+		if err != nil || mins < 1 {
+			return nil, &ErrInvalidArgument{
+				message: sprintf("`%s` is not a valid number of minutes", b[4]),
+				cmd:     cmd}
+		}
+
+		if err := srv.PostponeScheduled(b[3], time.Duration(mins)*time.Minute); err != nil {
+			return nil, &ErrCommandError{
+				message: err.Error(),
+				cmd:     cmd}
+		}
+
+		out.AddLine(sprintf("Postponed `%s` by %d minutes", b[3], mins))
+		out.Construct()
+		return out, nil
+
+	default:
+		return nil, &ErrInvalidArgument{
+			message: sprintf("`%s` is not a valid operation", op),
+			cmd:     cmd}
+	}
+
+	return nil, &ErrInvalidArgument{
+		message: sprintf("`%s` was passed the wrong number of arguments", cmd.Name()),
+		cmd:     cmd}
+}
+
+func listServersCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	out := newCommandOutput(cmd, "Galaxies")
+	out.Quoted = true
+
+	for i, srv := range cmd.Registrar().Servers() {
+		status, _ := ifaces.State(srv.Status().Status)
+		line := sprintf("**%s**: %s", srv.Config().Galaxy(), status)
+		if i == 0 {
+			line += " _(default)_"
+		}
+		out.AddLine(line)
+	}
+
+	out.Construct()
+	return out, nil
+}
+
+func lockServerCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Server Lock")
+		srv, b = cmd.Registrar().Server(a, 2)
+	)
+
+	if !HasNumArgs(b[1:], 1, 2) {
+		return nil, &ErrInvalidArgument{
+			message: sprintf("`%s` was passed the wrong number of arguments", cmd.Name()),
+			cmd:     cmd}
+	}
+
+	restart := len(b) > 3 && b[3] == "--restart"
+	if len(b) > 3 && !restart {
+		return nil, &ErrInvalidArgument{
+			message: sprintf("Unknown option `%s`", b[3]),
+			cmd:     cmd}
+	}
+
+	// Keep the password out of the channel history
+	if err := s.ChannelMessageDelete(m.ChannelID, m.ID); err != nil {
+		logger.LogWarning(cmd, "Failed to delete the lock command: "+err.Error())
+	}
+
+	srv.SetPassword(b[2])
+	srv.SendLog(ifaces.ChatData{Msg: sprintf("%s was locked by %s",
+		srv.Config().Galaxy(), m.Author.Mention())})
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+	out.AddLine("Players now need a password to join")
+	restartForChanges(out, srv, restart)
+	out.Construct()
+	return out, nil
+}
+
+func unlockServerCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
+	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
+	var (
+		out    = newCommandOutput(cmd, "Server Lock")
+		srv, b = cmd.Registrar().Server(a, 2)
+	)
+
+	if !HasNumArgs(b[1:], 0, 1) {
+		return nil, &ErrInvalidArgument{
+			message: sprintf("`%s` was passed the wrong number of arguments", cmd.Name()),
+			cmd:     cmd}
+	}
+
+	restart := len(b) > 2 && b[2] == "--restart"
+	if len(b) > 2 && !restart {
+		return nil, &ErrInvalidArgument{
+			message: sprintf("Unknown option `%s`", b[2]),
+			cmd:     cmd}
+	}
+
+	out.Description = srv.Config().Galaxy()
+	out.Quoted = true
+
+	if srv.Password() == "" {
+		out.AddLine("The server is not locked")
+		out.Construct()
+		return out, nil
+	}
+
+	srv.SetPassword("")
+	srv.SendLog(ifaces.ChatData{Msg: sprintf("%s was unlocked by %s",
+		srv.Config().Galaxy(), m.Author.Mention())})
+
+	out.AddLine("Players can now join without a password")
+	restartForChanges(out, srv, restart)
+	out.Construct()
+	return out, nil
+}
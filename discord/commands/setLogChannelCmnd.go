@@ -16,6 +16,9 @@ func setLogChannelCmnd(s *discordgo.Session, m *discordgo.MessageCreate,
 		out = newCommandOutput(cmd, "Update Log Channel")
 	)
 
+	srv, a := cmd.Registrar().Server(a, 1)
+	cfg := srv.Config()
+
 	if !HasNumArgs(a, 1, 1) {
 		return nil, &ErrInvalidArgument{
 			message: sprintf(`%s was passed the wrong number of arguments`, cmd.Name()),
@@ -32,8 +35,8 @@ func setLogChannelCmnd(s *discordgo.Session, m *discordgo.MessageCreate,
 	for _, dch := range channels {
 		logger.LogDebug(cmd, sprintf("Checking channel ID %s against %s", dch.ID, a[1]))
 		if dch.ID == a[1] && dch.Type == discordgo.ChannelTypeGuildText {
-			c.SetLogChannel(a[1])
-			c.SaveConfiguration()
+			cfg.SetLogChannel(a[1])
+			cfg.SaveConfiguration()
 			logger.LogInfo(cmd, sprintf(
 				"%s set the log channel to %s", m.Author.String(), dch.ID))
 			out.AddLine(sprintf("Set the game event log to channel %s", dch.Mention()))
@@ -16,6 +16,9 @@ func setStatusChannelCmnd(s *discordgo.Session, m *discordgo.MessageCreate,
 		out = newCommandOutput(cmd, "Update Status Channel")
 	)
 
+	srv, a := cmd.Registrar().Server(a, 1)
+	cfg := srv.Config()
+
 	if !HasNumArgs(a, 1, 1) {
 		return nil, &ErrInvalidArgument{
 			message: sprintf(`%s was passed the wrong number of arguments`, cmd.Name()),
@@ -32,8 +35,8 @@ func setStatusChannelCmnd(s *discordgo.Session, m *discordgo.MessageCreate,
 	for _, dch := range channels {
 		logger.LogDebug(cmd, sprintf("Checking channel ID %s against %s", dch.ID, a[1]))
 		if dch.ID == a[1] && dch.Type == discordgo.ChannelTypeGuildText {
-			c.SetStatusChannel(a[1])
-			c.SaveConfiguration()
+			cfg.SetStatusChannel(a[1])
+			cfg.SaveConfiguration()
 			logger.LogInfo(cmd, sprintf(
 				"%s set the status channel to %s", m.Author.String(), dch.ID))
 			out.AddLine(sprintf("Set the game status to channel %s", dch.Mention()))
@@ -10,9 +10,9 @@ import (
 func statusCmnd(s *discordgo.Session, m *discordgo.MessageCreate, a BotArgs,
 	c ifaces.IConfigurator, cmd *CommandRegistrant) (*CommandOutput, ICommandError) {
 	var (
-		out = newCommandOutput(cmd, "Server Status")
-		reg = cmd.Registrar()
-		srv = reg.server
+		out    = newCommandOutput(cmd, "Server Status")
+		reg    = cmd.Registrar()
+		srv, _ = reg.Server(a, 1)
 
 		ret string
 		err error
@@ -59,7 +59,8 @@ func (d *DataCache) UUID() string {
 
 // UpdateCache updates the internal name cache for nicknames and linked player
 // names. Linked player names takes precedence.
-func (d *DataCache) UpdateCache(s *discordgo.Session, gs ifaces.IGameServer) {
+func (d *DataCache) UpdateCache(s *discordgo.Session,
+	servers []ifaces.IGameServer) {
 	logger.LogInfo(d, "Updating Discord data cache")
 
 	newnamecache := make(map[string]map[string]string, 0)
@@ -83,7 +84,7 @@ GUILDGET:
 		newnamecache[gid] = make(map[string]string, 0)
 		newcolorcache[gid] = make(map[string]CachedColor, 0)
 
-		if gs != nil {
+		for _, gs := range servers {
 			logger.LogDebug(d, "Checking IGameServer players for linked discord users")
 			for _, p := range gs.Players() {
 				if _pdid := p.DiscordUID(); _pdid != "" {
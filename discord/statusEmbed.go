@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
 )
 
 var (
@@ -14,6 +15,7 @@ var (
 	galaxyFieldTemplate    string
 	configOneFieldTemplate string
 	configTwoFieldTemplate string
+	resourceFieldTemplate  string
 )
 
 const (
@@ -47,6 +49,11 @@ func init() {
 		"> **Total Players**:  _%d_\n" +
 		"> **Total Sectors**:  _%d_\n" +
 		"> **Players Online**: _%d_"
+
+	resourceFieldTemplate = "> **CPU**: _%.1f%%_\n" +
+		"> **Memory**: _%s_\n" +
+		"> **Threads**: _%d_\n" +
+		"> **Open Files**: _%d_"
 }
 
 func generateEmbedStatus(s ifaces.ServerStatus, tz *time.Location) *discordgo.MessageEmbed {
@@ -114,6 +121,10 @@ func generateEmbedStatus(s ifaces.ServerStatus, tz *time.Location) *discordgo.Me
 	}
 
 	embed.Title = name + " Status"
+	if s.Locked {
+		embed.Title = "🔒 " + embed.Title
+		statusField.Value += " (Locked)"
+	}
 
 	configOneField.Value = fmt.Sprintf(configOneField.Value, version,
 		seed, ifaces.Difficulty(difLevel), collision, pvpString, blkLimit,
@@ -130,5 +141,13 @@ func generateEmbedStatus(s ifaces.ServerStatus, tz *time.Location) *discordgo.Me
 
 	embed.Fields = append(embed.Fields, statusField, configOneField,
 		configTwoField, galaxyField)
+
+	if s.Process.PID != 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Inline: false, Name: "Resources", Value: fmt.Sprintf(
+				resourceFieldTemplate, s.Process.CPU, humanize.IBytes(s.Process.RSS),
+				s.Process.Threads, s.Process.FDs)})
+	}
+
 	return &embed
 }
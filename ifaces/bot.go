@@ -13,9 +13,9 @@ type IBotMentioner interface {
 	Mention() string
 }
 
-// IBotStarter describes a bot that can start
+// IBotStarter describes a bot that can start, and manage the given servers
 type IBotStarter interface {
-	Start(IGameServer)
+	Start([]IGameServer)
 }
 
 // IBotChatter describes an interface to a bot that can chat
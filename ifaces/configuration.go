@@ -16,7 +16,10 @@ type IConfigurator interface {
 	IEventConfigurator
 	IAuthConfigurator
 	IGameConfigurator
+	IGameINIConfigurator
 	ITimeConfigurator
+	IScheduleConfigurator
+	IBackupConfigurator
 	IChatConfigurator
 	IConfigSaveLoader
 	IModConfigurator
@@ -38,18 +41,52 @@ type IDiscordConfigurator interface {
 
 // IGameConfigurator describes an interface to a games configuration
 type IGameConfigurator interface {
-	RCONBin() string
 	RCONPort() int
 	DataPath() string
 	RCONAddr() string
 	RCONPass() string
+	GamePort() int
+	PingPort() int
 	InstallPath() string
 	LoadGameConfig() error
 	GameConfig() (*ServerGameConfig, bool)
 	PostUpCommand() string
 	PostDownCommand() string
+	Autostart() bool
+	ServerPassword() string
+	SetServerPassword(string) error
+	StageServerPassword(string) error
+	SyncServerPassword() error
+	MOTDList() []string
+	SetMOTDList([]string)
+	MOTDRotation() time.Duration
+	PortAvailable(int) bool
 	HangTimeDuration() time.Duration
 	DBUpdateTimeDuration() time.Duration
+	EventRetention() time.Duration
+	ChatStaleDuration() time.Duration
+	SoftRestartDeadline() time.Duration
+	HeartbeatInterval() time.Duration
+	HeartbeatGrace() (time.Duration, time.Duration)
+	TickLagAlert() time.Duration
+	ResourceSampleDuration() time.Duration
+	ResourceHistorySize() int
+	MemoryAlertThresholds() []uint64
+	CrashReportLines() int
+	CrashLimit() (int, time.Duration)
+	CrashBackoff() (time.Duration, time.Duration)
+}
+
+// IGameINIConfigurator describes an interface to an object that can edit the
+//	server.ini of a galaxy
+type IGameINIConfigurator interface {
+	GameSettings() ([]GameSetting, error)
+	GameSetting(string) (GameSetting, error)
+	StageGameSetting(string, string) (GameSettingChange, error)
+	StagedGameSettings() ([]GameSettingChange, error)
+	DiscardGameSettings() int
+	ApplyGameSettings() ([]GameSettingChange, error)
+	SeedGameConfig(string, string) error
 }
 
 // IGalaxyConfigurator describes an interface to an object that can configure a
@@ -99,6 +136,10 @@ type IAuthConfigurator interface {
 	AddCmndAuth(string, int)
 	GetCmndAuth(string) int
 	RemoveCmndAuth(string) error
+
+	InGameAdminLevel() int
+	LinkedOnly() (bool, string)
+	LinkedOnlyGrace() time.Duration
 }
 
 // IConfigSaveLoader describes an interface to a an object that saves
@@ -116,12 +157,19 @@ type IDatabaseConfigurator interface {
 // IModConfigurator describes an interface to a modconfig builder
 type IModConfigurator interface {
 	BuildModConfig() error
+	ValidateModConfig() ([]error, []string)
 	AddServerMod(int64) error
 	RemoveServerMod(int64) error
 	AddClientMod(int64) error
 	RemoveClientMod(int64) error
 	ListServerMods() []int64
 	ListClientMods() []int64
+	ModCatalog() ([]ModInfo, []error)
+	AddServerModWithDependencies(int64) ([]int64, []string, error)
+	ModUpdateCheck() (string, time.Duration)
+	WorkshopPath() string
+	WorkshopContentPath() string
+	SteamCMDPath() string
 }
 
 // IEventConfigurator describes a configuration object that has LoggedServerEvents
@@ -131,3 +179,15 @@ type IEventConfigurator interface {
 	SetLogChannel(string) chan ChatData
 	LogChannel() string
 }
+
+// IScheduleConfigurator describes a configuration object that has ScheduledTasks
+type IScheduleConfigurator interface {
+	ScheduledTasks() []*ScheduledTask
+}
+
+// IBackupConfigurator describes a configuration object for galaxy backups
+type IBackupConfigurator interface {
+	BackupPath() string
+	BackupRetention() (int, int)
+	BackupAfterSave() bool
+}
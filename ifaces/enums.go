@@ -39,6 +39,25 @@ const (
 	CommandFailure = 1
 	CommandWarning = 2
 
+	// RCON command priorities, from most to least urgent
+	CommandPriorityHealth     = 0
+	CommandPriorityModeration = 1
+	CommandPriorityChat       = 2
+	CommandPriorityBulk       = 3
+	CommandPriorityCount      = 4
+
+	// Actions that can be run by a ScheduledTask
+	ScheduleActionSave    = "save"
+	ScheduleActionStop    = "stop"
+	ScheduleActionStart   = "start"
+	ScheduleActionRestart = "restart"
+	ScheduleActionBackup  = "backup"
+
+	// Ways that workshop mods can be checked for updates
+	ModUpdatesOff      = "off"
+	ModUpdatesLocal    = "local"
+	ModUpdatesSteamCMD = "steamcmd"
+
 	difficultyBeginner = -3
 	difficultyEasy     = -2
 	difficultyNormal   = -1
@@ -2,6 +2,8 @@ package ifaces
 
 import (
 	"avorioncontrol/logger"
+	"context"
+	"time"
 )
 
 // IGameServer describes an interface to a server with full capability
@@ -13,6 +15,17 @@ type IGameServer interface {
 	ILockableServer
 	IPlayableServer
 	IVersionedServer
+	IBackupServer
+	ISeasonServer
+	IAdminSyncServer
+	IAccessServer
+	IModUpdateServer
+	IEventServer
+	IScheduledServer
+	IMonitoredServer
+	IHeartbeatServer
+	ILifecycleServer
+	IPreflightServer
 	ICommandableServer
 	IDiscordIntegratedServer
 }
@@ -24,12 +37,17 @@ type IServer interface {
 	Stop(bool) error
 	Start(bool) error
 	Restart() error
+	SoftRestart(time.Duration) error
+	CancelSoftRestart() bool
+	PendingRestart() (time.Time, bool)
 	Config() IConfigurator
 	Status() ServerStatus
 	CompareStatus(ServerStatus, ServerStatus) bool
 	InitializeEvents()
 	IsCrashed() bool
 	Crashed()
+	Recovered()
+	ClearCrashLoop() bool
 
 	logger.ILogger
 }
@@ -74,12 +92,97 @@ type ILockableServer interface {
 //	game commands
 type ICommandableServer interface {
 	RunCommand(string) (string, error)
+	RunCommandContext(context.Context, int, string) (string, error)
+	CommandQueueStats() CommandQueueStats
+}
+
+// IScheduledServer describes an interface to a server that runs ScheduledTasks
+type IScheduledServer interface {
+	Schedule() []ScheduledTaskStatus
+	ReloadSchedule()
+	SkipScheduled(string) error
+	PostponeScheduled(string, time.Duration) error
+}
+
+// IBackupServer describes an interface to a server that can snapshot and
+//	restore its galaxy
+type IBackupServer interface {
+	Backups() ([]BackupInfo, error)
+	CreateBackup() (BackupInfo, error)
+	RestoreBackup(string) error
+}
+
+// ISeasonServer describes an interface to a server that can replace its galaxy
+//	with a freshly seeded one
+type ISeasonServer interface {
+	NewGalaxy(string, string) (string, error)
+}
+
+// IAdminSyncServer describes an interface to a server that keeps its in-game
+//	admins in line with Discord
+type IAdminSyncServer interface {
+	SyncAdmins(func(string) (bool, error)) ([]AdminChange, error)
+}
+
+// IAccessServer describes an interface to a server that decides which players
+//	are allowed to join
+type IAccessServer interface {
+	SetAccessResolver(func(string) bool)
+	EnforceAccess(IPlayer)
+	AccessList() ([]AccessEntry, error)
+	SetAccess(AccessEntry) error
+	ClearAccess(int64) (bool, error)
+	ResolveSteamID(string) (int64, string, error)
+}
+
+// IModUpdateServer describes an interface to a server that checks its workshop
+//	mods for updates
+type IModUpdateServer interface {
+	CheckModUpdates() ([]int64, error)
+}
+
+// IEventServer describes an interface to a server that reports how its output
+//	is being dispatched to events and their subscribers, and that keeps a record
+//	of its events
+type IEventServer interface {
+	EventStats() ([]EventStats, []SubscriberStats)
+	SearchEvents(GameEventQuery) ([]GameEvent, error)
+	Events() []EventInfo
+	TestEvent(string) (EventMatch, bool)
+}
+
+// IPreflightServer describes an interface to a server that can check that it is
+//	able to start
+type IPreflightServer interface {
+	Preflight() PreflightReport
+}
+
+// ILifecycleServer describes an interface to a server that records its
+//	lifecycle state transitions
+type ILifecycleServer interface {
+	StateHistory() []StateTransition
+	SubscribeState() (<-chan StateTransition, func())
+}
+
+// IHeartbeatServer describes an interface to a server that receives heartbeats
+//	from its game loop
+type IHeartbeatServer interface {
+	Heartbeat(Heartbeat)
+	Heartbeats() []Heartbeat
+	CheckHang() error
+}
+
+// IMonitoredServer describes an interface to a server that tracks the resource
+//	usage of its process
+type IMonitoredServer interface {
+	ProcessHistory() []ProcessStats
 }
 
 // IMOTDServer describes an interface to a server that can set an MOTD
 type IMOTDServer interface {
 	MOTD() string
 	SetMOTD(string)
+	RotateMOTD()
 }
 
 // ISeededServer is an interface to an object that has a seed
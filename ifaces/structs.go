@@ -10,6 +10,20 @@ type ChatData struct {
 	Name string
 	UID  string
 	Msg  string
+
+	// Files are uploaded alongside the message
+	Files []ChatFile
+
+	// Logs can be sent to a channel other than the log channel, and mention a
+	// role
+	Channel     string
+	MentionRole string
+}
+
+// ChatFile describes a file attached to ChatData
+type ChatFile struct {
+	Name    string
+	Content []byte
 }
 
 // JumpInfo describes a ship jump
@@ -52,8 +66,127 @@ type ServerStatus struct {
 	PlayersOnline int
 	Alliances     int
 	Sectors       int
+	Locked        bool
+
+	Process ProcessStats
+	INI     *ServerGameConfig
+}
+
+// AdminChange describes a player that was added to or removed from the in-game
+// admin list
+type AdminChange struct {
+	Index   string
+	Name    string
+	Discord string
+	Added   bool
+}
+
+// Integration describes a player that has linked their Discord account
+type Integration struct {
+	Index   string
+	Name    string
+	Discord string
+}
+
+// AccessEntry describes a player that is explicitly allowed or denied access
+// to a server
+type AccessEntry struct {
+	SteamID int64
+	Name    string
+	Allowed bool
+}
+
+// GameSetting describes a setting in the server.ini of a galaxy
+type GameSetting struct {
+	Key      string // Section.Key
+	Value    string
+	ReadOnly bool
+}
 
-	INI *ServerGameConfig
+// GameSettingChange describes a change to a setting in server.ini
+type GameSettingChange struct {
+	Key string
+	Old string
+	New string
+}
+
+// PreflightCheck is the result of a single check made before starting a server
+type PreflightCheck struct {
+	Name    string
+	Passed  bool
+	Message string
+}
+
+// PreflightReport is the result of the checks made before starting a server
+type PreflightReport struct {
+	Time   time.Time
+	Checks []PreflightCheck
+}
+
+// Passed returns whether or not every check passed
+func (r PreflightReport) Passed() bool {
+	for _, c := range r.Checks {
+		if !c.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Failed returns the checks that did not pass
+func (r PreflightReport) Failed() []PreflightCheck {
+	failed := make([]PreflightCheck, 0)
+	for _, c := range r.Checks {
+		if !c.Passed {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
+
+// StateTransition describes a change in the lifecycle state of a server, using
+// the Server* state constants
+type StateTransition struct {
+	From   int       `json:"from"`
+	To     int       `json:"to"`
+	Time   time.Time `json:"time"`
+	Reason string    `json:"reason"`
+}
+
+// Heartbeat describes a heartbeat sent from the game loop of a server
+type Heartbeat struct {
+	Time     time.Time
+	Interval time.Duration // Requested time between heartbeats
+	Elapsed  time.Duration // Actual time since the previous heartbeat
+}
+
+// Lag returns how late the heartbeat was
+func (h Heartbeat) Lag() time.Duration {
+	if h.Elapsed < h.Interval {
+		return 0
+	}
+	return h.Elapsed - h.Interval
+}
+
+// ProcessStats describes the resource usage of a game server process
+type ProcessStats struct {
+	Time time.Time
+	PID  int
+
+	CPU     float64 // Percentage of a single core
+	RSS     uint64  // Bytes
+	Threads int
+	FDs     int
+}
+
+// CommandQueueStats describes the current state of a servers RCON command
+//	queue
+type CommandQueueStats struct {
+	Depth     [CommandPriorityCount]int
+	Processed int64
+	Failed    int64
+	Cancelled int64
+	Stale     int64
 }
 
 // ServerGameConfig describes an object that contains the current
@@ -81,4 +214,135 @@ type LoggedServerEvent struct {
 	Name    string
 	FString string
 	Regex   *regexp.Regexp
+
+	// The actions below run when the event matches, at most once per Cooldown.
+	// Templates are expanded with the groups of Regex ($1, ${name}), ${message},
+	// ${event} and ${galaxy}.
+	Cooldown    time.Duration
+	Channel     string // Posts the message here instead of the log channel
+	MentionRole string
+	RCON        string
+	MailTo      string
+	MailSubject string
+	Mail        string
+	Webhook     string
+}
+
+// ScheduledTask describes a server action that is run on a cron-like schedule
+type ScheduledTask struct {
+	Name   string
+	Galaxy string
+	Action string
+	Spec   string
+
+	// Restarts wait for the server to be empty
+	Empty bool
+
+	// Players are notified this long before the task runs
+	Warnings []time.Duration
+}
+
+// ScheduledTaskStatus describes the upcoming run of a ScheduledTask
+type ScheduledTaskStatus struct {
+	Name   string
+	Action string
+	Spec   string
+
+	Next      time.Time
+	Skipped   bool
+	Postponed time.Duration
+}
+
+// BackupInfo describes a compressed snapshot of a galaxy
+type BackupInfo struct {
+	Name     string
+	Time     time.Time
+	Size     int64
+	Checksum string
+}
+
+// ModInfo describes a mod, as read from its modinfo.lua
+type ModInfo struct {
+	ID      string
+	Name    string
+	Title   string
+	Version string
+	Path    string
+	Local   bool
+
+	ServerSideOnly bool
+	ClientSideOnly bool
+	Dependencies   []ModDependency
+}
+
+// ModDependency is a mod that another mod requires, optionally supports, or is
+// incompatible with
+type ModDependency struct {
+	ID           string
+	Min          string
+	Max          string
+	Optional     bool
+	Incompatible bool
+}
+
+// EventStats describes how often a server event was tried against a line of
+// output, how often it matched, and how long its regex took in total
+type EventStats struct {
+	Name       string
+	Candidates uint64
+	Matches    uint64
+	MatchTime  time.Duration
+}
+
+// EventInfo describes an event that lines of output are dispatched to
+type EventInfo struct {
+	Name    string
+	Kind    string // Empty for events that aren't published
+	Regex   string
+	FString string // The message of an event that is defined in the configuration
+}
+
+// EventGroup is a group that an event captured from a line of output. Name is
+// empty for groups that aren't named.
+type EventGroup struct {
+	Name  string
+	Value string
+}
+
+// EventMatch describes the event that a line of output would be dispatched to
+type EventMatch struct {
+	Event   string
+	Kind    string
+	Groups  []EventGroup
+	Payload string // The payload that would be published, as JSON
+}
+
+// SubscriberStats describes how well a subscriber to the events of a server is
+// keeping up
+type SubscriberStats struct {
+	Name      string
+	Queued    int
+	Delivered uint64
+	Dropped   uint64
+}
+
+// GameEvent is an event that was recorded in the tracking DB of a galaxy
+type GameEvent struct {
+	Time    time.Time
+	Type    string
+	Faction int64  // The index of the player involved, or 0
+	Name    string // The name of the player involved, if any
+	Message string // A readable summary of the event
+	Payload string // The payload of the event as JSON
+}
+
+// GameEventQuery filters the events that are searched for in the tracking DB.
+// Zero values don't filter anything.
+type GameEventQuery struct {
+	Types   []string
+	Faction int64
+	Name    string
+	Since   time.Time
+	Until   time.Time
+	Limit   int
 }
@@ -15,16 +15,28 @@ import (
 	"syscall"
 )
 
+// signalHelp describes the signals that are handled, for the usage text
+const signalHelp = `
+Signals:
+  SIGINT, SIGTERM  Stop every galaxy and exit
+  SIGUSR1          Reload the configuration, along with the events and schedules
+                   of every galaxy. Running galaxies are no longer restarted, so
+                   restart them from Discord for changes that need it. Galaxies
+                   that were added are only started once the bot is restarted.
+  SIGUSR2          Stop every galaxy and reload the configuration
+`
+
 var (
-	showhelp bool
-	loglevel int
-	token    string
-	prefix   string
-
-	config *configuration.Conf
-	server ifaces.IGameServer
-	disbot ifaces.IDiscordBot
-	core   *Core
+	showhelp    bool
+	maintenance bool
+	loglevel    int
+	token       string
+	prefix      string
+
+	config  *configuration.Conf
+	servers []ifaces.IGameServer
+	disbot  ifaces.IDiscordBot
+	core    *Core
 )
 
 func init() {
@@ -33,6 +45,8 @@ func init() {
 
 	flag.IntVar(&loglevel, "l", 0, "Log level")
 	flag.BoolVar(&showhelp, "h", false, "Show help text")
+	flag.BoolVar(&maintenance, "m", false,
+		"Maintenance mode (start the bot without starting Avorion)")
 	flag.StringVar(&token, "t", "", "Bot token")
 	flag.StringVar(&configFile, "c", "", "Configuration file")
 	flag.Parse()
@@ -43,6 +57,7 @@ func init() {
 
 	flag.Usage = func() {
 		flag.PrintDefaults()
+		fmt.Fprint(flag.CommandLine.Output(), signalHelp)
 	}
 }
 
@@ -75,32 +90,53 @@ func main() {
 	exit := make(chan struct{})
 
 	core = &Core{loglevel: config.Loglevel()}
-	server = avorion.New(config, &wg, exit)
+	servers = append(servers, avorion.New(config, &wg, exit))
+	for _, galaxy := range config.Galaxies() {
+		servers = append(servers, avorion.New(galaxy, &wg, exit))
+	}
 	disbot = discord.New(config, &wg, exit)
 
 	// We start this early to prevent an errant os.Interrupt from leaving the
 	// AvorionServer process running.
 	signal.Notify(sc)
-	disbot.Start(server)
+	disbot.Start(servers)
 
 	// FIXME: This needs to be handled on the object level
 	defer func() {
 		if r := recover(); r != nil {
 			fmt.Printf("Panic Caught: %v", r)
-			if server.IsUp() {
-				fmt.Printf("Attempting to shut down Avorion safely...\n")
-				if err := server.Stop(true); err != nil {
-					logger.LogError(server, err.Error())
+			for _, server := range servers {
+				if server.IsUp() {
+					fmt.Printf("Attempting to shut down %s safely...\n",
+						server.Config().Galaxy())
+					if err := server.Stop(true); err != nil {
+						logger.LogError(server, err.Error())
+					}
+					fmt.Printf("%s stopped gracefully.\n", server.Config().Galaxy())
 				}
-				fmt.Printf("Avorion stopped gracefully.")
 			}
 			os.Exit(1)
 		}
 	}()
 
-	if err := server.Start(true); err != nil {
-		logger.LogError(core, "Avorion: "+err.Error())
-		os.Exit(1)
+	if maintenance {
+		logger.LogInit(core, "Maintenance mode, not starting Avorion")
+	}
+
+	// A server that fails to start is left offline, so that it can be fixed and
+	// started from Discord
+	for _, server := range servers {
+		if maintenance || !server.Config().Autostart() {
+			logger.LogInit(server, "Autostart is disabled, leaving Avorion offline")
+			continue
+		}
+
+		if err := server.Start(true); err != nil {
+			logger.LogError(core, "Avorion: "+err.Error())
+			server.SendLog(ifaces.ChatData{Msg: fmt.Sprintf(
+				"**Failed to start %s:** %s\nUse `server start` once the problem "+
+					"has been fixed", server.Config().Galaxy(), err.Error())})
+		}
 	}
 
 	logger.LogInit(core, "Completed init, awaiting termination signal.")
@@ -114,18 +150,43 @@ func main() {
 			os.Exit(0)
 
 		case syscall.SIGUSR1:
-			logger.LogInfo(core, "Caught SIGUSR1, performing server reload+restart")
-			config.LoadConfiguration()
-			if err := server.Restart(); err != nil {
-				logger.LogError(server, err.Error())
+			logger.LogInfo(core, "Caught SIGUSR1, reloading the configuration "+
+				"without restarting any galaxies")
+			if err := config.LoadConfiguration(); err != nil {
+				logger.LogError(core, err.Error())
+				continue
 			}
 
+			reloadServers()
+
 		case syscall.SIGUSR2:
 			logger.LogInfo(core, "Caught SIGUSR2, performing stopping Avorion")
-			if err := server.Stop(true); err != nil {
-				logger.LogError(server, err.Error())
+			for _, server := range servers {
+				if err := server.Stop(true); err != nil {
+					logger.LogError(server, err.Error())
+				}
 			}
 			config.LoadConfiguration()
 		}
 	}
 }
+
+// reloadServers swaps in the events and schedules of every galaxy after the
+// configuration was reloaded, while Avorion keeps running. Galaxies that were
+// added to the configuration don't have a server until the bot is restarted.
+func reloadServers() {
+	running := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		server.InitializeEvents()
+		server.ReloadSchedule()
+		running[server.Config().Galaxy()] = true
+	}
+
+	for _, galaxy := range config.Galaxies() {
+		if !running[galaxy.Galaxy()] {
+			logger.LogWarning(core, fmt.Sprintf("Galaxy %s was added to the "+
+				"configuration, and will be started on the next restart of the bot",
+				galaxy.Galaxy()))
+		}
+	}
+}
@@ -0,0 +1,39 @@
+--[[
+
+  AvorionControl - data/scripts/commands/setheartbeat.lua
+  -------------------------------------------------------
+
+  This command is for use by the bot, and is used to set the number of seconds
+  between the heartbeats printed by the game loop.
+
+  License: BSD-3-Clause
+  https://opensource.org/licenses/BSD-3-Clause
+
+]]
+
+package.path = package.path .. ";data/scripts/lib/?.lua"
+include("avocontrol-utils")
+
+function execute(user, cmd, interval)
+  if type(user) ~= "nil" then
+    return 1, "\\c(f00)Do not run this please.", ""
+  end
+
+  interval = tonumber(interval)
+  if not interval or interval <= 0 then
+    return 1, "Invalid heartbeat interval", ""
+  end
+
+  if not SetConfigData("Heartbeat", {interval = interval}) then
+    return 1, "Failed to update data", ""
+  end
+
+  return 0, "Updated heartbeat interval", ""
+end
+
+function getDescription()
+  return "(Bot only) This sets the interval between game loop heartbeats"
+end
+
+function getHelp()
+end
@@ -0,0 +1,37 @@
+--[[
+
+  AvorionControl - data/scripts/commands/setmotd.lua
+  --------------------------------------------------
+
+  This command is for use by the bot, and is used to set the message of the day
+  that players are shown when they log in. Running it without a message clears
+  the message of the day.
+
+  License: BSD-3-Clause
+  https://opensource.org/licenses/BSD-3-Clause
+
+]]
+
+package.path = package.path .. ";data/scripts/lib/?.lua"
+include("avocontrol-utils")
+
+function execute(user, cmd, ...)
+  if type(user) ~= "nil" then
+    return 1, "\\c(f00)Do not run this please.", ""
+  end
+
+  local motd = table.concat({...}, " ")
+
+  if not SetConfigData("MOTD", {message = motd}) then
+    return 1, "Failed to update data", ""
+  end
+
+  return 0, "Updated message of the day", ""
+end
+
+function getDescription()
+  return "(Bot only) This sets the message of the day shown to players on login"
+end
+
+function getHelp()
+end
@@ -0,0 +1,44 @@
+--[[
+
+  AvorionControl - data/scripts/galaxy/avocontrol-heartbeat.lua
+  -------------------------------------------------------------
+
+  Print a heartbeat from the game loop, so that the bot can tell a hanging
+  server apart from one whose RCON thread is still responding. The interval
+  is set by the bot using the setheartbeat command.
+
+  Output: serverHeartbeatEvent: <interval ms> <elapsed ms>
+
+  License: BSD-3-Clause
+  https://opensource.org/licenses/BSD-3-Clause
+
+]]
+
+package.path = package.path .. ";data/scripts/lib/?.lua"
+include("avocontrol-utils")
+
+-- namespace AvorionControlHeartbeat
+AvorionControlHeartbeat = {}
+
+local defaultInterval = 10
+local interval = defaultInterval
+local last = nil
+
+function AvorionControlHeartbeat.getUpdateInterval()
+  return interval
+end
+
+function AvorionControlHeartbeat.update(timeStep)
+  local now = appTimeMs()
+  local elapsed = (last and now - last or timeStep * 1000)
+  last = now
+
+  print("serverHeartbeatEvent: ${i} ${e}"%_T % {
+    i=math.floor(interval * 1000), e=math.floor(elapsed)})
+
+  -- Pick up changes made by the bot for the next heartbeat
+  local data = FetchConfigData("Heartbeat", {interval = "number"})
+  if data.interval and data.interval > 0 then
+    interval = data.interval
+  end
+end
@@ -3,17 +3,26 @@
   AvorionControl - data/scripts/galaxy/server.lua
   -----------------------------------------------
 
-  Add player LogIn/Off event output and bot related scripts
+  Add player LogIn/Off event output, the message of the day, the game loop
+  heartbeat, and bot related scripts
 
   License: BSD-3-Clause
   https://opensource.org/licenses/BSD-3-Clause
 
 ]]
 
+package.path = package.path .. ";data/scripts/lib/?.lua"
+include("avocontrol-utils")
+
 -- Create our own login event output for more reliable tracking
 function onPlayerLogIn_AvoControl(playerIndex)
   local p = Player(playerIndex)
   print("playerJoinEvent: ${i} ${n}"%_T % {i=p.index, n=p.name})
+
+  local motd = FetchConfigData("MOTD", {message = "string"}).message
+  if motd and motd ~= "" then
+    p:sendChatMessage("Server", ChatMessageType.Normal, motd)
+  end
 end
 
 function onPlayerLogOff_AvoControl(playerIndex)
@@ -22,4 +31,6 @@ function onPlayerLogOff_AvoControl(playerIndex)
 end
 
 Server():registerCallback("onPlayerLogIn", "onPlayerLogIn_AvoControl")
-Server():registerCallback("onPlayerLogOff", "onPlayerLogOff_AvoControl")
\ No newline at end of file
+Server():registerCallback("onPlayerLogOff", "onPlayerLogOff_AvoControl")
+
+Galaxy():addScriptOnce("data/scripts/galaxy/avocontrol-heartbeat.lua")
\ No newline at end of file